@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"regexp"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+var (
+	// cgroup v1 paths end with the container id, e.g. /docker/<id> or
+	// /kubepods/.../cri-containerd-<id>.scope
+	cgroupV1Regexp = regexp.MustCompile(`[/-]([0-9a-f]{64})(?:\.scope)?$`)
+	// cgroup v2 hides the id from /proc/self/cgroup, but files of the
+	// container are mounted from the directory of the container runtime,
+	// e.g. /var/lib/docker/containers/<id>/hostname
+	cgroupV2Regexp = regexp.MustCompile(`/containers/(?:overlay-containers/)?([0-9a-f]{64})/`)
+)
+
+type containerDetector struct {
+	cgroupPath    string
+	mountInfoPath string
+}
+
+// Container returns a detector of the container id, which supports both
+// cgroup v1 and v2
+func Container() resource.Detector {
+	return containerDetector{
+		cgroupPath:    "/proc/self/cgroup",
+		mountInfoPath: "/proc/self/mountinfo",
+	}
+}
+
+func (d containerDetector) Detect(context.Context) (*resource.Resource, error) {
+	id := findInLines(d.cgroupPath, cgroupV1Regexp)
+	if id == "" {
+		id = findInLines(d.mountInfoPath, cgroupV2Regexp)
+	}
+	if id == "" {
+		return resource.Empty(), nil
+	}
+	return resource.NewSchemaless(semconv.ContainerID(id)), nil
+}
+
+// findInLines returns the first submatch of re in lines of the file, or an
+// empty string if the file is not readable, e.g. not running on Linux
+func findInLines(path string, re *regexp.Regexp) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := re.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detector provides resource detectors of the instrumented binary that
+// are not covered by the sdk, i.e. the default service name, the container id
+// in both cgroup v1 and v2, Kubernetes attributes exposed by the downward API
+// and attributes detected by the otel tool at build time.
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+type serviceNameDetector struct{}
+
+// ServiceName returns a detector that names the service after the binary, it
+// is overwritten by OTEL_SERVICE_NAME or service.name in
+// OTEL_RESOURCE_ATTRIBUTES if any.
+func ServiceName() resource.Detector {
+	return serviceNameDetector{}
+}
+
+func (serviceNameDetector) Detect(context.Context) (*resource.Resource, error) {
+	path, err := os.Executable()
+	if err != nil || path == "" {
+		path = os.Args[0]
+	}
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	if name == "" || name == "." {
+		return resource.Empty(), nil
+	}
+	return resource.NewSchemaless(semconv.ServiceName(name)), nil
+}
+
+type buildDetector map[string]string
+
+// Build returns a detector of attributes detected at build time, e.g. the
+// revision and the version of the main module
+func Build(attrs map[string]string) resource.Detector {
+	return buildDetector(attrs)
+}
+
+func (d buildDetector) Detect(context.Context) (*resource.Resource, error) {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, d[k]))
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+func detect(t *testing.T, d resource.Detector) map[attribute.Key]string {
+	t.Helper()
+	res, err := d.Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[attribute.Key]string{}
+	for _, attr := range res.Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+	return attrs
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainer(t *testing.T) {
+	const id = "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	cases := []struct {
+		name, cgroup, mountInfo, expect string
+	}{
+		{"docker v1", "12:pids:/docker/" + id + "\n", "", id},
+		{"containerd v1", "1:name=systemd:/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + id + ".scope\n", "", id},
+		{"docker v2", "0::/\n", "1 2 0:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw\n", id},
+		{"podman v2", "0::/\n", "1 2 0:1 /containers/overlay-containers/" + id + "/userdata/hostname /etc/hostname rw\n", id},
+		{"host", "0::/init.scope\n", "1 2 0:1 / / rw\n", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			d := containerDetector{
+				cgroupPath:    filepath.Join(dir, "cgroup"),
+				mountInfoPath: filepath.Join(dir, "mountinfo"),
+			}
+			writeFile(t, d.cgroupPath, c.cgroup)
+			writeFile(t, d.mountInfoPath, c.mountInfo)
+			got := detect(t, d)[semconv.ContainerIDKey]
+			if got != c.expect {
+				t.Fatalf("expect container id %q, got %q", c.expect, got)
+			}
+		})
+	}
+}
+
+func TestK8s(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "uid"), "pod-uid\n")
+	nsFile := filepath.Join(dir, "sa-namespace")
+	writeFile(t, nsFile, "prod")
+	d := k8sDetector{
+		podInfoDir: dir,
+		nsFile:     nsFile,
+		hostname:   func() (string, error) { return "web-0", nil },
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if attrs := detect(t, d); len(attrs) != 0 {
+		t.Fatalf("expect nothing outside Kubernetes, got %v", attrs)
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("NODE_NAME", "node-1")
+	attrs := detect(t, d)
+	expect := map[attribute.Key]string{
+		semconv.K8SPodNameKey:       "web-0",
+		semconv.K8SPodUIDKey:        "pod-uid",
+		semconv.K8SNamespaceNameKey: "prod",
+		semconv.K8SNodeNameKey:      "node-1",
+	}
+	for k, v := range expect {
+		if attrs[k] != v {
+			t.Errorf("expect %s=%q, got %q", k, v, attrs[k])
+		}
+	}
+
+	t.Setenv("K8S_POD_NAME", "web-1")
+	if got := detect(t, d)[semconv.K8SPodNameKey]; got != "web-1" {
+		t.Fatalf("expect pod name from env, got %q", got)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	path, _ := os.Executable()
+	got := detect(t, ServiceName())[semconv.ServiceNameKey]
+	if got == "" || got != filepath.Base(path) {
+		t.Fatalf("expect service name %q, got %q", filepath.Base(path), got)
+	}
+}
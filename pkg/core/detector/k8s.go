@@ -0,0 +1,116 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+const (
+	// K8sPodInfoDir overwrites the directory where the downward API volume
+	// is mounted
+	K8sPodInfoDir        = "OTEL_K8S_PODINFO_DIR"
+	defaultPodInfoDir    = "/etc/podinfo"
+	serviceAccountNsFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// k8sSource tells where an attribute is exposed by the downward API, the
+// environment variables are tried in order before the file in the downward
+// API volume
+type k8sSource struct {
+	key  attribute.Key
+	envs []string
+	file string
+}
+
+var k8sSources = []k8sSource{
+	{semconv.K8SPodNameKey, []string{"K8S_POD_NAME", "POD_NAME"}, "name"},
+	{semconv.K8SPodUIDKey, []string{"K8S_POD_UID", "POD_UID"}, "uid"},
+	{semconv.K8SNamespaceNameKey, []string{"K8S_NAMESPACE_NAME", "POD_NAMESPACE"}, "namespace"},
+	{semconv.K8SNodeNameKey, []string{"K8S_NODE_NAME", "NODE_NAME"}, "nodename"},
+	{semconv.K8SContainerNameKey, []string{"K8S_CONTAINER_NAME"}, ""},
+}
+
+type k8sDetector struct {
+	podInfoDir string
+	nsFile     string
+	hostname   func() (string, error)
+}
+
+// K8s returns a detector of Kubernetes attributes, which are read from the
+// environment variables and the volume of the downward API. The pod name and
+// the namespace fall back to the hostname and the namespace of the service
+// account, as they are always available in a pod.
+func K8s() resource.Detector {
+	dir := os.Getenv(K8sPodInfoDir)
+	if dir == "" {
+		dir = defaultPodInfoDir
+	}
+	return k8sDetector{
+		podInfoDir: dir,
+		nsFile:     serviceAccountNsFile,
+		hostname:   os.Hostname,
+	}
+}
+
+func (d k8sDetector) Detect(context.Context) (*resource.Resource, error) {
+	// Kubernetes injects the service host into every container
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return resource.Empty(), nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(k8sSources))
+	for _, src := range k8sSources {
+		val := d.lookup(src)
+		if val == "" {
+			switch src.key {
+			case semconv.K8SPodNameKey:
+				val, _ = d.hostname()
+			case semconv.K8SNamespaceNameKey:
+				val = readTrimmed(d.nsFile)
+			}
+		}
+		if val != "" {
+			attrs = append(attrs, src.key.String(val))
+		}
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
+
+func (d k8sDetector) lookup(src k8sSource) string {
+	for _, env := range src.envs {
+		if val := os.Getenv(env); val != "" {
+			return val
+		}
+	}
+	if src.file == "" {
+		return ""
+	}
+	return readTrimmed(filepath.Join(d.podInfoDir, src.file))
+}
+
+func readTrimmed(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package enabler switches instrumentations at runtime, through a local admin
+// endpoint or a watched config file, so that a noisy instrumentation can be
+// turned off without restarting the application. Neither of them starts unless
+// runtime switches are enabled explicitly.
+package enabler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
+)
+
+const (
+	// RuntimeSwitch must be true for the admin endpoint and the config file
+	// watcher to start, otherwise nothing listens or polls even if they are
+	// configured
+	RuntimeSwitch = "OTEL_INSTRUMENTATION_RUNTIME_SWITCH_ENABLED"
+	// AdminAddr is the address the admin endpoint listens on, e.g. 9465 or
+	// :9465, which listen on the loopback address as the endpoint is not
+	// authenticated, the host must be given to listen elsewhere. The endpoint
+	// is disabled if it's not set.
+	AdminAddr = "OTEL_INSTRUMENTATION_ADMIN_ADDR"
+	// ConfigFile is the JSON file mapping instrumentation names or patterns to
+	// whether they are enabled, e.g. {"redis*": false}
+	ConfigFile = "OTEL_INSTRUMENTATION_CONFIG_FILE"
+	// AdminPath is where the admin endpoint serves instrumentations
+	AdminPath = "/instrumentations"
+
+	pollInterval = 5 * time.Second
+)
+
+var (
+	mu     sync.Mutex
+	stop   chan struct{}
+	server *http.Server
+)
+
+// Start starts the admin endpoint and the config file watcher if runtime
+// switches are enabled and they are configured
+func Start() {
+	if enabled, _ := strconv.ParseBool(os.Getenv(RuntimeSwitch)); !enabled {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if stop != nil {
+		return
+	}
+	stop = make(chan struct{})
+	if path := os.Getenv(ConfigFile); path != "" {
+		w := &watcher{path: path}
+		w.poll()
+		go w.run(stop)
+	}
+	if addr := os.Getenv(AdminAddr); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(AdminPath, Handler())
+		server = &http.Server{Addr: adminAddr(addr), Handler: mux}
+		go func(s *http.Server) {
+			err := s.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				log.Printf("Failed to serve the instrumentation admin endpoint: %v", err)
+			}
+		}(server)
+	}
+}
+
+// Stop stops the admin endpoint and the config file watcher, states of
+// instrumentations are left as they are
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	stop = nil
+	if server != nil {
+		_ = server.Close()
+		server = nil
+	}
+}
+
+// adminAddr returns the address to listen on, the host defaults to the
+// loopback address rather than all addresses
+func adminAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// A bare port
+		return net.JoinHostPort("127.0.0.1", addr)
+	}
+	if host == "" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return addr
+}
+
+// matchInstrumentations returns the names of registered instrumentations that
+// match the pattern
+func matchInstrumentations(pattern string) ([]string, error) {
+	names := make([]string, 0)
+	for name := range instrumenter.InstrumentEnablerStates() {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+		if matched {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Handler returns the handler of the admin endpoint:
+//
+//	GET    lists whether each instrumentation is enabled
+//	POST   ?name=<name or pattern>&enabled=<bool> switches instrumentations,
+//	       the name must match at least one registered instrumentation
+//	DELETE switches all instrumentations back to their initial states
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			name := r.URL.Query().Get("name")
+			enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+			if name == "" || err != nil {
+				http.Error(w, "expect name and enabled", http.StatusBadRequest)
+				return
+			}
+			names, err := matchInstrumentations(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if len(names) == 0 {
+				http.Error(w, fmt.Sprintf("no instrumentation matches %q", name),
+					http.StatusNotFound)
+				return
+			}
+			names, err = instrumenter.SetInstrumentEnabled(name, enabled)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Instrumentations %v are switched to enabled=%v", names,
+				enabled)
+		case http.MethodDelete:
+			instrumenter.ResetInstrumentEnablers()
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(instrumenter.InstrumentEnablerStates())
+	})
+}
+
+// watcher applies the config file whenever it changes, changes made through
+// the admin endpoint are discarded then
+type watcher struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// run polls the config file until stop is closed
+func (w *watcher) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *watcher) poll() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		if !w.modTime.IsZero() {
+			// The file is removed, switch back to initial states
+			instrumenter.ResetInstrumentEnablers()
+			w.modTime, w.size = time.Time{}, 0
+		}
+		return
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+	content, err := os.ReadFile(w.path)
+	if err == nil {
+		err = Apply(content)
+	}
+	if err != nil {
+		log.Printf("Failed to apply the instrumentation config %s: %v",
+			w.path, err)
+	}
+}
+
+// Apply switches instrumentations by the JSON config, instrumentations that
+// are not mentioned are switched back to their initial states. Patterns are
+// applied before exact names, so that {"*": false, "nethttp": true} keeps
+// only nethttp enabled. The config is applied as a whole, a broken one leaves
+// all instrumentations untouched.
+func Apply(content []byte) error {
+	states := map[string]bool{}
+	err := json.Unmarshal(content, &states)
+	if err != nil {
+		return err
+	}
+	overrides := make([]instrumenter.InstrumentOverride, 0, len(states))
+	for pattern, enabled := range states {
+		overrides = append(overrides, instrumenter.InstrumentOverride{
+			Pattern: pattern,
+			Enabled: enabled,
+		})
+	}
+	// Later overrides take precedence
+	sort.Slice(overrides, func(i, j int) bool {
+		pi, pj := overrides[i].Pattern, overrides[j].Pattern
+		wi := strings.ContainsAny(pi, "*?[")
+		wj := strings.ContainsAny(pj, "*?[")
+		if wi != wj {
+			return wi
+		}
+		return pi < pj
+	})
+	return instrumenter.ReplaceInstrumentEnablers(overrides)
+}
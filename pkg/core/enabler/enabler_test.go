@@ -0,0 +1,170 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enabler
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
+)
+
+func TestHandler(t *testing.T) {
+	t.Cleanup(instrumenter.ResetInstrumentEnablers)
+	redis := instrumenter.RegisterInstrumentEnabler("redisv9", "")
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	states := func(method, query string, status int) map[string]bool {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+"?"+query, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s: expect status %d, got %d", method, query, status,
+				resp.StatusCode)
+		}
+		result := map[string]bool{}
+		if status == http.StatusOK {
+			if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return result
+	}
+	if !states(http.MethodGet, "", http.StatusOK)["redisv9"] {
+		t.Fatal("expect redisv9 to be enabled initially")
+	}
+	if states(http.MethodPost, "name=redis*&enabled=false", http.StatusOK)["redisv9"] ||
+		redis.Enable() {
+		t.Fatal("expect redisv9 to be disabled")
+	}
+	states(http.MethodPost, "name=redis*", http.StatusBadRequest)
+	states(http.MethodPost, "name=[&enabled=true", http.StatusBadRequest)
+	states(http.MethodPost, "name=mysql&enabled=false", http.StatusNotFound)
+	states(http.MethodPatch, "", http.StatusMethodNotAllowed)
+	if !states(http.MethodDelete, "", http.StatusOK)["redisv9"] || !redis.Enable() {
+		t.Fatal("expect redisv9 to be enabled after reset")
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	t.Cleanup(instrumenter.ResetInstrumentEnablers)
+	redis := instrumenter.RegisterInstrumentEnabler("redisv8", "")
+	nethttp := instrumenter.RegisterInstrumentEnabler("nethttp", "")
+	file := filepath.Join(t.TempDir(), "instrumentations.json")
+	w := &watcher{path: file}
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		w.poll()
+	}
+
+	// Exact names take precedence over patterns regardless of the order
+	now := time.Now()
+	write(`{"nethttp": true, "*": false}`, now)
+	if redis.Enable() || !nethttp.Enable() {
+		t.Fatalf("unexpected states %v", instrumenter.InstrumentEnablerStates())
+	}
+	// Changes made elsewhere are discarded once the file changes
+	_, _ = instrumenter.SetInstrumentEnabled("nethttp", false)
+	write(`{"redisv8": false}`, now.Add(time.Second))
+	if redis.Enable() || !nethttp.Enable() {
+		t.Fatalf("unexpected states %v", instrumenter.InstrumentEnablerStates())
+	}
+	// A broken file leaves the states untouched
+	write(`{`, now.Add(2*time.Second))
+	if redis.Enable() {
+		t.Fatal("expect redisv8 to stay disabled")
+	}
+	write(`{"nethttp": false, "[": false}`, now.Add(3*time.Second))
+	if redis.Enable() || !nethttp.Enable() {
+		t.Fatalf("unexpected states %v", instrumenter.InstrumentEnablerStates())
+	}
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	w.poll()
+	if !redis.Enable() || !nethttp.Enable() {
+		t.Fatal("expect initial states once the file is removed")
+	}
+}
+
+func TestAdminAddr(t *testing.T) {
+	for addr, expect := range map[string]string{
+		"9465":           "127.0.0.1:9465",
+		":9465":          "127.0.0.1:9465",
+		"127.0.0.1:9465": "127.0.0.1:9465",
+		"0.0.0.0:9465":   "0.0.0.0:9465",
+		"[::1]:9465":     "[::1]:9465",
+	} {
+		if actual := adminAddr(addr); actual != expect {
+			t.Errorf("%s: expect %s, got %s", addr, expect, actual)
+		}
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	t.Setenv(AdminAddr, addr)
+	get := func() error {
+		resp, err := http.Get("http://" + addr + AdminPath)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	// Nothing listens unless runtime switches are enabled
+	Start()
+	time.Sleep(100 * time.Millisecond)
+	if get() == nil {
+		t.Fatal("expect the admin endpoint to be off by default")
+	}
+	t.Setenv(RuntimeSwitch, "true")
+	Start()
+	defer Stop()
+	var ok bool
+	for i := 0; i < 50 && !ok; i++ {
+		time.Sleep(10 * time.Millisecond)
+		ok = get() == nil
+	}
+	if !ok {
+		t.Fatal("expect the admin endpoint to be served")
+	}
+	Stop()
+	if get() == nil {
+		t.Fatal("expect the admin endpoint to be stopped")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logger holds the LoggerProvider that log bridges emit records to,
+// bridges are hooks of logging libraries that copy records written by the
+// application to OpenTelemetry, the original output is left untouched.
+package logger
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+)
+
+// SDKLogger is the logger that the sdk reports its own errors to, e.g. failed
+// exports. Bridges skip it, the errors would be fed back to the sdk otherwise.
+var SDKLogger = stdlog.New(os.Stderr, "", stdlog.LstdFlags)
+
+var (
+	provider log.LoggerProvider
+	loggers  = map[string]log.Logger{}
+	mu       sync.RWMutex
+)
+
+// SetLoggerProvider sets the provider of bridges, nil disables all bridges
+func SetLoggerProvider(p log.LoggerProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	provider = p
+	loggers = map[string]log.Logger{}
+}
+
+// Enabled reports whether records emitted by bridges are exported anywhere,
+// bridges should check it before converting records
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return provider != nil
+}
+
+// GetLogger returns the logger of the instrumentation scope, or nil if there
+// is no provider
+func GetLogger(scope string) log.Logger {
+	mu.RLock()
+	l, ok := loggers[scope]
+	p := provider
+	mu.RUnlock()
+	if ok || p == nil {
+		return l
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok = loggers[scope]; ok {
+		return l
+	}
+	l = p.Logger(scope)
+	loggers[scope] = l
+	return l
+}
+
+// Emit emits the record to the logger of the instrumentation scope. The span
+// of ctx, or the current span of the goroutine if ctx has none, is correlated
+// with the record by the sdk. Records of a finished request are emitted as
+// well, even if its context is already canceled.
+func Emit(ctx context.Context, scope string, record log.Record) {
+	l := GetLogger(scope)
+	if l == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if record.Timestamp().IsZero() {
+		record.SetTimestamp(time.Now())
+	}
+	record.SetObservedTimestamp(time.Now())
+	l.Emit(context.WithoutCancel(ctx), record)
+}
+
+// Value converts an arbitrary value of the logging library to a log value
+func Value(v any) log.Value {
+	switch val := v.(type) {
+	case nil:
+		return log.Value{}
+	case log.Value:
+		return val
+	case string:
+		return log.StringValue(val)
+	case bool:
+		return log.BoolValue(val)
+	case int:
+		return log.IntValue(val)
+	case int8:
+		return log.Int64Value(int64(val))
+	case int16:
+		return log.Int64Value(int64(val))
+	case int32:
+		return log.Int64Value(int64(val))
+	case int64:
+		return log.Int64Value(val)
+	case uint:
+		return uintValue(uint64(val))
+	case uint8:
+		return log.Int64Value(int64(val))
+	case uint16:
+		return log.Int64Value(int64(val))
+	case uint32:
+		return log.Int64Value(int64(val))
+	case uint64:
+		return uintValue(val)
+	case float32:
+		return log.Float64Value(float64(val))
+	case float64:
+		return log.Float64Value(val)
+	case []byte:
+		return log.BytesValue(val)
+	case time.Duration:
+		return log.Int64Value(val.Nanoseconds())
+	case time.Time:
+		return log.Int64Value(val.UnixNano())
+	case error:
+		return log.StringValue(val.Error())
+	case fmt.Stringer:
+		return log.StringValue(val.String())
+	case []any:
+		values := make([]log.Value, 0, len(val))
+		for _, e := range val {
+			values = append(values, Value(e))
+		}
+		return log.SliceValue(values...)
+	case map[string]any:
+		kvs := make([]log.KeyValue, 0, len(val))
+		for k, e := range val {
+			kvs = append(kvs, KeyValue(k, e))
+		}
+		return log.MapValue(kvs...)
+	default:
+		return log.StringValue(fmt.Sprintf("%+v", val))
+	}
+}
+
+// KeyValue converts an arbitrary field of the logging library to a log
+// attribute
+func KeyValue(key string, v any) log.KeyValue {
+	return log.KeyValue{Key: key, Value: Value(v)}
+}
+
+// uintValue converts v to an int value if it fits, otherwise to a string value
+func uintValue(v uint64) log.Value {
+	const maxInt64 = 1<<63 - 1
+	if v > maxInt64 {
+		return log.StringValue(fmt.Sprint(v))
+	}
+	return log.Int64Value(int64(v))
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordExporter keeps exported records in memory, it drops records of a
+// canceled context as the stdout exporter does
+type recordExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *recordExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *recordExporter) Shutdown(context.Context) error { return nil }
+
+func (e *recordExporter) ForceFlush(context.Context) error { return nil }
+
+func newProvider(t *testing.T) *recordExporter {
+	t.Helper()
+	exporter := &recordExporter{}
+	SetLoggerProvider(sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)),
+	))
+	t.Cleanup(func() { SetLoggerProvider(nil) })
+	return exporter
+}
+
+func TestNoProvider(t *testing.T) {
+	SetLoggerProvider(nil)
+	if Enabled() {
+		t.Fatal("expect bridges to be disabled without a provider")
+	}
+	if GetLogger("zap") != nil {
+		t.Fatal("expect no logger without a provider")
+	}
+	// Nothing to emit to, it must not panic
+	Emit(context.Background(), "zap", log.Record{})
+}
+
+func TestGetLogger(t *testing.T) {
+	newProvider(t)
+	if !Enabled() {
+		t.Fatal("expect bridges to be enabled")
+	}
+	l := GetLogger("zap")
+	if l == nil || GetLogger("zap") != l {
+		t.Fatal("expect the logger of a scope to be cached")
+	}
+	if GetLogger("logrus") == l {
+		t.Fatal("expect scopes to have their own loggers")
+	}
+	// A new provider drops loggers of the old one
+	newProvider(t)
+	if GetLogger("zap") == l {
+		t.Fatal("expect loggers of the old provider to be dropped")
+	}
+}
+
+func TestEmit(t *testing.T) {
+	exporter := newProvider(t)
+	tid, _ := trace.TraceIDFromHex("5b8efff798038103d269b633813fc60c")
+	sid, _ := trace.SpanIDFromHex("eee19b7ec3c1b174")
+	ctx := trace.ContextWithSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    tid,
+			SpanID:     sid,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+	var record log.Record
+	record.SetBody(log.StringValue("hello"))
+	record.SetSeverity(log.SeverityInfo)
+	Emit(ctx, "zap", record)
+	timestamp := time.Unix(1700000000, 0)
+	record.SetTimestamp(timestamp)
+	// A nil ctx falls back to the background one
+	Emit(nil, "logrus", record)
+	// The request of ctx has finished, its record is still exported
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	Emit(canceled, "slog", record)
+
+	if len(exporter.records) != 3 {
+		t.Fatalf("expect 3 records, got %d", len(exporter.records))
+	}
+	first, second := exporter.records[0], exporter.records[1]
+	if first.Body().AsString() != "hello" ||
+		first.Severity() != log.SeverityInfo ||
+		first.InstrumentationScope().Name != "zap" {
+		t.Fatalf("unexpected record %v", first)
+	}
+	if first.Timestamp().IsZero() || first.ObservedTimestamp().IsZero() {
+		t.Fatal("expect timestamps to be set")
+	}
+	if first.TraceID() != tid || first.SpanID() != sid {
+		t.Fatal("expect the record to be correlated with the span of ctx")
+	}
+	if !second.Timestamp().Equal(timestamp) ||
+		second.InstrumentationScope().Name != "logrus" {
+		t.Fatalf("unexpected record %v", second)
+	}
+	if second.TraceID().IsValid() {
+		t.Fatal("expect no span to be correlated")
+	}
+	third := exporter.records[2]
+	if third.InstrumentationScope().Name != "slog" || third.SpanID() != sid {
+		t.Fatalf("unexpected record %v", third)
+	}
+}
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+func TestValue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, c := range []struct {
+		v      any
+		expect log.Value
+	}{
+		{nil, log.Value{}},
+		{log.IntValue(1), log.IntValue(1)},
+		{"s", log.StringValue("s")},
+		{true, log.BoolValue(true)},
+		{1, log.IntValue(1)},
+		{int8(-8), log.Int64Value(-8)},
+		{int16(16), log.Int64Value(16)},
+		{int32(32), log.Int64Value(32)},
+		{int64(64), log.Int64Value(64)},
+		{uint(1), log.Int64Value(1)},
+		{uint8(8), log.Int64Value(8)},
+		{uint16(16), log.Int64Value(16)},
+		{uint32(32), log.Int64Value(32)},
+		{uint64(64), log.Int64Value(64)},
+		{uint64(math.MaxUint64), log.StringValue("18446744073709551615")},
+		{float32(0.5), log.Float64Value(0.5)},
+		{1.5, log.Float64Value(1.5)},
+		{[]byte("b"), log.BytesValue([]byte("b"))},
+		{time.Second, log.Int64Value(int64(time.Second))},
+		{now, log.Int64Value(now.UnixNano())},
+		{errors.New("e"), log.StringValue("e")},
+		{stringer{}, log.StringValue("stringer")},
+		{[]any{1, "s"}, log.SliceValue(log.IntValue(1), log.StringValue("s"))},
+		{map[string]any{"k": 1}, log.MapValue(log.Int("k", 1))},
+		{struct{ A int }{1}, log.StringValue("{A:1}")},
+	} {
+		if actual := Value(c.v); !actual.Equal(c.expect) {
+			t.Errorf("%#v: expect %v, got %v", c.v, c.expect, actual)
+		}
+	}
+	if kv := KeyValue("k", "v"); !kv.Equal(log.String("k", "v")) {
+		t.Errorf("unexpected attribute %v", kv)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package propagator builds the global TextMapPropagator from OTEL_PROPAGATORS.
+package propagator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	Propagators        = "OTEL_PROPAGATORS"
+	DefaultPropagators = "tracecontext,baggage"
+	None               = "none"
+)
+
+// New returns the composite propagator of names, which injects all formats
+// and extracts them all in order, i.e. the last valid format that is found
+// wins. Unknown names are skipped and reported in the error.
+func New(names string) (propagation.TextMapPropagator, error) {
+	var props []propagation.TextMapPropagator
+	var errs []error
+	seen := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case "tracecontext":
+			props = append(props, propagation.TraceContext{})
+		case "baggage":
+			props = append(props, propagation.Baggage{})
+		case "b3":
+			props = append(props, b3.New(
+				b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			props = append(props, b3.New(
+				b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			props = append(props, jaeger.Jaeger{})
+		case "xray":
+			props = append(props, xray.Propagator{})
+		case None:
+		default:
+			errs = append(errs, fmt.Errorf("unsupported propagator %q", name))
+		}
+	}
+	// "none" disables propagation no matter what else is listed
+	if seen[None] {
+		props = nil
+	}
+	return propagation.NewCompositeTextMapPropagator(props...), errors.Join(errs...)
+}
+
+// FromEnv returns the propagator configured by OTEL_PROPAGATORS, which is
+// tracecontext and baggage if not configured
+func FromEnv() (propagation.TextMapPropagator, error) {
+	names := os.Getenv(Propagators)
+	if strings.TrimSpace(names) == "" {
+		names = DefaultPropagators
+	}
+	return New(names)
+}
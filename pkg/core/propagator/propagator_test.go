@@ -0,0 +1,164 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package propagator
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lowerCarrier mimics transports that lower the case of keys, e.g. gRPC
+// metadata and HTTP/2 headers
+type lowerCarrier map[string]string
+
+func (c lowerCarrier) Get(key string) string {
+	v, _ := utils.LookupMetadata(c, key)
+	return v
+}
+
+func (c lowerCarrier) Set(key, value string) { c[strings.ToLower(key)] = value }
+
+func (c lowerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func newSpanContext(t *testing.T) context.Context {
+	t.Helper()
+	tid, _ := trace.TraceIDFromHex("5b8efff798038103d269b633813fc60c")
+	sid, _ := trace.SpanIDFromHex("eee19b7ec3c1b174")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
+
+func mustNew(t *testing.T, names string) propagation.TextMapPropagator {
+	t.Helper()
+	p, err := New(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(Propagators, "")
+	p, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fields of a composite propagator come in no particular order
+	names := p.Fields()
+	sort.Strings(names)
+	fields := strings.Join(names, ",")
+	if fields != "baggage,traceparent,tracestate" {
+		t.Fatalf("unexpected default fields %s", fields)
+	}
+
+	t.Setenv(Propagators, "b3, nope ,jaeger")
+	p, err = FromEnv()
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("expect unsupported propagator error, got %v", err)
+	}
+	if len(p.Fields()) == 0 {
+		t.Fatal("expect known propagators to be kept")
+	}
+
+	t.Setenv(Propagators, "tracecontext,none")
+	p, _ = FromEnv()
+	if len(p.Fields()) != 0 {
+		t.Fatalf("expect no fields for none, got %v", p.Fields())
+	}
+}
+
+func TestInjectAllFormats(t *testing.T) {
+	p := mustNew(t, "tracecontext,baggage,b3,b3multi,jaeger,xray")
+	carrier := propagation.MapCarrier{}
+	p.Inject(newSpanContext(t), carrier)
+	for _, key := range []string{"traceparent", "b3", "x-b3-traceid",
+		"uber-trace-id", "X-Amzn-Trace-Id"} {
+		if carrier.Get(key) == "" {
+			t.Errorf("expect %s to be injected, got %v", key, carrier)
+		}
+	}
+}
+
+func TestMixedFormats(t *testing.T) {
+	ctx := newSpanContext(t)
+	expect := trace.SpanContextFromContext(ctx)
+	// The downstream understands every format that upstreams may speak
+	downstream := mustNew(t, "tracecontext,baggage,b3multi,jaeger,xray")
+	for _, upstream := range []string{"tracecontext", "b3", "b3multi",
+		"jaeger", "xray"} {
+		t.Run(upstream, func(t *testing.T) {
+			carrier := lowerCarrier{}
+			mustNew(t, upstream).Inject(ctx, carrier)
+			got := trace.SpanContextFromContext(
+				downstream.Extract(context.Background(), carrier))
+			if got.TraceID() != expect.TraceID() ||
+				got.SpanID() != expect.SpanID() || !got.IsSampled() {
+				t.Fatalf("expect %v, got %v from %v", expect, got, carrier)
+			}
+		})
+	}
+
+	// Nothing is propagated to a downstream that speaks none of the formats
+	carrier := lowerCarrier{}
+	mustNew(t, "jaeger").Inject(ctx, carrier)
+	got := trace.SpanContextFromContext(
+		mustNew(t, "tracecontext").Extract(context.Background(), carrier))
+	if got.IsValid() {
+		t.Fatalf("expect no span context, got %v", got)
+	}
+}
+
+func TestLastFormatWins(t *testing.T) {
+	ctx := newSpanContext(t)
+	// Another upstream speaks b3 with a span context of its own
+	tid, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	sid, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	other := trace.ContextWithRemoteSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    tid,
+			SpanID:     sid,
+			TraceFlags: trace.FlagsSampled,
+		}))
+	carrier := lowerCarrier{}
+	mustNew(t, "tracecontext").Inject(ctx, carrier)
+	mustNew(t, "b3").Inject(other, carrier)
+
+	got := trace.SpanContextFromContext(mustNew(t, "tracecontext,b3").
+		Extract(context.Background(), carrier))
+	if got.TraceID() != tid {
+		t.Fatalf("expect b3 to win, got %v", got)
+	}
+	got = trace.SpanContextFromContext(mustNew(t, "b3,tracecontext").
+		Extract(context.Background(), carrier))
+	if got.TraceID() != trace.SpanContextFromContext(ctx).TraceID() {
+		t.Fatalf("expect tracecontext to win, got %v", got)
+	}
+}
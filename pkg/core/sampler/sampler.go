@@ -0,0 +1,260 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sampler builds the sampler of the TracerProvider, which consists of
+// the standard sampler configured by OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, and sampling rules declared in the project config
+// that take precedence over the standard one for spans they match.
+package sampler
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracesSampler    = "OTEL_TRACES_SAMPLER"
+	TracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
+)
+
+// FromEnv returns the sampler configured by OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, which is parentbased_always_on if not configured.
+// The default sampler is returned along with the error if the configuration is
+// invalid.
+func FromEnv() (sdktrace.Sampler, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv(TracesSampler)))
+	arg, hasArg := os.LookupEnv(TracesSamplerArg)
+	ratio := 1.0
+	if hasArg && (name == "traceidratio" || name == "parentbased_traceidratio") {
+		var err error
+		ratio, err = strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return sdktrace.ParentBased(sdktrace.AlwaysSample()),
+				fmt.Errorf("bad %s %q, expect a ratio in [0, 1]",
+					TracesSamplerArg, arg)
+		}
+	}
+	switch name {
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	default:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()),
+			fmt.Errorf("unsupported %s %q", TracesSampler, name)
+	}
+}
+
+// Rule decides how spans it matches are sampled, a span matches the rule if it
+// matches all non-empty patterns of the rule
+type Rule struct {
+	// Name is a regular expression matching the span name
+	Name string
+	// Route is a regular expression matching the http.route attribute, or the
+	// url.path attribute if the route is unknown when the span starts
+	Route string
+	// Scope is a regular expression matching the instrumentation scope name
+	Scope string
+	// Ratio is the ratio of matched traces to sample, 0 drops all of them
+	Ratio float64
+	// Rate limits the number of sampled spans per second, 0 means no limit
+	Rate float64
+}
+
+type compiledRule struct {
+	name    *regexp.Regexp
+	route   *regexp.Regexp
+	scope   *regexp.Regexp
+	ratio   sdktrace.Sampler
+	limiter *rateLimiter
+}
+
+func (r *compiledRule) match(p sdktrace.SamplingParameters,
+	scope instrumentation.Scope) bool {
+	if r.name != nil && !r.name.MatchString(p.Name) {
+		return false
+	}
+	if r.scope != nil && !r.scope.MatchString(scope.Name) {
+		return false
+	}
+	if r.route != nil {
+		route, ok := findAttribute(p.Attributes, semconv.HTTPRouteKey)
+		if !ok {
+			route, ok = findAttribute(p.Attributes, semconv.URLPathKey)
+		}
+		if !ok || !r.route.MatchString(route) {
+			return false
+		}
+	}
+	return true
+}
+
+func findAttribute(attrs []attribute.KeyValue, key attribute.Key) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+// ruleSampler samples spans by the first rule they match, and falls back to
+// the standard sampler if there is none
+type ruleSampler struct {
+	rules    []*compiledRule
+	fallback sdktrace.Sampler
+}
+
+// New returns a sampler that applies rules in order before the fallback
+// sampler. Rules only decide for root spans and spans whose remote parent is
+// not sampled, children of local spans follow their parents, so that traces
+// are never broken in the middle of the process, and children of sampled remote
+// spans are left to the fallback sampler.
+func New(rules []Rule, fallback sdktrace.Sampler) (sdktrace.Sampler, error) {
+	compile := func(pattern string) (*regexp.Regexp, error) {
+		if pattern == "" {
+			return nil, nil
+		}
+		return regexp.Compile(pattern)
+	}
+	s := &ruleSampler{fallback: fallback}
+	for i, rule := range rules {
+		if rule.Ratio < 0 || rule.Ratio > 1 {
+			return nil, fmt.Errorf("rule %d: bad ratio %v", i, rule.Ratio)
+		}
+		if rule.Rate < 0 {
+			return nil, fmt.Errorf("rule %d: bad rate %v", i, rule.Rate)
+		}
+		cr := &compiledRule{ratio: sdktrace.TraceIDRatioBased(rule.Ratio)}
+		var err error
+		for _, item := range []struct {
+			re      **regexp.Regexp
+			pattern string
+		}{
+			{&cr.name, rule.Name},
+			{&cr.route, rule.Route},
+			{&cr.scope, rule.Scope},
+		} {
+			*item.re, err = compile(item.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+		}
+		if rule.Rate > 0 {
+			cr.limiter = newRateLimiter(rule.Rate)
+		}
+		s.rules = append(s.rules, cr)
+	}
+	return s, nil
+}
+
+func (s *ruleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.ShouldSampleScope(p, instrumentation.Scope{})
+}
+
+// ShouldSampleScope is called by the instrumented sdk in place of ShouldSample,
+// where the instrumentation scope of the tracer is available
+func (s *ruleSampler) ShouldSampleScope(p sdktrace.SamplingParameters,
+	scope instrumentation.Scope) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+	if psc.IsValid() && !psc.IsRemote() {
+		decision := sdktrace.Drop
+		if psc.IsSampled() {
+			decision = sdktrace.RecordAndSample
+		}
+		return sdktrace.SamplingResult{
+			Decision:   decision,
+			Tracestate: psc.TraceState(),
+		}
+	}
+	if psc.IsValid() && psc.IsSampled() {
+		return s.fallback.ShouldSample(p)
+	}
+	for _, rule := range s.rules {
+		if !rule.match(p, scope) {
+			continue
+		}
+		result := sdktrace.SamplingResult{
+			Decision:   sdktrace.Drop,
+			Tracestate: psc.TraceState(),
+		}
+		if rule.ratio.ShouldSample(p).Decision != sdktrace.RecordAndSample {
+			return result
+		}
+		if rule.limiter != nil && !rule.limiter.allow() {
+			return result
+		}
+		result.Decision = sdktrace.RecordAndSample
+		return result
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s *ruleSampler) Description() string {
+	return fmt.Sprintf("RuleBased{rules:%d,fallback:%s}", len(s.rules),
+		s.fallback.Description())
+}
+
+// rateLimiter is a token bucket that holds one second of tokens, or a single
+// token if the rate is less than one per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	capacity := math.Max(rate, 1)
+	return &rateLimiter{rate: rate, capacity: capacity, tokens: capacity,
+		now: time.Now}
+}
+
+func (l *rateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		l.tokens = math.Min(l.tokens, l.capacity)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sampler
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFromEnv(t *testing.T) {
+	cases := []struct {
+		sampler, arg string
+		expect       string
+		bad          bool
+	}{
+		{"", "", "ParentBased{root:AlwaysOnSampler", false},
+		{"always_on", "", "AlwaysOnSampler", false},
+		{"always_off", "", "AlwaysOffSampler", false},
+		{"traceidratio", "0.25", "TraceIDRatioBased{0.25}", false},
+		{"traceidratio", "", "AlwaysOnSampler", false},
+		{"parentbased_always_off", "", "ParentBased{root:AlwaysOffSampler", false},
+		{"parentbased_traceidratio", "0.5", "ParentBased{root:TraceIDRatioBased{0.5}", false},
+		{"traceidratio", "2", "ParentBased{root:AlwaysOnSampler", true},
+		{"unknown", "", "ParentBased{root:AlwaysOnSampler", true},
+	}
+	for _, c := range cases {
+		t.Setenv(TracesSampler, c.sampler)
+		t.Setenv(TracesSamplerArg, c.arg)
+		if c.arg == "" {
+			// Leave the argument unset, an empty one is a bad ratio
+			os.Unsetenv(TracesSamplerArg)
+		}
+		s, err := FromEnv()
+		if (err != nil) != c.bad {
+			t.Errorf("%s=%s: unexpected error %v", c.sampler, c.arg, err)
+		}
+		if !strings.HasPrefix(s.Description(), c.expect) {
+			t.Errorf("%s=%s: expect %s, got %s", c.sampler, c.arg, c.expect,
+				s.Description())
+		}
+	}
+}
+
+func sample(t *testing.T, s sdktrace.Sampler, ctx context.Context, name,
+	scope string, attrs ...attribute.KeyValue) sdktrace.SamplingDecision {
+	t.Helper()
+	tid, _ := trace.TraceIDFromHex("0123456789abcdef0123456789abcdef")
+	p := sdktrace.SamplingParameters{
+		ParentContext: ctx,
+		TraceID:       tid,
+		Name:          name,
+		Attributes:    attrs,
+	}
+	return s.(*ruleSampler).ShouldSampleScope(p,
+		instrumentation.Scope{Name: scope}).Decision
+}
+
+func TestRules(t *testing.T) {
+	s, err := New([]Rule{
+		{Route: "^/healthz$", Ratio: 0},
+		{Scope: "redis", Ratio: 0},
+		{Name: "^GET /api", Ratio: 1},
+	}, sdktrace.AlwaysSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cases := []struct {
+		name, scope string
+		attrs       []attribute.KeyValue
+		expect      sdktrace.SamplingDecision
+	}{
+		{"GET /healthz", "net/http", []attribute.KeyValue{semconv.URLPath("/healthz")}, sdktrace.Drop},
+		{"GET /healthz", "net/http", []attribute.KeyValue{semconv.HTTPRoute("/healthz")}, sdktrace.Drop},
+		{"GET /healthz/deep", "net/http", []attribute.KeyValue{semconv.URLPath("/healthz/deep")}, sdktrace.RecordAndSample},
+		{"GET", "github.com/redis/go-redis/v9", nil, sdktrace.Drop},
+		{"GET /api/users", "net/http", nil, sdktrace.RecordAndSample},
+		{"unmatched", "", nil, sdktrace.RecordAndSample},
+	}
+	for _, c := range cases {
+		got := sample(t, s, ctx, c.name, c.scope, c.attrs...)
+		if got != c.expect {
+			t.Errorf("%s (%s): expect %v, got %v", c.name, c.scope, c.expect, got)
+		}
+	}
+
+	// Children of local spans follow their parents whatever rules say
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	})
+	local := trace.ContextWithSpanContext(ctx, parent)
+	if sample(t, s, local, "GET /api/users", "net/http") != sdktrace.Drop {
+		t.Error("expect drop for spans whose parent is not sampled")
+	}
+	parent = parent.WithTraceFlags(trace.FlagsSampled)
+	local = trace.ContextWithSpanContext(ctx, parent)
+	if sample(t, s, local, "GET", "github.com/redis/go-redis/v9") !=
+		sdktrace.RecordAndSample {
+		t.Error("expect sampled for spans whose parent is sampled")
+	}
+
+	// Rules decide for spans whose remote parent is not sampled, others are
+	// left to the fallback
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+		Remote:  true,
+	})
+	ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+	if sample(t, s, ctx, "GET /api/users", "net/http") !=
+		sdktrace.RecordAndSample {
+		t.Error("expect rules to decide for remote parents not sampled")
+	}
+	s, _ = New([]Rule{{Route: "^/healthz$", Ratio: 0}},
+		sdktrace.ParentBased(sdktrace.AlwaysSample()))
+	remote = remote.WithTraceFlags(trace.FlagsSampled)
+	ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+	if sample(t, s, ctx, "GET /healthz", "net/http",
+		semconv.URLPath("/healthz")) != sdktrace.RecordAndSample {
+		t.Error("expect the fallback to decide for sampled remote parents")
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	for _, rule := range []Rule{
+		{Name: "(", Ratio: 1},
+		{Name: "a", Ratio: 1.5},
+		{Name: "a", Ratio: 1, Rate: -1},
+	} {
+		_, err := New([]Rule{rule}, sdktrace.AlwaysSample())
+		if err == nil {
+			t.Errorf("expect error for %+v", rule)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s, err := New([]Rule{{Name: "limited", Ratio: 1, Rate: 2}},
+		sdktrace.AlwaysSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	s.(*ruleSampler).rules[0].limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+	sampled := 0
+	for i := 0; i < 10; i++ {
+		if sample(t, s, ctx, "limited", "") == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	if sampled != 2 {
+		t.Fatalf("expect 2 sampled spans in a burst, got %d", sampled)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if sample(t, s, ctx, "limited", "") != sdktrace.RecordAndSample {
+		t.Fatal("expect a token after half a second")
+	}
+	if sample(t, s, ctx, "limited", "") != sdktrace.Drop {
+		t.Fatal("expect no token left")
+	}
+}
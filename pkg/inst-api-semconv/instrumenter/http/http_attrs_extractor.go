@@ -152,3 +152,19 @@ func (h *HttpServerAttrsExtractor[REQUEST, RESPONSE, GETTER1, GETTER2, GETTER3])
 func (h *HttpServerAttrsExtractor[REQUEST, RESPONSE, GETTER1, GETTER2, GETTER3]) GetSpanKey() attribute.Key {
 	return utils.HTTP_SERVER_KEY
 }
+
+// OnBeforeStart passes the url path and the route, if it's known already, to
+// the sampler
+func (h *HttpServerAttrsExtractor[REQUEST, RESPONSE, GETTER1, GETTER2, GETTER3]) OnBeforeStart(attributes []attribute.KeyValue, request REQUEST) []attribute.KeyValue {
+	attributes = append(attributes, attribute.KeyValue{
+		Key:   semconv.URLPathKey,
+		Value: attribute.StringValue(h.UrlExtractor.Getter.GetUrlPath(request)),
+	})
+	if route := h.Base.HttpGetter.GetHttpRoute(request); route != "" {
+		attributes = append(attributes, attribute.KeyValue{
+			Key:   semconv.HTTPRouteKey,
+			Value: attribute.StringValue(route),
+		})
+	}
+	return attributes
+}
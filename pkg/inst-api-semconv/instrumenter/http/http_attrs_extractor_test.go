@@ -347,6 +347,20 @@ func TestHttpServerExtractorEnd(t *testing.T) {
 	}
 }
 
+func TestHttpServerExtractorBeforeStart(t *testing.T) {
+	httpServerExtractor := HttpServerAttrsExtractor[testRequest, testResponse, httpServerAttrsGetter, networkAttrsGetter, urlAttrsGetter]{}
+	attrs := httpServerExtractor.OnBeforeStart(nil, testRequest{})
+	if len(attrs) != 2 {
+		t.Fatalf("expect url path and route only, got %v", attrs)
+	}
+	if attrs[0].Key != semconv.URLPathKey || attrs[0].Value.AsString() != "url-path" {
+		t.Fatalf("urlpath should be url-path")
+	}
+	if attrs[1].Key != semconv.HTTPRouteKey || attrs[1].Value.AsString() != "http-route" {
+		t.Fatalf("httproute should be http-route")
+	}
+}
+
 func TestHttpServerExtractorWithFilter(t *testing.T) {
 	httpServerExtractor := HttpServerAttrsExtractor[testRequest, testResponse, httpServerAttrsGetter, networkAttrsGetter, urlAttrsGetter]{
 		Base:             HttpCommonAttrsExtractor[testRequest, testResponse, httpServerAttrsGetter, networkAttrsGetter]{},
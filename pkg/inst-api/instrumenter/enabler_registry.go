@@ -0,0 +1,145 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrumenter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// registeredEnabler is an InstrumentEnabler that can be switched at runtime
+type registeredEnabler struct {
+	name    string
+	initial bool
+	enabled atomic.Bool
+}
+
+func (e *registeredEnabler) Enable() bool {
+	return e.enabled.Load()
+}
+
+// InstrumentOverride switches instrumentations whose name matches Pattern
+type InstrumentOverride struct {
+	Pattern string
+	Enabled bool
+}
+
+var (
+	enablers         = map[string]*registeredEnabler{}
+	enablerOverrides []InstrumentOverride
+	enablerMu        sync.Mutex
+)
+
+// RegisterInstrumentEnabler registers the instrumentation by name and returns
+// its enabler, which is initially disabled if env is "false". Registering the
+// same name again returns the same enabler, so that instrumentations sharing a
+// name are switched together.
+func RegisterInstrumentEnabler(name, env string) InstrumentEnabler {
+	enablerMu.Lock()
+	defer enablerMu.Unlock()
+	if e, ok := enablers[name]; ok {
+		return e
+	}
+	e := &registeredEnabler{name: name, initial: os.Getenv(env) != "false"}
+	e.enabled.Store(e.resolve())
+	enablers[name] = e
+	return e
+}
+
+// resolve returns the state of the enabler, i.e. the last override that
+// matches its name, or the initial state if there is none
+func (e *registeredEnabler) resolve() bool {
+	enabled := e.initial
+	for _, o := range enablerOverrides {
+		if matched, _ := path.Match(o.Pattern, e.name); matched {
+			enabled = o.Enabled
+		}
+	}
+	return enabled
+}
+
+// SetInstrumentEnabled switches instrumentations whose name matches pattern,
+// e.g. "redis*", and returns the names of them. The override also applies to
+// instrumentations that register later.
+func SetInstrumentEnabled(pattern string, enabled bool) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+	}
+	enablerMu.Lock()
+	defer enablerMu.Unlock()
+	for i, o := range enablerOverrides {
+		if o.Pattern == pattern {
+			enablerOverrides = append(enablerOverrides[:i], enablerOverrides[i+1:]...)
+			break
+		}
+	}
+	enablerOverrides = append(enablerOverrides,
+		InstrumentOverride{Pattern: pattern, Enabled: enabled})
+	names := make([]string, 0)
+	for name, e := range enablers {
+		if matched, _ := path.Match(pattern, name); matched {
+			e.enabled.Store(enabled)
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReplaceInstrumentEnablers replaces all overrides with the given ones, later
+// ones take precedence. Every instrumentation is switched straight to its new
+// state rather than back to its initial state in between, and nothing is
+// switched if any pattern is malformed.
+func ReplaceInstrumentEnablers(overrides []InstrumentOverride) error {
+	for _, o := range overrides {
+		if _, err := path.Match(o.Pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", o.Pattern, err)
+		}
+	}
+	enablerMu.Lock()
+	defer enablerMu.Unlock()
+	enablerOverrides = append([]InstrumentOverride(nil), overrides...)
+	for _, e := range enablers {
+		e.enabled.Store(e.resolve())
+	}
+	return nil
+}
+
+// ResetInstrumentEnablers drops all overrides, instrumentations are switched
+// back to their initial states
+func ResetInstrumentEnablers() {
+	enablerMu.Lock()
+	defer enablerMu.Unlock()
+	enablerOverrides = nil
+	for _, e := range enablers {
+		e.enabled.Store(e.initial)
+	}
+}
+
+// InstrumentEnablerStates returns whether each registered instrumentation is
+// enabled
+func InstrumentEnablerStates() map[string]bool {
+	enablerMu.Lock()
+	defer enablerMu.Unlock()
+	states := make(map[string]bool, len(enablers))
+	for name, e := range enablers {
+		states[name] = e.Enable()
+	}
+	return states
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrumenter
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisterInstrumentEnabler(t *testing.T) {
+	t.Cleanup(ResetInstrumentEnablers)
+	t.Setenv("OTEL_INSTRUMENTATION_TESTOFF_ENABLED", "false")
+	on := RegisterInstrumentEnabler("teston", "OTEL_INSTRUMENTATION_TESTON_ENABLED")
+	off := RegisterInstrumentEnabler("testoff", "OTEL_INSTRUMENTATION_TESTOFF_ENABLED")
+	if !on.Enable() || off.Enable() {
+		t.Fatalf("unexpected initial states %v/%v", on.Enable(), off.Enable())
+	}
+	if RegisterInstrumentEnabler("teston", "") != on {
+		t.Fatal("expect the same enabler for the same name")
+	}
+
+	names, err := SetInstrumentEnabled("test*", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"testoff", "teston"}) {
+		t.Fatalf("unexpected switched names %v", names)
+	}
+	if on.Enable() {
+		t.Fatal("expect teston to be disabled")
+	}
+	// Overrides apply to instrumentations registered later in order
+	_, _ = SetInstrumentEnabled("testlate", true)
+	late := RegisterInstrumentEnabler("testlate", "")
+	if !late.Enable() {
+		t.Fatal("expect the latest override to win")
+	}
+	if !InstrumentEnablerStates()["testlate"] || InstrumentEnablerStates()["teston"] {
+		t.Fatalf("unexpected states %v", InstrumentEnablerStates())
+	}
+
+	ResetInstrumentEnablers()
+	if !on.Enable() || off.Enable() || !late.Enable() {
+		t.Fatal("expect initial states after reset")
+	}
+	if _, err = SetInstrumentEnabled("[", true); err == nil {
+		t.Fatal("expect error for bad pattern")
+	}
+}
+
+func TestReplaceInstrumentEnablers(t *testing.T) {
+	t.Cleanup(ResetInstrumentEnablers)
+	mysql := RegisterInstrumentEnabler("testmysql", "")
+	redis := RegisterInstrumentEnabler("testredis", "")
+
+	err := ReplaceInstrumentEnablers([]InstrumentOverride{
+		{Pattern: "test*", Enabled: false},
+		{Pattern: "testmysql", Enabled: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mysql.Enable() || redis.Enable() {
+		t.Fatalf("unexpected states %v", InstrumentEnablerStates())
+	}
+	// Overrides that are not given any more are dropped
+	err = ReplaceInstrumentEnablers([]InstrumentOverride{
+		{Pattern: "testmysql", Enabled: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mysql.Enable() || !redis.Enable() {
+		t.Fatalf("unexpected states %v", InstrumentEnablerStates())
+	}
+	// A bad pattern leaves the states untouched
+	err = ReplaceInstrumentEnablers([]InstrumentOverride{
+		{Pattern: "*", Enabled: false},
+		{Pattern: "[", Enabled: true},
+	})
+	if err == nil {
+		t.Fatal("expect error for bad pattern")
+	}
+	if mysql.Enable() || !redis.Enable() {
+		t.Fatalf("unexpected states %v", InstrumentEnablerStates())
+	}
+}
@@ -42,6 +42,14 @@ type SpanKeyProvider interface {
 	GetSpanKey() attribute.Key
 }
 
+// SamplingAttributesExtractor is implemented by attributes extractors that
+// know attributes samplers make decisions on before the span starts, e.g. the
+// url path of http servers. They are passed to the sampler only, OnStart is
+// still called with the started span to extract all attributes.
+type SamplingAttributesExtractor[REQUEST any] interface {
+	OnBeforeStart(attributes []attribute.KeyValue, request REQUEST) []attribute.KeyValue
+}
+
 type AlwaysInternalExtractor[REQUEST any] struct {
 }
 
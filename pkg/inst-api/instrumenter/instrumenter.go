@@ -65,6 +65,10 @@ func (i *InternalInstrumenter[REQUEST, RESPONSE]) ShouldStart(parentContext cont
 	return !suppressed
 }
 
+// notStartedKey marks the context returned by Start of a disabled instrumenter,
+// the value is the instrumenter
+type notStartedKey struct{}
+
 var cachePool = &sync.Pool{
 	New: func() interface{} {
 		return make([]attribute.KeyValue, 0, 25)
@@ -96,7 +100,11 @@ func (i *InternalInstrumenter[REQUEST, RESPONSE]) Start(parentContext context.Co
 
 func (i *InternalInstrumenter[REQUEST, RESPONSE]) doStart(parentContext context.Context, request REQUEST, timestamp time.Time, options ...trace.SpanStartOption) context.Context {
 	if i.enabler != nil && !i.enabler.Enable() {
-		return parentContext
+		// Tell doEnd that there is no span of ours to end
+		return context.WithValue(parentContext, notStartedKey{}, i)
+	}
+	if parentContext.Value(notStartedKey{}) != nil {
+		parentContext = context.WithValue(parentContext, notStartedKey{}, nil)
 	}
 	for _, listener := range i.operationListeners {
 		parentContext = listener.OnBeforeStart(parentContext, timestamp)
@@ -104,7 +112,17 @@ func (i *InternalInstrumenter[REQUEST, RESPONSE]) doStart(parentContext context.
 	// extract span name
 	spanName := i.spanNameExtractor.Extract(request)
 	spanKind := i.spanKindExtractor.Extract(request)
+	// extract attrs that samplers make decisions on, e.g. the url path
+	var samplingAttrs []attribute.KeyValue
+	for _, extractor := range i.attributesExtractors {
+		if se, ok := extractor.(SamplingAttributesExtractor[REQUEST]); ok {
+			samplingAttrs = se.OnBeforeStart(samplingAttrs, request)
+		}
+	}
 	options = append(options, trace.WithSpanKind(spanKind), trace.WithTimestamp(timestamp))
+	if len(samplingAttrs) > 0 {
+		options = append(options, trace.WithAttributes(samplingAttrs...))
+	}
 	newCtx, span := i.tracer.Start(parentContext, spanName, options...)
 	attrs := make([]attribute.KeyValue, 0, 20)
 	// extract span attrs
@@ -127,7 +145,9 @@ func (i *InternalInstrumenter[REQUEST, RESPONSE]) End(ctx context.Context, reque
 }
 
 func (i *InternalInstrumenter[REQUEST, RESPONSE]) doEnd(ctx context.Context, request REQUEST, response RESPONSE, err error, timestamp time.Time, options ...trace.SpanEndOption) {
-	if i.enabler != nil && !i.enabler.Enable() {
+	// Spans are ended even if the instrumentation is disabled in the meantime,
+	// they would never be exported otherwise
+	if ctx.Value(notStartedKey{}) == any(i) {
 		return
 	}
 	for _, listener := range i.operationListeners {
@@ -194,6 +194,38 @@ func TestEnabler(t *testing.T) {
 	}
 }
 
+func TestEndAfterDisabled(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	enabler := RegisterInstrumentEnabler("testtoggle", "")
+	t.Cleanup(ResetInstrumentEnablers)
+	builder := Builder[testRequest, testResponse]{}
+	builder.Init().
+		SetSpanNameExtractor(testNameExtractor{}).
+		SetSpanKindExtractor(&AlwaysClientExtractor[testRequest]{}).
+		SetInstrumentEnabler(enabler)
+	instrumenter := builder.BuildInstrumenterWithTracer(tp.Tracer("test-tracer"))
+
+	// The span started before the instrumentation is disabled is still ended
+	ctx := instrumenter.Start(context.Background(), testRequest{})
+	SetInstrumentEnabled("testtoggle", false)
+	instrumenter.End(ctx, testRequest{}, testResponse{}, nil)
+	if len(sr.Ended()) != 1 {
+		t.Fatalf("expect 1 ended span, got %d", len(sr.Ended()))
+	}
+
+	// Nothing is started while it's disabled, the parent span is not ended
+	parent, span := tp.Tracer("test-tracer").Start(context.Background(), "parent")
+	ctx = instrumenter.Start(parent, testRequest{})
+	SetInstrumentEnabled("testtoggle", true)
+	inner := instrumenter.Start(ctx, testRequest{})
+	instrumenter.End(inner, testRequest{}, testResponse{}, nil)
+	instrumenter.End(ctx, testRequest{}, testResponse{}, nil)
+	if len(sr.Ended()) != 2 || !span.IsRecording() {
+		t.Fatalf("expect 2 ended spans, got %d", len(sr.Ended()))
+	}
+}
+
 func TestPropFromUpStream(t *testing.T) {
 	builder := Builder[testRequest, testResponse]{}
 	builder.Init().
@@ -331,3 +363,62 @@ type SpanRecorder struct {
 	started []sdktrace.ReadWriteSpan
 	ended   []sdktrace.ReadOnlySpan
 }
+
+type samplingAttributesExtractor struct {
+	testAttributesExtractor
+	spanCtx trace.SpanContext
+}
+
+func (s *samplingAttributesExtractor) OnStart(attributes []attribute.KeyValue, parentContext context.Context, request testRequest) ([]attribute.KeyValue, context.Context) {
+	s.spanCtx = trace.SpanContextFromContext(parentContext)
+	return append(attributes, attribute.String("testAttribute", "testValue")), parentContext
+}
+
+func (s *samplingAttributesExtractor) OnBeforeStart(attributes []attribute.KeyValue, request testRequest) []attribute.KeyValue {
+	return append(attributes, attribute.String("url.path", "/test"))
+}
+
+// recordingSampler records attributes it's asked to sample by
+type recordingSampler struct {
+	attrs []attribute.KeyValue
+}
+
+func (r *recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	r.attrs = p.Attributes
+	return sdktrace.SamplingResult{Decision: sdktrace.RecordAndSample}
+}
+
+func (r *recordingSampler) Description() string {
+	return "recording"
+}
+
+func TestSamplingAttributes(t *testing.T) {
+	sampler := &recordingSampler{}
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(sr))
+	extractor := &samplingAttributesExtractor{}
+	builder := Builder[testRequest, testResponse]{}
+	builder.Init().
+		SetSpanNameExtractor(testNameExtractor{}).
+		SetSpanKindExtractor(&AlwaysServerExtractor[testRequest]{}).
+		AddAttributesExtractor(extractor)
+	instrumenter := builder.BuildInstrumenterWithTracer(tp.Tracer("test"))
+	ctx := instrumenter.Start(context.Background(), testRequest{})
+	instrumenter.End(ctx, testRequest{}, testResponse{}, nil)
+
+	// The sampler only sees what it needs
+	assert.Equal(t, []attribute.KeyValue{attribute.String("url.path", "/test")},
+		sampler.attrs)
+	// Attributes are still extracted with the started span
+	span := trace.SpanFromContext(ctx)
+	assert.Equal(t, span.SpanContext(), extractor.spanCtx)
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect 1 span, got %d", len(spans))
+	}
+	assert.Contains(t, spans[0].Attributes(),
+		attribute.String("testAttribute", "testValue"))
+	assert.Contains(t, spans[0].Attributes(),
+		attribute.String("url.path", "/test"))
+}
@@ -46,3 +46,4 @@ const MCP_SCOPE_NAME = "pkg/rules/mcp/setup.go"
 const KAFKAGO_PRODUCER_SCOPE_NAME = "pkg/rules/segmentio-kafka-go/kafka_producer_setup.go"
 const KAFKAGO_CONSUMER_SCOPE_NAME = "pkg/rules/segmentio-kafka-go/kafka_consumer_setup.go"
 const GOPG_SCOPE_NAME = "pkg/rules/gopg/setup.go"
+const SPAN_SCOPE_NAME = "pkg/rules/span/setup.go"
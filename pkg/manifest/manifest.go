@@ -0,0 +1,120 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Instrumentation Manifest
+//
+// The manifest is embedded into the binary by the otel tool at build time, it
+// tells which tool version built the binary and which packages are instrumented
+// by which rules. It answers whether a running binary was built with otel and
+// which integrations are active, without access to the build logs.
+
+// DistroName is the value of telemetry.distro.name resource attribute
+const DistroName = "opentelemetry-go-auto-instrumentation"
+
+type Rule struct {
+	// Kind of the rule, i.e. func, struct or file
+	Kind string
+	// Target is the instrumented function/receiver, struct field or file
+	Target string
+	// Version is the version range of the rule, empty means any version
+	Version string `json:",omitempty"`
+	// RuleFile is the name of the rule file the rule comes from
+	RuleFile string
+}
+
+type Package struct {
+	ImportPath string
+	// Version is the module version, empty for standard library packages
+	Version string `json:",omitempty"`
+	Rules   []Rule
+}
+
+type Manifest struct {
+	// ToolVersion is the version of otel tool that built the binary
+	ToolVersion string
+	// GoVersion is the Go version that built the binary
+	GoVersion string
+	// Packages is the list of instrumented packages
+	Packages []Package
+}
+
+var (
+	current *Manifest
+	once    sync.Once
+)
+
+// Register registers the manifest of the binary. It is called by the otel
+// setup package during initialization and only the first call takes effect,
+// user code should never call it.
+func Register(m *Manifest) {
+	once.Do(func() {
+		current = m
+	})
+}
+
+// Get returns the manifest embedded into the binary, or nil if the binary is
+// not built with otel tool
+func Get() *Manifest {
+	return current
+}
+
+// Instrumented reports whether the binary is built with otel tool
+func Instrumented() bool {
+	return current != nil
+}
+
+// Integrations returns the sorted names of active integrations, which are rule
+// files without the .json suffix, e.g. "gin", "redis"
+func (m *Manifest) Integrations() []string {
+	if m == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, pkg := range m.Packages {
+		for _, rule := range pkg.Rules {
+			name := strings.TrimSuffix(rule.RuleFile, ".json")
+			// The base rule is fundamental to otel itself rather than an
+			// integration of any library
+			if name == "" || name == "base" {
+				continue
+			}
+			set[name] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSON returns the manifest in JSON format
+func (m *Manifest) JSON() string {
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(bs)
+}
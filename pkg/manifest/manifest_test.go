@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	if Instrumented() || Get() != nil {
+		t.Fatal("expect no manifest before registration")
+	}
+	if Get().Integrations() != nil {
+		t.Fatal("expect no integrations for nil manifest")
+	}
+	m := &Manifest{
+		ToolVersion: "1.0.0",
+		GoVersion:   "go1.23.0",
+		Packages: []Package{
+			{
+				ImportPath: "runtime",
+				Rules:      []Rule{{Kind: "file", Target: "file runtime_linker.go", RuleFile: "base.json"}},
+			},
+			{
+				ImportPath: "github.com/gin-gonic/gin",
+				Version:    "v1.10.0",
+				Rules: []Rule{
+					{Kind: "func", Target: "func (*Context).Next", RuleFile: "gin.json"},
+					{Kind: "func", Target: "func (*Engine).ServeHTTP", RuleFile: "gin.json"},
+				},
+			},
+			{
+				ImportPath: "net/http",
+				Rules:      []Rule{{Kind: "func", Target: "func (*Transport).RoundTrip", RuleFile: "nethttp.json"}},
+			},
+		},
+	}
+	Register(m)
+	Register(&Manifest{})
+	if !Instrumented() || Get() != m {
+		t.Fatal("expect the first registered manifest")
+	}
+	expect := []string{"gin", "nethttp"}
+	if got := Get().Integrations(); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect integrations %v, got %v", expect, got)
+	}
+	if !strings.Contains(Get().JSON(), `"ToolVersion":"1.0.0"`) {
+		t.Fatalf("unexpected json %s", Get().JSON())
+	}
+}
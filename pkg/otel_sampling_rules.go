@@ -0,0 +1,22 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/sampler"
+
+// samplingRules holds the sampling rules declared in the project config. This
+// file is regenerated by the otel tool during preprocess, the declaration here
+// only serves as a placeholder for non-otel builds.
+var samplingRules = []sampler.Rule{}
@@ -24,44 +24,61 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/detector"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/enabler"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/meter"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/propagator"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/sampler"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/db"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/experimental"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/http"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/rpc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/manifest"
 	testaccess "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/testaccess"
+	"github.com/go-logr/stdr"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	otelruntime "go.opentelemetry.io/contrib/instrumentation/runtime"
 
 	// The version of the following packages/modules must be fixed
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	_ "go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/log/global"
 	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/noop"
-	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 )
 
 // set the following environment variables based on https://opentelemetry.io/docs/specs/otel/configuration/sdk-environment-variables
 // your service name: OTEL_SERVICE_NAME
 // your otlp endpoint: OTEL_EXPORTER_OTLP_ENDPOINT OTEL_EXPORTER_OTLP_TRACES_ENDPOINT OTEL_EXPORTER_OTLP_METRICS_ENDPOINT OTEL_EXPORTER_OTLP_LOGS_ENDPOINT
+// your exporters: OTEL_TRACES_EXPORTER OTEL_METRICS_EXPORTER OTEL_LOGS_EXPORTER
 // your otlp header: OTEL_EXPORTER_OTLP_HEADERS
 const exec_name = "otel"
 const report_protocol = "OTEL_EXPORTER_OTLP_PROTOCOL"
 const trace_report_protocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
 const metrics_exporter = "OTEL_METRICS_EXPORTER"
 const trace_exporter = "OTEL_TRACES_EXPORTER"
+const logs_report_protocol = "OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"
+const logs_exporter = "OTEL_LOGS_EXPORTER"
 const prometheus_exporter_port = "OTEL_EXPORTER_PROMETHEUS_PORT"
 const default_prometheus_exporter_port = "9464"
 
@@ -71,9 +88,15 @@ var (
 	traceProvider      *trace.TracerProvider
 	metricsProvider    otelmetric.MeterProvider
 	batchSpanProcessor trace.SpanProcessor
+	logExporter        sdklog.Exporter
+	loggerProvider     *sdklog.LoggerProvider
 )
 
 func init() {
+	applyEnvDefaults()
+	if otelManifest != nil {
+		manifest.Register(otelManifest)
+	}
 	if testaccess.IsInTest() {
 		trace.GetTestSpans = testaccess.GetTestSpans
 		metric.GetTestMetrics = testaccess.GetTestMetrics
@@ -97,6 +120,65 @@ func init() {
 	}
 }
 
+// applyEnvDefaults applies the default environment variables declared in the
+// project config, variables that are already set at runtime are left as is.
+func applyEnvDefaults() {
+	for k, v := range envDefaults {
+		if _, ok := os.LookupEnv(k); !ok {
+			_ = os.Setenv(k, v)
+		}
+	}
+}
+
+// newResource returns the resource shared by all providers, which describes
+// the service, the host, the process, the container and the pod it runs in, and
+// tells the telemetry is produced by this distro. Detectors that come later take
+// precedence, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES always win.
+func newResource(ctx context.Context) *resource.Resource {
+	attrs := []attribute.KeyValue{semconv.TelemetryDistroName(manifest.DistroName)}
+	if m := manifest.Get(); m != nil {
+		attrs = append(attrs, semconv.TelemetryDistroVersion(m.ToolVersion))
+	}
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithDetectors(detector.ServiceName()),
+		resource.WithHost(),
+		resource.WithOS(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessExecutablePath(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithProcessRuntimeDescription(),
+		resource.WithDetectors(detector.Container(), detector.K8s(),
+			detector.Build(buildAttributes)),
+		resource.WithAttributes(attrs...),
+		resource.WithFromEnv())
+	if err != nil {
+		// The resource is still usable, it just misses some attributes
+		log.Printf("Failed to detect the OpenTelemetry resource: %v", err)
+	}
+	return res
+}
+
+// newSampler returns the sampler configured by OTEL_TRACES_SAMPLER, preceded by
+// the sampling rules declared in the project config if any
+func newSampler() trace.Sampler {
+	s, err := sampler.FromEnv()
+	if err != nil {
+		log.Printf("Failed to configure the OpenTelemetry sampler: %v", err)
+	}
+	if len(samplingRules) == 0 {
+		return s
+	}
+	rs, err := sampler.New(samplingRules, s)
+	if err != nil {
+		log.Printf("Failed to apply the OpenTelemetry sampling rules: %v", err)
+		return s
+	}
+	return rs
+}
+
 func newSpanProcessor(ctx context.Context) trace.SpanProcessor {
 	if testaccess.IsInTest() {
 		traceExporter := testaccess.GetSpanExporter()
@@ -129,25 +211,81 @@ func newSpanProcessor(ctx context.Context) trace.SpanProcessor {
 func initOpenTelemetry(ctx context.Context) error {
 
 	batchSpanProcessor = newSpanProcessor(ctx)
+	res := newResource(ctx)
 
 	if batchSpanProcessor != nil {
 		traceProvider = trace.NewTracerProvider(
-			trace.WithSpanProcessor(batchSpanProcessor))
+			trace.WithSpanProcessor(batchSpanProcessor),
+			trace.WithSampler(newSampler()),
+			trace.WithResource(res))
 	} else {
-		traceProvider = trace.NewTracerProvider()
+		traceProvider = trace.NewTracerProvider(
+			trace.WithSampler(newSampler()),
+			trace.WithResource(res))
 	}
 
 	otel.SetTracerProvider(traceProvider)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	return initMetrics()
+	prop, err := propagator.FromEnv()
+	if err != nil {
+		log.Printf("Failed to configure the OpenTelemetry propagators: %v", err)
+	}
+	otel.SetTextMapPropagator(prop)
+	enabler.Start()
+	initLogs(ctx, res)
+	return initMetrics(res)
 }
 
-func initMetrics() error {
+// initLogs creates the LoggerProvider that log bridges emit records to. Unlike
+// traces and metrics, logs are exported only if OTEL_LOGS_EXPORTER is given
+// explicitly, as applications usually ship their logs on their own already.
+func initLogs(ctx context.Context, res *resource.Resource) {
+	exporter := os.Getenv(logs_exporter)
+	if exporter == "" {
+		exporter = "none"
+	}
+	var err error
+	switch exporter {
+	case "none":
+		return
+	case "console":
+		logExporter, err = stdoutlog.New()
+	default:
+		if os.Getenv(report_protocol) == "grpc" || os.Getenv(logs_report_protocol) == "grpc" {
+			logExporter, err = otlploggrpc.New(ctx)
+		} else {
+			logExporter, err = otlploghttp.New(ctx)
+		}
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", "Failed to create the OpenTelemetry log exporter", err)
+	}
+	var processor sdklog.Processor
+	if testaccess.IsInTest() {
+		// in test, we just send the record immediately
+		processor = sdklog.NewSimpleProcessor(logExporter)
+	} else {
+		processor = sdklog.NewBatchProcessor(logExporter)
+	}
+	loggerProvider = sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(processor),
+	)
+	// Errors of the sdk would go through the default logger, which is bridged
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.SDKLogger.Print(err)
+	}))
+	otel.SetLogger(stdr.New(logger.SDKLogger))
+	global.SetLoggerProvider(loggerProvider)
+	logger.SetLoggerProvider(loggerProvider)
+}
+
+func initMetrics(res *resource.Resource) error {
 	ctx := context.Background()
 	// TODO: abstract the if-else
 	var err error
 	if testaccess.IsInTest() {
 		metricsProvider = metric.NewMeterProvider(
+			metric.WithResource(res),
 			metric.WithReader(testaccess.ManualReader),
 		)
 	} else {
@@ -156,6 +294,7 @@ func initMetrics() error {
 		} else if os.Getenv(metrics_exporter) == "console" {
 			metricExporter, err = stdoutmetric.New()
 			metricsProvider = metric.NewMeterProvider(
+				metric.WithResource(res),
 				metric.WithReader(metric.NewPeriodicReader(metricExporter)),
 			)
 		} else if os.Getenv(metrics_exporter) == "prometheus" {
@@ -164,6 +303,7 @@ func initMetrics() error {
 				log.Fatalf("Failed to create prometheus metric exporter: %v", err)
 			}
 			metricsProvider = metric.NewMeterProvider(
+				metric.WithResource(res),
 				metric.WithReader(promExporter),
 			)
 			go serveMetrics()
@@ -171,11 +311,13 @@ func initMetrics() error {
 			if os.Getenv(report_protocol) == "grpc" || os.Getenv(trace_report_protocol) == "grpc" {
 				metricExporter, err = otlpmetricgrpc.New(ctx)
 				metricsProvider = metric.NewMeterProvider(
+					metric.WithResource(res),
 					metric.WithReader(metric.NewPeriodicReader(metricExporter)),
 				)
 			} else {
 				metricExporter, err = otlpmetrichttp.New(ctx)
 				metricsProvider = metric.NewMeterProvider(
+					metric.WithResource(res),
 					metric.WithReader(metric.NewPeriodicReader(metricExporter)),
 				)
 			}
@@ -217,6 +359,7 @@ func serveMetrics() {
 }
 
 func gracefullyShutdown(ctx context.Context) {
+	enabler.Stop()
 	if metricsProvider != nil {
 		mp, ok := metricsProvider.(*metric.MeterProvider)
 		if ok {
@@ -226,6 +369,10 @@ func gracefullyShutdown(ctx context.Context) {
 	if traceProvider != nil {
 		_ = traceProvider.Shutdown(ctx)
 	}
+	if loggerProvider != nil {
+		// Shutting down the provider flushes and shuts down the exporter
+		_ = loggerProvider.Shutdown(ctx)
+	}
 	if spanExporter != nil {
 		_ = spanExporter.Shutdown(ctx)
 	}
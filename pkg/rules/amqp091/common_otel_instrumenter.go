@@ -85,12 +85,12 @@ type carrierGetter struct {
 }
 
 func (r *carrierGetter) Get(key string) string {
-	vInf, ok := r.req.headers[key]
-	if !ok {
-		return ""
-	}
-	if v, ok := vInf.(string); ok {
+	vInf, _ := utils.LookupMetadata(r.req.headers, key)
+	switch v := vInf.(type) {
+	case string:
 		return v
+	case []byte:
+		return string(v)
 	}
 	return ""
 }
@@ -18,25 +18,17 @@ import (
 	"context"
 	"database/sql"
 	"log"
-	"os"
 	"strings"
 
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 )
 
 var databaseSqlInstrumenter = BuildDatabaseSqlOtelInstrumenter()
 
-type dbSqlInnerEnabler struct {
-	enabled bool
-}
-
-func (d dbSqlInnerEnabler) Enable() bool {
-	return d.enabled
-}
-
-var dbSqlEnabler = dbSqlInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_DATABASESQL_ENABLED") != "false"}
+var dbSqlEnabler = instrumenter.RegisterInstrumentEnabler("databasesql", "OTEL_INSTRUMENTATION_DATABASESQL_ENABLED")
 
 const (
 	cacheUpperBound = 1024
@@ -70,9 +62,6 @@ func beforeOpenInstrumentation(call api.CallContext, driverName, dataSourceName
 
 //go:linkname afterOpenInstrumentation database/sql.afterOpenInstrumentation
 func afterOpenInstrumentation(call api.CallContext, db *sql.DB, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if db == nil {
 		return
 	}
@@ -107,9 +96,6 @@ func beforePingContextInstrumentation(call api.CallContext, db *sql.DB, ctx cont
 
 //go:linkname afterPingContextInstrumentation database/sql.afterPingContextInstrumentation
 func afterPingContextInstrumentation(call api.CallContext, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -131,9 +117,6 @@ func beforePrepareContextInstrumentation(call api.CallContext, db *sql.DB, ctx c
 
 //go:linkname afterPrepareContextInstrumentation database/sql.afterPrepareContextInstrumentation
 func afterPrepareContextInstrumentation(call api.CallContext, stmt *sql.Stmt, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if stmt == nil {
 		return
 	}
@@ -162,9 +145,6 @@ func beforeExecContextInstrumentation(call api.CallContext, db *sql.DB, ctx cont
 
 //go:linkname afterExecContextInstrumentation database/sql.afterExecContextInstrumentation
 func afterExecContextInstrumentation(call api.CallContext, result sql.Result, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -181,9 +161,6 @@ func beforeQueryContextInstrumentation(call api.CallContext, db *sql.DB, ctx con
 
 //go:linkname afterQueryContextInstrumentation database/sql.afterQueryContextInstrumentation
 func afterQueryContextInstrumentation(call api.CallContext, rows *sql.Rows, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -200,9 +177,6 @@ func beforeTxInstrumentation(call api.CallContext, db *sql.DB, ctx context.Conte
 
 //go:linkname afterTxInstrumentation database/sql.afterTxInstrumentation
 func afterTxInstrumentation(call api.CallContext, tx *sql.Tx, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if tx == nil {
 		return
 	}
@@ -237,9 +211,6 @@ func beforeConnInstrumentation(call api.CallContext, db *sql.DB, ctx context.Con
 
 //go:linkname afterConnInstrumentation database/sql.afterConnInstrumentation
 func afterConnInstrumentation(call api.CallContext, conn *sql.Conn, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if conn == nil {
 		return
 	}
@@ -274,9 +245,6 @@ func beforeConnPingContextInstrumentation(call api.CallContext, conn *sql.Conn,
 
 //go:linkname afterConnPingContextInstrumentation database/sql.afterConnPingContextInstrumentation
 func afterConnPingContextInstrumentation(call api.CallContext, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -298,9 +266,6 @@ func beforeConnPrepareContextInstrumentation(call api.CallContext, conn *sql.Con
 
 //go:linkname afterConnPrepareContextInstrumentation database/sql.afterConnPrepareContextInstrumentation
 func afterConnPrepareContextInstrumentation(call api.CallContext, stmt *sql.Stmt, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if stmt == nil {
 		return
 	}
@@ -329,9 +294,6 @@ func beforeConnExecContextInstrumentation(call api.CallContext, conn *sql.Conn,
 
 //go:linkname afterConnExecContextInstrumentation database/sql.afterConnExecContextInstrumentation
 func afterConnExecContextInstrumentation(call api.CallContext, result sql.Result, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -348,9 +310,6 @@ func beforeConnQueryContextInstrumentation(call api.CallContext, conn *sql.Conn,
 
 //go:linkname afterConnQueryContextInstrumentation database/sql.afterConnQueryContextInstrumentation
 func afterConnQueryContextInstrumentation(call api.CallContext, rows *sql.Rows, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -367,9 +326,6 @@ func beforeConnTxInstrumentation(call api.CallContext, conn *sql.Conn, ctx conte
 
 //go:linkname afterConnTxInstrumentation database/sql.afterConnTxInstrumentation
 func afterConnTxInstrumentation(call api.CallContext, tx *sql.Tx, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -391,9 +347,6 @@ func beforeTxPrepareContextInstrumentation(call api.CallContext, tx *sql.Tx, ctx
 
 //go:linkname afterTxPrepareContextInstrumentation database/sql.afterTxPrepareContextInstrumentation
 func afterTxPrepareContextInstrumentation(call api.CallContext, stmt *sql.Stmt, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if stmt == nil {
 		return
 	}
@@ -426,9 +379,6 @@ func beforeTxStmtContextInstrumentation(call api.CallContext, tx *sql.Tx, ctx co
 
 //go:linkname afterTxStmtContextInstrumentation database/sql.afterTxStmtContextInstrumentation
 func afterTxStmtContextInstrumentation(call api.CallContext, stmt *sql.Stmt) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	if stmt == nil {
 		return
 	}
@@ -464,9 +414,6 @@ func beforeTxExecContextInstrumentation(call api.CallContext, tx *sql.Tx, ctx co
 
 //go:linkname afterTxExecContextInstrumentation database/sql.afterTxExecContextInstrumentation
 func afterTxExecContextInstrumentation(call api.CallContext, result sql.Result, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -483,9 +430,6 @@ func beforeTxQueryContextInstrumentation(call api.CallContext, tx *sql.Tx, ctx c
 
 //go:linkname afterTxQueryContextInstrumentation database/sql.afterTxQueryContextInstrumentation
 func afterTxQueryContextInstrumentation(call api.CallContext, rows *sql.Rows, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -502,9 +446,6 @@ func beforeTxCommitInstrumentation(call api.CallContext, tx *sql.Tx) {
 
 //go:linkname afterTxCommitInstrumentation database/sql.afterTxCommitInstrumentation
 func afterTxCommitInstrumentation(call api.CallContext, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -521,9 +462,6 @@ func beforeTxRollbackInstrumentation(call api.CallContext, tx *sql.Tx) {
 
 //go:linkname afterTxRollbackInstrumentation database/sql.afterTxRollbackInstrumentation
 func afterTxRollbackInstrumentation(call api.CallContext, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -544,9 +482,6 @@ func beforeStmtExecContextInstrumentation(call api.CallContext, stmt *sql.Stmt,
 
 //go:linkname afterStmtExecContextInstrumentation database/sql.afterStmtExecContextInstrumentation
 func afterStmtExecContextInstrumentation(call api.CallContext, result sql.Result, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 
@@ -567,9 +502,6 @@ func beforeStmtQueryContextInstrumentation(call api.CallContext, stmt *sql.Stmt,
 
 //go:linkname afterStmtQueryContextInstrumentation database/sql.afterStmtQueryContextInstrumentation
 func afterStmtQueryContextInstrumentation(call api.CallContext, rows *sql.Rows, err error) {
-	if !dbSqlEnabler.Enable() {
-		return
-	}
 	instrumentEnd(call, err)
 }
 func instrumentStart(call api.CallContext, ctx context.Context, spanName, query, endpoint, driverName, dsn string, args ...any) {
@@ -57,10 +57,10 @@ func dubboConsumerGracefulShutdownFilterInvokeOnEnter(call api.CallContext, _ in
 
 //go:linkname dubboConsumerGracefulShutdownFilterInvokeOnExit dubbo.apache.org/dubbo-go/v3/filter/graceful_shutdown.dubboConsumerGracefulShutdownFilterInvokeOnExit
 func dubboConsumerGracefulShutdownFilterInvokeOnExit(call api.CallContext, res protocol.Result) {
-	if !dubboEnabler.Enable() {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok {
 		return
 	}
-	data := call.GetData().(map[string]interface{})
 	ctx, ok := data["ctx"].(context.Context)
 	if !ok {
 		return
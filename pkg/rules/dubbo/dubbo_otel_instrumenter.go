@@ -15,8 +15,6 @@
 package dubbo
 
 import (
-	"os"
-
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/rpc"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
@@ -26,15 +24,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-type dubboEnable struct {
-	enable bool
-}
-
-func (d *dubboEnable) Enable() bool {
-	return d.enable
-}
-
-var dubboEnabler = dubboEnable{os.Getenv("OTEL_INSTRUMENTATION_DUBBO_ENABLED") != "false"}
+var dubboEnabler = instrumenter.RegisterInstrumentEnabler("dubbo", "OTEL_INSTRUMENTATION_DUBBO_ENABLED")
 
 type dubboAttrsGetter struct{}
 
@@ -55,11 +55,10 @@ func dubboProviderGracefulShutdownFilterInvokeOnEnter(call api.CallContext, _ in
 
 //go:linkname dubboProviderGracefulShutdownFilterInvokeOnExit dubbo.apache.org/dubbo-go/v3/filter/graceful_shutdown.dubboProviderGracefulShutdownFilterInvokeOnExit
 func dubboProviderGracefulShutdownFilterInvokeOnExit(call api.CallContext, res protocol.Result) {
-	if !dubboEnabler.Enable() {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok {
 		return
 	}
-
-	data := call.GetData().(map[string]interface{})
 	ctx, ok := data["ctx"].(context.Context)
 	if !ok {
 		return
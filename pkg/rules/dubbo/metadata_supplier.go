@@ -19,6 +19,7 @@ import (
 )
 
 import (
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
 	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
@@ -31,17 +32,18 @@ type dubboMetadataSupplier struct {
 var _ propagation.TextMapCarrier = &dubboMetadataSupplier{}
 
 func (s *dubboMetadataSupplier) Get(key string) string {
-	if s.metadata == nil {
-		return ""
-	}
-	item, ok := s.metadata[key].([]string)
-	if !ok {
-		return ""
-	}
-	if len(item) == 0 {
-		return ""
+	item, _ := utils.LookupMetadata(s.metadata, key)
+	// Attachments are strings in the dubbo protocol, but string slices in the
+	// triple protocol
+	switch v := item.(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
 	}
-	return item[0]
+	return ""
 }
 
 func (s *dubboMetadataSupplier) Set(key string, value string) {
@@ -15,23 +15,15 @@
 package echo
 
 import (
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	echo "github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-type echoInnerEnabler struct {
-	enabled bool
-}
-
-func (e echoInnerEnabler) Enable() bool {
-	return e.enabled
-}
-
-var echoEnabler = echoInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_ECHO_ENABLED") != "false"}
+var echoEnabler = instrumenter.RegisterInstrumentEnabler("echo", "OTEL_INSTRUMENTATION_ECHO_ENABLED")
 
 func otelTraceMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -17,26 +17,18 @@ package elasticsearch
 import (
 	"context"
 	"net/http"
-	"os"
 	"strings"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/elastic/elastic-transport-go/v8/elastictransport"
 	elasticsearch "github.com/elastic/go-elasticsearch/v8"
 )
 
 var esInstrumenter = BuildElasticSearchInstrumenter()
 
-type esInnerEnabler struct {
-	enabled bool
-}
-
-func (g esInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var esEnabler = esInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_ELASTICSEARCH_ENABLED") != "false"}
+var esEnabler = instrumenter.RegisterInstrumentEnabler("elasticsearch", "OTEL_INSTRUMENTATION_ELASTICSEARCH_ENABLED")
 
 //go:linkname beforeElasticSearchPerform github.com/elastic/go-elasticsearch/v8.beforeElasticSearchPerform
 func beforeElasticSearchPerform(call api.CallContext, client *elasticsearch.BaseClient, request *http.Request) {
@@ -61,10 +53,10 @@ func beforeElasticSearchPerform(call api.CallContext, client *elasticsearch.Base
 
 //go:linkname afterElasticSearchPerform github.com/elastic/go-elasticsearch/v8.afterElasticSearchPerform
 func afterElasticSearchPerform(call api.CallContext, response *http.Response, err error) {
-	if !esEnabler.Enable() {
+	newCtx, ok := call.GetKeyData("ctx").(context.Context)
+	if !ok {
 		return
 	}
-	newCtx := call.GetKeyData("ctx").(context.Context)
 	er := call.GetKeyData("request").(*esRequest)
 	esInstrumenter.End(newCtx, er, response, err)
 }
@@ -55,10 +55,10 @@ func clientFastHttpOnEnter(call api.CallContext, c *fasthttp.HostClient, req *fa
 
 //go:linkname clientFastHttpOnExit github.com/valyala/fasthttp.clientFastHttpOnExit
 func clientFastHttpOnExit(call api.CallContext, err error) {
-	if !fastHttpEnabler.Enable() {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok {
 		return
 	}
-	data := call.GetData().(map[string]interface{})
 	ctx := data["ctx"].(context.Context)
 	request := data["request"].(fastHttpRequest)
 	resp := data["response"].(*fasthttp.Response)
@@ -17,7 +17,6 @@ import (
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/version"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
-	"os"
 	"strconv"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/http"
@@ -30,15 +29,7 @@ import (
 
 var emptyFastHttpResponse = fastHttpResponse{}
 
-type fastHttpInnerEnabler struct {
-	enabled bool
-}
-
-func (g fastHttpInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var fastHttpEnabler = fastHttpInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_FASTHTTP_ENABLED") != "false"}
+var fastHttpEnabler = instrumenter.RegisterInstrumentEnabler("fasthttp", "OTEL_INSTRUMENTATION_FASTHTTP_ENABLED")
 
 type fastHttpClientAttrsGetter struct {
 }
@@ -14,16 +14,6 @@
 
 package gin
 
-import (
-	"os"
-)
+import "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 
-type ginInnerEnabler struct {
-	enabled bool
-}
-
-func (g ginInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var ginEnabler = ginInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GIN_ENABLED") != "false"}
+var ginEnabler = instrumenter.RegisterInstrumentEnabler("gin", "OTEL_INSTRUMENTATION_GIN_ENABLED")
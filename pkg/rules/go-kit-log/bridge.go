@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	otellog "go.opentelemetry.io/otel/log"
+)
+
+const kitlogScope = "github.com/go-kit/log"
+
+// emitKitlogRecord copies keyvals to OpenTelemetry, where the value of "msg"
+// becomes the body and the value of "level" decides the severity, all other
+// pairs are kept as attributes. A context value decides the span of the record
+// instead of being copied. It must be called before trace_id and span_id are
+// appended.
+func emitKitlogRecord(keyvals []interface{}) {
+	if !logger.Enabled() {
+		return
+	}
+	ctx := context.Background()
+	var record otellog.Record
+	record.SetTimestamp(time.Now())
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		if c, ok := value.(context.Context); ok {
+			ctx = c
+			continue
+		}
+		switch key {
+		case "msg", "message":
+			record.SetBody(otellog.StringValue(fmt.Sprint(value)))
+		case "level":
+			text := fmt.Sprint(value)
+			record.SetSeverity(kitlogSeverity(text))
+			record.SetSeverityText(text)
+		default:
+			record.AddAttributes(logger.KeyValue(key, value))
+		}
+	}
+	logger.Emit(ctx, kitlogScope, record)
+}
+
+func kitlogSeverity(level string) otellog.Severity {
+	switch strings.ToLower(level) {
+	case "debug":
+		return otellog.SeverityDebug
+	case "info":
+		return otellog.SeverityInfo
+	case "warn":
+		return otellog.SeverityWarn
+	case "error":
+		return otellog.SeverityError
+	default:
+		return otellog.SeverityUndefined
+	}
+}
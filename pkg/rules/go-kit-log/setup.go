@@ -15,28 +15,21 @@
 package log
 
 import (
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-type kitlogInnerEnabler struct {
-	enabled bool
-}
-
-func (g kitlogInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var kitlogEnabler = kitlogInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GOKITLOG_ENABLED") != "false"}
+var kitlogEnabler = instrumenter.RegisterInstrumentEnabler("gokitlog", "OTEL_INSTRUMENTATION_GOKITLOG_ENABLED")
 
 //go:linkname logfmtLoggerLogOnEnter github.com/go-kit/log.logfmtLoggerLogOnEnter
 func logfmtLoggerLogOnEnter(call api.CallContext, _ interface{}, keyvals ...interface{}) {
 	if !kitlogEnabler.Enable() {
 		return
 	}
+	emitKitlogRecord(keyvals)
 
 	traceId, spanId := trace.GetTraceAndSpanId()
 	if traceId == "" && spanId == "" {
@@ -60,6 +53,7 @@ func jsonLoggerLogOnEnter(call api.CallContext, _ interface{}, kervals ...interf
 	if !kitlogEnabler.Enable() {
 		return
 	}
+	emitKitlogRecord(kervals)
 
 	traceId, spanId := trace.GetTraceAndSpanId()
 	if traceId == "" && spanId == "" {
@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	otellog "go.opentelemetry.io/otel/log"
+)
+
+const goLogScope = "log"
+
+// emitGoLogRecord copies the formatted message to OpenTelemetry. The log
+// package has no notion of severity, so it's left undefined. Messages written
+// by the default handler of log/slog are skipped, they are copied by the slog
+// bridge along with their levels and attributes, and so are messages of the
+// sdk itself. The log package takes no context, so the record belongs to the
+// current span of the goroutine.
+func emitGoLogRecord(l *log.Logger, calldepth int, msg []byte) {
+	if l == nil || l == logger.SDKLogger || !logger.Enabled() {
+		return
+	}
+	// The default handler of log/slog is the only caller that passes 0
+	if calldepth == 0 {
+		return
+	}
+	var record otellog.Record
+	record.SetTimestamp(time.Now())
+	record.SetBody(otellog.StringValue(string(bytes.TrimSuffix(msg, []byte("\n")))))
+	logger.Emit(context.Background(), goLogScope, record)
+}
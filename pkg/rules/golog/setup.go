@@ -16,23 +16,15 @@ package golog
 
 import (
 	"log"
-	"os"
 	"strings"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-type glogInnerEnabler struct {
-	enabled bool
-}
-
-func (g glogInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var glogEnabler = glogInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GLOG_ENABLED") != "false"}
+var glogEnabler = instrumenter.RegisterInstrumentEnabler("glog", "OTEL_INSTRUMENTATION_GLOG_ENABLED")
 
 //go:linkname goLogWriteOnEnter log.goLogWriteOnEnter
 func goLogWriteOnEnter(call api.CallContext, ce *log.Logger, pc uintptr, calldepth int, appendOutput func([]byte) []byte) {
@@ -51,7 +43,9 @@ func goLogWriteOnEnter(call api.CallContext, ce *log.Logger, pc uintptr, calldep
 			sb.WriteString(spanId)
 		}
 		bytes = append(bytes, []byte(sb.String())...)
+		start := len(bytes)
 		bytes = appendOutput(bytes)
+		emitGoLogRecord(ce, calldepth, bytes[start:])
 		sb.Reset()
 		return bytes
 	}
@@ -14,16 +14,6 @@
 
 package gomicro
 
-import (
-	"os"
-)
+import "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 
-type goMicroInnerEnabler struct {
-	enabled bool
-}
-
-func (g goMicroInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var goMicroEnabler = goMicroInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GOMICRO_ENABLED") != "false"}
+var goMicroEnabler = instrumenter.RegisterInstrumentEnabler("gomicro", "OTEL_INSTRUMENTATION_GOMICRO_ENABLED")
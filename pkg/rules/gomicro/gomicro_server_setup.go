@@ -54,9 +54,6 @@ func ServeRequestOnEnter(call api.CallContext, _ interface{}, ctx context.Contex
 
 //go:linkname ServeRequestOnExit go-micro.dev/v5/server.ServeRequestOnExit
 func ServeRequestOnExit(call api.CallContext, r error) {
-	if !goMicroEnabler.Enable() {
-		return
-	}
 	data, ok := call.GetData().(map[string]interface{})
 	if !ok || data == nil || data["ctx"] == nil {
 		return
@@ -17,25 +17,15 @@ package gopg
 import (
 	"context"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
-	"os"
 	_ "unsafe"
 )
 
 var requestKey = "otel-request"
 
-type gopgInnerEnabler struct {
-	enabled bool
-}
-
-func (g gopgInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var gopgEnabler = gopgInnerEnabler{
-	enabled: os.Getenv("OTEL_INSTRUMENTATION_GOPG_ENABLED") != "false",
-}
+var gopgEnabler = instrumenter.RegisterInstrumentEnabler("gopg", "OTEL_INSTRUMENTATION_GOPG_ENABLED")
 
 var gopgInstrumenter = BuildGopgInstrumenter()
 
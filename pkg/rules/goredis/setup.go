@@ -17,11 +17,11 @@ package goredis
 import (
 	"context"
 	"net"
-	"os"
 	"strings"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"go.opentelemetry.io/otel/trace"
 
 	redis "github.com/redis/go-redis/v9"
@@ -29,39 +29,22 @@ import (
 
 var goRedisInstrumenter = BuildGoRedisOtelInstrumenter()
 
-type redisV9InnerEnabler struct {
-	enabled bool
-}
-
-func (r redisV9InnerEnabler) Enable() bool {
-	return r.enabled
-}
-
-var rv9Enabler = redisV9InnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_REDISV9_ENABLED") != "false"}
+var rv9Enabler = instrumenter.RegisterInstrumentEnabler("redisv9", "OTEL_INSTRUMENTATION_REDISV9_ENABLED")
 
 var redisV9StartOptions = []trace.SpanStartOption{}
 
 //go:linkname afterNewRedisClient github.com/redis/go-redis/v9.afterNewRedisClient
 func afterNewRedisClient(call api.CallContext, client *redis.Client) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisHook(client.Options().Addr))
 }
 
 //go:linkname afterNewFailOverRedisClient github.com/redis/go-redis/v9.afterNewFailOverRedisClient
 func afterNewFailOverRedisClient(call api.CallContext, client *redis.Client) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisHook(client.Options().Addr))
 }
 
 //go:linkname afterNewClusterClient github.com/redis/go-redis/v9.afterNewClusterClient
 func afterNewClusterClient(call api.CallContext, client *redis.ClusterClient) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.OnNewNode(func(rdb *redis.Client) {
 		rdb.AddHook(newOtRedisHook(rdb.Options().Addr))
 	})
@@ -69,9 +52,6 @@ func afterNewClusterClient(call api.CallContext, client *redis.ClusterClient) {
 
 //go:linkname afterNewRingClient github.com/redis/go-redis/v9.afterNewRingClient
 func afterNewRingClient(call api.CallContext, client *redis.Ring) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.OnNewNode(func(rdb *redis.Client) {
 		rdb.AddHook(newOtRedisHook(rdb.Options().Addr))
 	})
@@ -79,20 +59,16 @@ func afterNewRingClient(call api.CallContext, client *redis.Ring) {
 
 //go:linkname afterNewSentinelClient github.com/redis/go-redis/v9.afterNewSentinelClient
 func afterNewSentinelClient(call api.CallContext, client *redis.SentinelClient) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisHook(client.String()))
 }
 
 //go:linkname afterClientConn github.com/redis/go-redis/v9.afterClientConn
 func afterClientConn(call api.CallContext, client *redis.Conn) {
-	if !rv9Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisHook(client.String()))
 }
 
+// otRedisHook is added to every client, whether the instrumentation is enabled
+// is checked by every command, so that it can be switched at runtime
 type otRedisHook struct {
 	Addr string
 }
@@ -115,6 +91,9 @@ func (o *otRedisHook) DialHook(next redis.DialHook) redis.DialHook {
 
 func (o *otRedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !rv9Enabler.Enable() {
+			return next(ctx, cmd)
+		}
 		if strings.Contains(cmd.FullName(), "ping") || strings.Contains(cmd.FullName(), "PING") {
 			return next(ctx, cmd)
 		}
@@ -134,6 +113,9 @@ func (o *otRedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 
 func (o *otRedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
 	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !rv9Enabler.Enable() {
+			return next(ctx, cmds)
+		}
 		summary := ""
 		summaryCmds := cmds
 		if len(summaryCmds) > 10 {
@@ -3,66 +3,43 @@ package goredisv8
 import (
 	"context"
 	"errors"
-	"os"
 	"strings"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	redis "github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/otel/trace"
 )
 
 var redisv8Instrumenter = BuildRedisv8Instrumenter()
 
-type redisV8InnerEnabler struct {
-	enabled bool
-}
-
-func (g redisV8InnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var rv8Enabler = redisV8InnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_REDISV8_ENABLED") != "false"}
+var rv8Enabler = instrumenter.RegisterInstrumentEnabler("redisv8", "OTEL_INSTRUMENTATION_REDISV8_ENABLED")
 
 var redisV8StartOptions = []trace.SpanStartOption{}
 
 //go:linkname afterNewRedisV8Client github.com/go-redis/redis/v8.afterNewRedisV8Client
 func afterNewRedisV8Client(call api.CallContext, client *redis.Client) {
-	if !rv8Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisV8Hook(client.Options().Addr))
 }
 
 //go:linkname afterNewFailOverRedisV8Client github.com/go-redis/redis/v8.afterNewFailOverRedisV8Client
 func afterNewFailOverRedisV8Client(call api.CallContext, client *redis.Client) {
-	if !rv8Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisV8Hook(client.Options().Addr))
 }
 
 //go:linkname afterNewConnRedisV8Client github.com/go-redis/redis/v8.afterNewConnRedisV8Client
 func afterNewConnRedisV8Client(call api.CallContext, conn *redis.Conn) {
-	if !rv8Enabler.Enable() {
-		return
-	}
 	conn.AddHook(newOtRedisV8Hook(conn.String()))
 }
 
 //go:linkname afterNewClusterV8Client github.com/go-redis/redis/v8.afterNewClusterV8Client
 func afterNewClusterV8Client(call api.CallContext, client *redis.ClusterClient) {
-	if !rv8Enabler.Enable() {
-		return
-	}
 	client.AddHook(newOtRedisV8Hook(strings.Join(client.Options().Addrs, ",")))
 }
 
 //go:linkname afterNewRingV8Client github.com/go-redis/redis/v8.afterNewRingV8Client
 func afterNewRingV8Client(call api.CallContext, client *redis.Ring) {
-	if !rv8Enabler.Enable() {
-		return
-	}
 	addrBuilder := strings.Builder{}
 	for addr, _ := range client.Options().Addrs {
 		addrBuilder.WriteString(addr)
@@ -70,6 +47,8 @@ func afterNewRingV8Client(call api.CallContext, client *redis.Ring) {
 	client.AddHook(newOtRedisV8Hook(addrBuilder.String()))
 }
 
+// otRedisV8Hook is added to every client, whether the instrumentation is
+// enabled is checked by every command, so that it can be switched at runtime
 type otRedisV8Hook struct {
 	Addr string
 }
@@ -81,6 +60,9 @@ func newOtRedisV8Hook(addr string) *otRedisV8Hook {
 }
 
 func (o *otRedisV8Hook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if !rv8Enabler.Enable() {
+		return ctx, nil
+	}
 	request := redisv8Data{
 		cmd:  cmd,
 		Host: o.Addr,
@@ -95,15 +77,19 @@ func (o *otRedisV8Hook) AfterProcess(ctx context.Context, cmd redis.Cmder) error
 		cmd:  cmd,
 		Host: o.Addr,
 	}
+	// Nothing was started if the instrumentation was disabled before
 	redisV8Ctx, ok := ctx.Value(redisV8Context).(context.Context)
 	if !ok {
-		redisV8Ctx = ctx
+		return nil
 	}
 	redisv8Instrumenter.End(redisV8Ctx, request, nil, cmd.Err())
 	return nil
 }
 
 func (o *otRedisV8Hook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if !rv8Enabler.Enable() {
+		return ctx, nil
+	}
 	request := redisv8Data{
 		cmd:  pipelineCmd,
 		Host: o.Addr,
@@ -128,9 +114,10 @@ func (o *otRedisV8Hook) AfterProcessPipeline(ctx context.Context, cmds []redis.C
 		}
 	}
 	tError = errors.New(errSb.String())
+	// Nothing was started if the instrumentation was disabled before
 	redisV8Ctx, ok := ctx.Value(redisV8Context).(context.Context)
 	if !ok {
-		redisV8Ctx = ctx
+		return nil
 	}
 	if hasError {
 		redisv8Instrumenter.End(redisV8Ctx, request, nil, tError)
@@ -16,10 +16,10 @@ package gorm
 
 import (
 	"context"
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	driver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -28,15 +28,7 @@ import (
 var contextKey = "otel-context"
 var requestKey = "otel-request"
 
-type gormInnerEnabler struct {
-	enabled bool
-}
-
-func (g gormInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var gormEnabler = gormInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GORM_ENABLED") != "false"}
+var gormEnabler = instrumenter.RegisterInstrumentEnabler("gorm", "OTEL_INSTRUMENTATION_GORM_ENABLED")
 
 var gormInstrumenter = BuildGormInstrumenter()
 
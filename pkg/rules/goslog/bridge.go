@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	"go.opentelemetry.io/otel/log"
+)
+
+const goSlogScope = "log/slog"
+
+// emitGoSlogRecord copies the record to OpenTelemetry, attributes that are
+// attached to the logger by With are not visible to the hook
+func emitGoSlogRecord(l *slog.Logger, ctx context.Context, level slog.Level,
+	msg string, args []any) {
+	if l == nil || !logger.Enabled() {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetBody(log.StringValue(msg))
+	// slog levels are four apart from each other, as severities are
+	record.SetSeverity(log.Severity(level + 9))
+	record.SetSeverityText(level.String())
+	// Let slog pair up args, which handles bad keys the same way as handlers
+	r := slog.NewRecord(time.Time{}, level, msg, 0)
+	r.Add(args...)
+	r.Attrs(func(attr slog.Attr) bool {
+		record.AddAttributes(log.KeyValue{
+			Key:   attr.Key,
+			Value: goSlogValue(attr.Value),
+		})
+		return true
+	})
+	logger.Emit(ctx, goSlogScope, record)
+}
+
+func goSlogValue(v slog.Value) log.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		kvs := make([]log.KeyValue, 0, len(attrs))
+		for _, attr := range attrs {
+			kvs = append(kvs, log.KeyValue{
+				Key:   attr.Key,
+				Value: goSlogValue(attr.Value),
+			})
+		}
+		return log.MapValue(kvs...)
+	default:
+		return logger.Value(v.Any())
+	}
+}
@@ -17,28 +17,21 @@ package golog
 import (
 	"context"
 	"log/slog"
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-type goSlogInnerEnabler struct {
-	enabled bool
-}
-
-func (g goSlogInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var goSlogEnabler = goSlogInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GOSLOG_ENABLED") != "false"}
+var goSlogEnabler = instrumenter.RegisterInstrumentEnabler("goslog", "OTEL_INSTRUMENTATION_GOSLOG_ENABLED")
 
 //go:linkname goSlogWriteOnEnter log/slog.goSlogWriteOnEnter
 func goSlogWriteOnEnter(call api.CallContext, ce *slog.Logger, ctx context.Context, level slog.Level, msg string, args ...any) {
 	if !goSlogEnabler.Enable() {
 		return
 	}
+	emitGoSlogRecord(ce, ctx, level, msg, args)
 	traceId, spanId := trace.GetTraceAndSpanId()
 	if traceId != "" {
 		msg = msg + " trace_id=" + traceId
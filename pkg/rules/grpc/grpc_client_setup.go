@@ -37,9 +37,6 @@ func grpcClientOnEnter(call api.CallContext, ctx context.Context, target string,
 
 //go:linkname grpcClientOnExit google.golang.org/grpc.grpcClientOnExit
 func grpcClientOnExit(call api.CallContext, cc *grpc.ClientConn, err error) {
-	if !grpcEnabler.Enable() {
-		return
-	}
 	return
 }
 
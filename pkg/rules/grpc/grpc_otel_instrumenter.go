@@ -19,7 +19,6 @@ import (
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/version"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
-	"os"
 	"strings"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/rpc"
@@ -28,15 +27,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-type grpcInnerEnabler struct {
-	enabled bool
-}
-
-func (g grpcInnerEnabler) Enable() bool {
-	return g.enabled
-}
-
-var grpcEnabler = grpcInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_GRPC_ENABLED") != "false"}
+var grpcEnabler = instrumenter.RegisterInstrumentEnabler("grpc", "OTEL_INSTRUMENTATION_GRPC_ENABLED")
 
 type grpcAttrsGetter struct {
 }
@@ -40,9 +40,6 @@ func grpcServerOnEnter(call api.CallContext, opts ...grpc.ServerOption) {
 
 //go:linkname grpcServerOnExit google.golang.org/grpc.grpcServerOnExit
 func grpcServerOnExit(call api.CallContext, s *grpc.Server) {
-	if !grpcEnabler.Enable() {
-		return
-	}
 	return
 }
 
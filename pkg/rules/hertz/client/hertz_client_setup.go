@@ -16,23 +16,15 @@ package client
 
 import (
 	"context"
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
 )
 
-type hertzClientInnerEnabler struct {
-	enabled bool
-}
-
-func (h hertzClientInnerEnabler) Enable() bool {
-	return h.enabled
-}
-
-var hertzClientEnabler = hertzClientInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_HERTZ_ENABLED") != "false"}
+var hertzClientEnabler = instrumenter.RegisterInstrumentEnabler("hertz", "OTEL_INSTRUMENTATION_HERTZ_ENABLED")
 
 var hertzClientInstrumenter = BuildHertzClientInstrumenter()
 
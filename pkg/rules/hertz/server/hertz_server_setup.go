@@ -16,25 +16,17 @@ package server
 
 import (
 	"context"
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/config"
 	"github.com/cloudwego/hertz/pkg/common/tracer/stats"
 )
 
-type hertzServerInnerEnabler struct {
-	enabled bool
-}
-
-func (h hertzServerInnerEnabler) Enable() bool {
-	return h.enabled
-}
-
-var hertzServerEnabler = hertzServerInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_HERTZ_ENABLED") != "false"}
+var hertzServerEnabler = instrumenter.RegisterInstrumentEnabler("hertz", "OTEL_INSTRUMENTATION_HERTZ_ENABLED")
 
 var hertzInstrumenter = BuildHertzServerInstrumenter()
 
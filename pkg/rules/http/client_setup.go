@@ -62,9 +62,6 @@ func clientOnEnter(call api.CallContext, t *http.Transport, req *http.Request) {
 
 //go:linkname clientOnExit net/http.clientOnExit
 func clientOnExit(call api.CallContext, res *http.Response, err error) {
-	if !netHttpEnabler.Enable() {
-		return
-	}
 	data, ok := call.GetData().(map[string]interface{})
 	if !ok || data == nil || data["ctx"] == nil {
 		return
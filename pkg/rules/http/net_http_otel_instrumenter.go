@@ -15,7 +15,6 @@
 package http
 
 import (
-	"os"
 	"strconv"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
@@ -29,15 +28,7 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 )
 
-type netHttpInnerEnabler struct {
-	enabled bool
-}
-
-func (n netHttpInnerEnabler) Enable() bool {
-	return n.enabled
-}
-
-var netHttpEnabler = netHttpInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_NETHTTP_ENABLED") != "false"}
+var netHttpEnabler = instrumenter.RegisterInstrumentEnabler("nethttp", "OTEL_INSTRUMENTATION_NETHTTP_ENABLED")
 
 var emptyHttpResponse = netHttpResponse{}
 
@@ -58,9 +58,6 @@ func serverOnEnter(call api.CallContext, _ interface{}, w http.ResponseWriter, r
 
 //go:linkname serverOnExit net/http.serverOnExit
 func serverOnExit(call api.CallContext) {
-	if !netHttpEnabler.Enable() {
-		return
-	}
 	data, ok := call.GetData().(map[string]interface{})
 	if !ok || data == nil || data["ctx"] == nil {
 		return
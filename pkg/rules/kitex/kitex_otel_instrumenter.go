@@ -20,21 +20,12 @@ import (
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/version"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
-	"os"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/rpc"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 )
 
-type kitexInnerEnabler struct {
-	enabled bool
-}
-
-func (k kitexInnerEnabler) Enable() bool {
-	return k.enabled
-}
-
-var kitexEnabler = kitexInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_KITEX_ENABLED") != "false"}
+var kitexEnabler = instrumenter.RegisterInstrumentEnabler("kitex", "OTEL_INSTRUMENTATION_KITEX_ENABLED")
 
 type kitexAttrsGetter struct{}
 
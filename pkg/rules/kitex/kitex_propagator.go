@@ -16,6 +16,7 @@ package kitex
 
 import (
 	"context"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
 	"github.com/bytedance/gopkg/cloud/metainfo"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -28,10 +29,8 @@ type metadataProvider struct {
 }
 
 func (m *metadataProvider) Get(key string) string {
-	if v, ok := m.metadata[key]; ok {
-		return v
-	}
-	return ""
+	v, _ := utils.LookupMetadata(m.metadata, key)
+	return v
 }
 
 func (m *metadataProvider) Set(key, value string) {
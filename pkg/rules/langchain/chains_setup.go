@@ -42,10 +42,10 @@ func callChainOnEnter(call api.CallContext, ctx context.Context,
 
 //go:linkname callChainOnExit github.com/tmc/langchaingo/chains.callChainOnExit
 func callChainOnExit(call api.CallContext, v map[string]any, err error) {
-	if !langChainEnabler.Enable() {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok {
 		return
 	}
-	data := call.GetData().(map[string]interface{})
 	ctx, ok := data["ctx"].(context.Context)
 	if !ok {
 		return
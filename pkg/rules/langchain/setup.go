@@ -15,7 +15,7 @@
 package langchain
 
 import (
-	"os"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 )
 
 const (
@@ -27,14 +27,6 @@ const (
 	MRelevantDoc       = "relevantDocuments"
 )
 
-type langChainInnerEnabler struct {
-	enabled bool
-}
-
-func (l langChainInnerEnabler) Enable() bool {
-	return l.enabled
-}
-
-var langChainEnabler = langChainInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_LANGCHAIN_ENABLED") != "false"}
+var langChainEnabler = instrumenter.RegisterInstrumentEnabler("langchain", "OTEL_INSTRUMENTATION_LANGCHAIN_ENABLED")
 
 var langChainCommonInstrument = BuildCommonLangchainOtelInstrumenter()
@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrus
+
+import (
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/log"
+)
+
+const logrusScope = "github.com/sirupsen/logrus"
+
+// emitLogrusRecord copies the entry to OpenTelemetry, it must be called before
+// trace_id and span_id are added to the entry
+func emitLogrusRecord(entry *logrus.Entry) {
+	if !logger.Enabled() {
+		return
+	}
+	var record log.Record
+	record.SetTimestamp(entry.Time)
+	record.SetBody(log.StringValue(entry.Message))
+	record.SetSeverity(logrusSeverity(entry.Level))
+	record.SetSeverityText(entry.Level.String())
+	if entry.Caller != nil {
+		record.AddAttributes(
+			log.String("code.function", entry.Caller.Function),
+			log.String("code.filepath", entry.Caller.File),
+			log.Int("code.lineno", entry.Caller.Line),
+		)
+	}
+	for k, v := range entry.Data {
+		record.AddAttributes(logger.KeyValue(k, v))
+	}
+	logger.Emit(entry.Context, logrusScope, record)
+}
+
+func logrusSeverity(level logrus.Level) log.Severity {
+	switch level {
+	case logrus.TraceLevel:
+		return log.SeverityTrace
+	case logrus.DebugLevel:
+		return log.SeverityDebug
+	case logrus.InfoLevel:
+		return log.SeverityInfo
+	case logrus.WarnLevel:
+		return log.SeverityWarn
+	case logrus.ErrorLevel:
+		return log.SeverityError
+	case logrus.FatalLevel:
+		return log.SeverityFatal
+	case logrus.PanicLevel:
+		return log.SeverityFatal2
+	default:
+		return log.SeverityUndefined
+	}
+}
@@ -18,20 +18,12 @@ import (
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/sdk/trace"
-	"os"
 )
 
-type logrusInnerEnabler struct {
-	enabled bool
-}
-
-func (l logrusInnerEnabler) Enable() bool {
-	return l.enabled
-}
-
-var logrusEnabler = logrusInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_LOGRUS_ENABLED") != "false"}
+var logrusEnabler = instrumenter.RegisterInstrumentEnabler("logrus", "OTEL_INSTRUMENTATION_LOGRUS_ENABLED")
 
 //go:linkname logNewOnEnter github.com/sirupsen/logrus.logNewOnEnter
 func logNewOnEnter(call api.CallContext, log *logrus.Logger, formatter logrus.Formatter) {
@@ -43,10 +35,10 @@ func logNewOnEnter(call api.CallContext, log *logrus.Logger, formatter logrus.Fo
 
 //go:linkname logNewOnExit github.com/sirupsen/logrus.logNewOnExit
 func logNewOnExit(call api.CallContext) {
-	if !logrusEnabler.Enable() {
+	logger, ok := call.GetData().(*logrus.Logger)
+	if !ok {
 		return
 	}
-	logger := call.GetData().(*logrus.Logger)
 	logger.AddHook(&logHook{})
 	return
 }
@@ -61,6 +53,7 @@ func (hook *logHook) Fire(entry *logrus.Entry) error {
 	if !logrusEnabler.Enable() {
 		return nil
 	}
+	emitLogrusRecord(entry)
 	// 修改日志内容
 	traceId, spanId := trace.GetTraceAndSpanId()
 	if traceId != "" {
@@ -18,27 +18,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var mongoInstrumenter = BuildMongoOtelInstrumenter()
 
-type mongoInnerEnabler struct {
-	enabled bool
-}
-
-func (m mongoInnerEnabler) Enable() bool {
-	return m.enabled
-}
-
-var mongoEnabler = mongoInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_MONGO_ENABLED") != "false"}
+var mongoEnabler = instrumenter.RegisterInstrumentEnabler("mongo", "OTEL_INSTRUMENTATION_MONGO_ENABLED")
 
 //go:linkname mongoOnEnter go.mongodb.org/mongo-driver/mongo.mongoOnEnter
 func mongoOnEnter(call api.CallContext, opts ...*options.ClientOptions) {
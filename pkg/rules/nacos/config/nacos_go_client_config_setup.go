@@ -56,7 +56,7 @@ func beforeNewConfigClient(call api.CallContext, nc nacos_client.INacosClient) {
 
 //go:linkname afterNewConfigClient github.com/nacos-group/nacos-sdk-go/v2/clients/config_client.afterNewConfigClient
 func afterNewConfigClient(call api.CallContext, client *config_client.ConfigClient, err error) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("namespace") {
 		return
 	}
 	if client == nil {
@@ -133,7 +133,7 @@ func beforeCallConfigServer(call api.CallContext, server *nacos_server.NacosServ
 
 //go:linkname afterCallConfigServer github.com/nacos-group/nacos-sdk-go/v2/common/nacos_server.afterCallConfigServer
 func afterCallConfigServer(call api.CallContext, result string, err error) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("ts") {
 		return
 	}
 	method := call.GetKeyData("method").(string)
@@ -169,7 +169,7 @@ func beforeRequestProxy(call api.CallContext, cp *config_client.ConfigProxy, rpc
 
 //go:linkname afterRequestProxy github.com/nacos-group/nacos-sdk-go/v2/clients/config_client.afterRequestProxy
 func afterRequestProxy(call api.CallContext, resp rpc_response.IResponse, err error) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("ts") {
 		return
 	}
 	t := call.GetKeyData("ts").(int64)
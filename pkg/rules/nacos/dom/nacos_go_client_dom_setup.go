@@ -44,7 +44,7 @@ func beforeNewBeatReactor(call api.CallContext, clientCfg constant.ClientConfig,
 
 //go:linkname afterNewBeatReactor github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client/naming_http.afterNewBeatReactor
 func afterNewBeatReactor(call api.CallContext, b naming_http.BeatReactor) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("namespace") {
 		return
 	}
 	t := reflect.ValueOf(&b).Elem()
@@ -101,7 +101,7 @@ func beforeRequestToServer(call api.CallContext, proxy *naming_grpc.NamingGrpcPr
 
 //go:linkname afterRequestToServer github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client/naming_grpc.afterRequestToServer
 func afterRequestToServer(call api.CallContext, resp rpc_response.IResponse, err error) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("ts") {
 		return
 	}
 	t := call.GetKeyData("ts").(int64)
@@ -135,7 +135,7 @@ func beforeCallServer(call api.CallContext, server *nacos_server.NacosServer, ap
 
 //go:linkname afterCallServer github.com/nacos-group/nacos-sdk-go/v2/common/nacos_server.afterCallServer
 func afterCallServer(call api.CallContext, result string, err error) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("ts") {
 		return
 	}
 	method := call.GetKeyData("method").(string)
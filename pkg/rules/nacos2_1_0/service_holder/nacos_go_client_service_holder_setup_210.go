@@ -40,7 +40,7 @@ func beforeNewServiceInfoHolder210(call api.CallContext, namespace, cacheDir str
 
 //go:linkname afterNewServiceInfoHolder210 github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client/naming_cache.afterNewServiceInfoHolder210
 func afterNewServiceInfoHolder210(call api.CallContext, holder *naming_cache.ServiceInfoHolder) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("namespace") {
 		return
 	}
 	reg, err := experimental.GlobalMeter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
@@ -44,7 +44,7 @@ func beforeNewBeatReactor211(call api.CallContext, ctx context.Context, clientCf
 
 //go:linkname afterNewBeatReactor211 github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client/naming_http.afterNewBeatReactor211
 func afterNewBeatReactor211(call api.CallContext, b naming_http.BeatReactor) {
-	if !experimental.NacosEnabler.Enable() {
+	if !call.HasKeyData("namespace") {
 		return
 	}
 	t := reflect.ValueOf(&b).Elem()
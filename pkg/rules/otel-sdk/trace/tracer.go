@@ -37,6 +37,12 @@ type tracer struct {
 
 var _ trace.Tracer = &tracer{}
 
+// scopedSampler is a sampler that also decides by the instrumentation scope of
+// the tracer, e.g. the rule-based sampler of otel tool
+type scopedSampler interface {
+	ShouldSampleScope(SamplingParameters, instrumentation.Scope) SamplingResult
+}
+
 // Start starts a Span and returns it along with a context containing it.
 //
 // The Span is created with the provided name and as a child of any existing
@@ -105,14 +111,20 @@ func (tr *tracer) newSpan(ctx context.Context, name string, config *trace.SpanCo
 		sid = tr.provider.idGenerator.NewSpanID(ctx, tid)
 	}
 
-	samplingResult := tr.provider.sampler.ShouldSample(SamplingParameters{
+	params := SamplingParameters{
 		ParentContext: ctx,
 		TraceID:       tid,
 		Name:          name,
 		Kind:          config.SpanKind(),
 		Attributes:    config.Attributes(),
 		Links:         config.Links(),
-	})
+	}
+	var samplingResult SamplingResult
+	if ss, ok := tr.provider.sampler.(scopedSampler); ok {
+		samplingResult = ss.ShouldSampleScope(params, tr.instrumentationScope)
+	} else {
+		samplingResult = tr.provider.sampler.ShouldSample(params)
+	}
 
 	scc := trace.SpanContextConfig{
 		TraceID:    tid,
@@ -37,10 +37,6 @@ func consumerReadMessageOnEnter(call api.CallContext, _ interface{}, ctx context
 
 //go:linkname consumerReadMessageOnExit github.com/segmentio/kafka-go.consumerReadMessageOnExit
 func consumerReadMessageOnExit(call api.CallContext, message kafka.Message, err error) {
-	if !kafkaEnabler.Enable() {
-		return
-	}
-
 	instrumentationData, ok := call.GetData().(map[string]interface{})
 	if !ok {
 		return
@@ -28,11 +28,11 @@ import (
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"go.opentelemetry.io/otel/trace"
-	"os"
+	"strings"
 )
 
 // Instrumentation enabler controller
-var kafkaEnabler = kafkaInnerEnabler{os.Getenv("OTEL_SEGMENTIO_KAFKA_ENABLED") != "false"}
+var kafkaEnabler = instrumenter.RegisterInstrumentEnabler("segmentio_kafka", "OTEL_SEGMENTIO_KAFKA_ENABLED")
 
 // Cache Instrumenter instances to avoid repeated creation
 var (
@@ -40,14 +40,6 @@ var (
 	consumerInstrumenter = buildKafkaConsumerInstrumenter()
 )
 
-type kafkaInnerEnabler struct {
-	enabled bool
-}
-
-func (k kafkaInnerEnabler) Enable() bool {
-	return k.enabled
-}
-
 // KafkaProducerCarrier implements OpenTelemetry propagator carrier interface for producers
 type kafkaProducerCarrier struct {
 	messages []*kafka.Message
@@ -76,11 +68,15 @@ type kafkaConsumerCarrier struct {
 }
 
 func (carrier kafkaConsumerCarrier) Get(key string) string {
-	if carrier.message.Headers != nil {
-		for _, header := range carrier.message.Headers {
-			if header.Key == key {
-				return string(header.Value)
-			}
+	for _, header := range carrier.message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	// Producers of other languages may write keys in another case
+	for _, header := range carrier.message.Headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
 		}
 	}
 	return ""
@@ -91,7 +87,11 @@ func (carrier kafkaConsumerCarrier) Set(key, value string) {
 }
 
 func (carrier kafkaConsumerCarrier) Keys() []string {
-	return []string{}
+	keys := make([]string, 0, len(carrier.message.Headers))
+	for _, header := range carrier.message.Headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
 }
 
 // KafkaProducerStatusExtractor extracts producer operation status
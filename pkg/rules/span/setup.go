@@ -0,0 +1,205 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package span
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The built-in hooks of the "Span" shorthand of func rules. The otel tool
+// generates a pair of hooks for each span rule, which push the rule settings
+// to the generic implementation below, so that no hook code is required to
+// trace user functions.
+
+// Functions rarely return more than this number of values, we don't bother
+// looking for the error beyond that
+const maxReturnVals = 8
+
+var spanEnabler = instrumenter.RegisterInstrumentEnabler("span", "OTEL_INSTRUMENTATION_SPAN_ENABLED")
+
+// spanRule is the setting of the span rule, it's baked by the otel tool
+type spanRule struct {
+	// Whether the target function is a method, the span name then contains
+	// the receiver type
+	method bool
+	// Attribute keys of captured parameters, keyed by the parameter index
+	params map[int]string
+}
+
+type spanRequest struct {
+	name  string
+	attrs []attribute.KeyValue
+}
+
+type spanNameExtractor struct{}
+
+func (spanNameExtractor) Extract(request spanRequest) string {
+	return request.name
+}
+
+type spanAttrsExtractor struct{}
+
+func (spanAttrsExtractor) OnStart(attributes []attribute.KeyValue, parentContext context.Context, request spanRequest) ([]attribute.KeyValue, context.Context) {
+	return append(attributes, request.attrs...), parentContext
+}
+
+func (spanAttrsExtractor) OnEnd(attributes []attribute.KeyValue, context context.Context, request spanRequest, response any, err error) ([]attribute.KeyValue, context.Context) {
+	return attributes, context
+}
+
+// spanStatusExtractor keeps the error message as the status description, the
+// error itself is already recorded as an exception event by the instrumenter
+type spanStatusExtractor struct{}
+
+func (spanStatusExtractor) Extract(span trace.Span, request spanRequest, response any, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func buildSpanInstrumenter() instrumenter.Instrumenter[spanRequest, any] {
+	builder := instrumenter.Builder[spanRequest, any]{}
+	return builder.Init().SetSpanNameExtractor(&spanNameExtractor{}).
+		SetSpanKindExtractor(&instrumenter.AlwaysInternalExtractor[spanRequest]{}).
+		SetSpanStatusExtractor(&spanStatusExtractor{}).
+		AddAttributesExtractor(&spanAttrsExtractor{}).
+		SetInstrumentationScope(instrumentation.Scope{
+			Name:    utils.SPAN_SCOPE_NAME,
+			Version: version.Tag,
+		}).
+		BuildInstrumenter()
+}
+
+var spanInstrumenter = buildSpanInstrumenter()
+
+// spanName names the span after the function, e.g. "main.Foo" for functions
+// and "main.(*Server).Foo" for methods
+func spanName(call api.CallContext, rule *spanRule) string {
+	pkg := call.GetPackageName()
+	if rule.method {
+		recv := fmt.Sprintf("%T", call.GetParam(0))
+		// Strip the package qualifier and type arguments, e.g. "*main.Server"
+		// -> "*Server", "main.Cache[int]" -> "Cache"
+		if i := strings.Index(recv, "["); i >= 0 {
+			recv = recv[:i]
+		}
+		ptr := strings.HasPrefix(recv, "*")
+		if i := strings.LastIndex(recv, "."); i >= 0 {
+			recv = recv[i+1:]
+		}
+		if ptr {
+			recv = "(*" + recv + ")"
+		}
+		return pkg + "." + recv + "." + call.GetFuncName()
+	}
+	return pkg + "." + call.GetFuncName()
+}
+
+func toAttribute(key string, val interface{}) (attribute.KeyValue, bool) {
+	switch v := val.(type) {
+	case nil:
+		return attribute.KeyValue{}, false
+	case string:
+		return attribute.String(key, v), true
+	case bool:
+		return attribute.Bool(key, v), true
+	case int:
+		return attribute.Int(key, v), true
+	case int8:
+		return attribute.Int(key, int(v)), true
+	case int16:
+		return attribute.Int(key, int(v)), true
+	case int32:
+		return attribute.Int(key, int(v)), true
+	case int64:
+		return attribute.Int64(key, v), true
+	case uint8:
+		return attribute.Int64(key, int64(v)), true
+	case uint16:
+		return attribute.Int64(key, int64(v)), true
+	case uint32:
+		return attribute.Int64(key, int64(v)), true
+	case float32:
+		return attribute.Float64(key, float64(v)), true
+	case float64:
+		return attribute.Float64(key, v), true
+	case []string:
+		return attribute.StringSlice(key, v), true
+	case fmt.Stringer:
+		return attribute.String(key, v.String()), true
+	case error:
+		return attribute.String(key, v.Error()), true
+	}
+	return attribute.String(key, fmt.Sprint(val)), true
+}
+
+func spanOnEnter(call api.CallContext, rule *spanRule) {
+	if !spanEnabler.Enable() {
+		return
+	}
+	// Parent span comes from the context.Context parameter if any, it's
+	// either the first parameter or the one right after the receiver
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if c, ok := call.GetParam(i).(context.Context); ok && c != nil {
+			ctx = c
+			break
+		}
+	}
+	request := spanRequest{name: spanName(call, rule)}
+	for index, key := range rule.params {
+		if attr, ok := toAttribute(key, call.GetParam(index)); ok {
+			request.attrs = append(request.attrs, attr)
+		}
+	}
+	newCtx := spanInstrumenter.Start(ctx, request)
+	data := make(map[string]interface{}, 2)
+	data["ctx"] = newCtx
+	data["request"] = request
+	call.SetData(data)
+}
+
+func spanOnExit(call api.CallContext) {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok || data == nil {
+		return
+	}
+	ctx, ok := data["ctx"].(context.Context)
+	if !ok {
+		return
+	}
+	request, ok := data["request"].(spanRequest)
+	if !ok {
+		return
+	}
+	var err error
+	for i := 0; i < maxReturnVals; i++ {
+		if e, ok := call.GetReturnVal(i).(error); ok && e != nil {
+			err = e
+		}
+	}
+	spanInstrumenter.End(ctx, request, nil, err)
+}
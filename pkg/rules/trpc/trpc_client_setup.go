@@ -62,10 +62,10 @@ func clientTrpcOnEnter(call api.CallContext, _ interface{}, ctx context.Context,
 //
 //go:linkname clientTrpcOnExit trpc.group/trpc-go/trpc-go/client.clientTrpcOnExit
 func clientTrpcOnExit(call api.CallContext, err error) {
-	if !trpcEnabler.Enable() {
+	data, ok := call.GetData().(map[string]interface{})
+	if !ok {
 		return
 	}
-	data := call.GetData().(map[string]interface{})
 	ctx := data["ctx"].(context.Context)
 	request := data["request"].(trpcReq)
 	statusCode := 0
@@ -16,7 +16,6 @@ package trpc
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api-semconv/instrumenter/rpc"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
@@ -30,15 +29,7 @@ import (
 	"trpc.group/trpc-go/trpc-go/codec"
 )
 
-type trpcInnerEnabler struct {
-	enabled bool
-}
-
-func (t trpcInnerEnabler) Enable() bool {
-	return t.enabled
-}
-
-var trpcEnabler = trpcInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_TRPC_ENABLED") != "false"}
+var trpcEnabler = instrumenter.RegisterInstrumentEnabler("trpc", "OTEL_INSTRUMENTATION_TRPC_ENABLED")
 
 type trpcClientAttrsGetter struct {
 }
@@ -99,7 +90,8 @@ type trpcRequestCarrier struct {
 }
 
 func (t trpcRequestCarrier) Get(key string) string {
-	return string(t.reqHeader.ServerMetaData()[key])
+	v, _ := utils.LookupMetadata(t.reqHeader.ServerMetaData(), key)
+	return string(v)
 }
 
 func (t trpcRequestCarrier) Set(key string, value string) {
@@ -115,11 +107,12 @@ func (t trpcRequestCarrier) Set(key string, value string) {
 }
 
 func (t trpcRequestCarrier) Keys() []string {
-	vals := []string{}
-	for _, byteV := range t.reqHeader.ClientMetaData() {
-		vals = append(vals, string(byteV))
+	md := t.reqHeader.ServerMetaData()
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
 	}
-	return vals
+	return keys
 }
 
 func BuildTrpcClientInstrumenter() instrumenter.Instrumenter[trpcReq, trpcRes] {
@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"context"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	"go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+)
+
+const zapScope = "go.uber.org/zap"
+
+// emitZapRecord copies the entry and its fields to OpenTelemetry, fields that
+// are attached to the logger by With are not visible to the hook. zap takes no
+// context, so a context passed as a field, e.g. zap.Any("ctx", ctx), decides
+// the span of the record instead of being copied.
+func emitZapRecord(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	if ce == nil || !logger.Enabled() {
+		return
+	}
+	var record log.Record
+	record.SetTimestamp(ce.Time)
+	record.SetBody(log.StringValue(ce.Message))
+	record.SetSeverity(zapSeverity(ce.Level))
+	record.SetSeverityText(ce.Level.String())
+	if ce.LoggerName != "" {
+		record.AddAttributes(log.String("logger.name", ce.LoggerName))
+	}
+	if ce.Caller.Defined {
+		record.AddAttributes(
+			log.String("code.filepath", ce.Caller.File),
+			log.Int("code.lineno", ce.Caller.Line),
+		)
+	}
+	ctx := context.Background()
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		if c, ok := field.Interface.(context.Context); ok {
+			ctx = c
+			continue
+		}
+		field.AddTo(enc)
+	}
+	for k, v := range enc.Fields {
+		record.AddAttributes(logger.KeyValue(k, v))
+	}
+	logger.Emit(ctx, zapScope, record)
+}
+
+func zapSeverity(level zapcore.Level) log.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return log.SeverityDebug
+	case zapcore.InfoLevel:
+		return log.SeverityInfo
+	case zapcore.WarnLevel:
+		return log.SeverityWarn
+	case zapcore.ErrorLevel:
+		return log.SeverityError
+	case zapcore.DPanicLevel:
+		return log.SeverityFatal1
+	case zapcore.PanicLevel:
+		return log.SeverityFatal2
+	case zapcore.FatalLevel:
+		return log.SeverityFatal3
+	default:
+		return log.SeverityUndefined
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/logger"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/log"
+)
+
+const zeroLogScope = "github.com/rs/zerolog"
+
+// emitZeroLogRecord copies the event to OpenTelemetry. Fields of the event are
+// only available in encoded form, which is exposed by otel_event_accessor.go,
+// they are decoded as JSON and dropped if the binary encoding is in use. It
+// must be called before trace_id and span_id are added to the event.
+func emitZeroLogRecord(e *zerolog.Event, msg string) {
+	if e == nil || !logger.Enabled() {
+		return
+	}
+	level := e.OtelLevel()
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetBody(log.StringValue(msg))
+	record.SetSeverity(zeroLogSeverity(level))
+	record.SetSeverityText(level.String())
+	fields := map[string]interface{}{}
+	buf := append(bytes.Clone(e.OtelFields()), '}')
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	if decoder.Decode(&fields) == nil {
+		for k, v := range fields {
+			if k == zerolog.LevelFieldName {
+				continue
+			}
+			record.AddAttributes(log.KeyValue{Key: k, Value: zeroLogValue(v)})
+		}
+	}
+	logger.Emit(context.Background(), zeroLogScope, record)
+}
+
+// zeroLogValue converts decoded JSON value, where numbers are kept as integers
+// whenever possible
+func zeroLogValue(v interface{}) log.Value {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return log.Int64Value(i)
+		}
+		f, _ := val.Float64()
+		return log.Float64Value(f)
+	case []interface{}:
+		values := make([]log.Value, 0, len(val))
+		for _, e := range val {
+			values = append(values, zeroLogValue(e))
+		}
+		return log.SliceValue(values...)
+	case map[string]interface{}:
+		kvs := make([]log.KeyValue, 0, len(val))
+		for k, e := range val {
+			kvs = append(kvs, log.KeyValue{Key: k, Value: zeroLogValue(e)})
+		}
+		return log.MapValue(kvs...)
+	default:
+		return logger.Value(val)
+	}
+}
+
+// zeroLogSeverity maps levels by name, as levels are not the same across
+// supported versions, e.g. TraceLevel is absent from early ones
+func zeroLogSeverity(level zerolog.Level) log.Severity {
+	switch level.String() {
+	case "trace":
+		return log.SeverityTrace
+	case "debug":
+		return log.SeverityDebug
+	case "info":
+		return log.SeverityInfo
+	case "warn":
+		return log.SeverityWarn
+	case "error":
+		return log.SeverityError
+	case "fatal":
+		return log.SeverityFatal
+	case "panic":
+		return log.SeverityFatal2
+	default:
+		return log.SeverityUndefined
+	}
+}
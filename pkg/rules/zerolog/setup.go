@@ -15,29 +15,22 @@
 package zerolog
 
 import (
-	"os"
 	_ "unsafe"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/instrumenter"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-type zeroLogInnerEnabler struct {
-	enabled bool
-}
-
-func (z zeroLogInnerEnabler) Enable() bool {
-	return z.enabled
-}
-
-var zeroLogEnabler = zeroLogInnerEnabler{os.Getenv("OTEL_INSTRUMENTATION_ZEROLOG_ENABLED") != "false"}
+var zeroLogEnabler = instrumenter.RegisterInstrumentEnabler("zerolog", "OTEL_INSTRUMENTATION_ZEROLOG_ENABLED")
 
 //go:linkname zeroLogWriteOnEnter github.com/rs/zerolog.zeroLogWriteOnEnter
 func zeroLogWriteOnEnter(call api.CallContext, ce *zerolog.Event, msg string) {
 	if !zeroLogEnabler.Enable() {
 		return
 	}
+	emitZeroLogRecord(ce, msg)
 	traceId, spanId := trace.GetTraceAndSpanId()
 	if traceId != "" && spanId != "" {
 		cer := ce.Str("trace_id", traceId).Str("span_id", spanId)
@@ -46,15 +46,41 @@ func ResetTestSpans() {
 }
 
 func GetTestMetrics() (interface{}, error) {
-	var tmp, result metricdata.ResourceMetrics
-	err := ManualReader.Collect(context.Background(), &tmp)
+	result, err := Metrics()
 	if err != nil {
 		return metricdata.ResourceMetrics{}, err
 	}
-	result = DeepCopyMetric(tmp)
 	return result, nil
 }
 
+// Spans returns spans ended so far, it's meant to be called from tests built by
+// "otel go test", where spans are exported to memory rather than to backends
+func Spans() tracetest.SpanStubs {
+	return spanExporter.GetSpans()
+}
+
+// SpansNamed returns ended spans with the given name
+func SpansNamed(name string) tracetest.SpanStubs {
+	named := make(tracetest.SpanStubs, 0)
+	for _, span := range spanExporter.GetSpans() {
+		if span.Name == name {
+			named = append(named, span)
+		}
+	}
+	return named
+}
+
+// Metrics collects metrics recorded so far, it's meant to be called from tests
+// built by "otel go test"
+func Metrics() (metricdata.ResourceMetrics, error) {
+	var tmp metricdata.ResourceMetrics
+	err := ManualReader.Collect(context.Background(), &tmp)
+	if err != nil {
+		return metricdata.ResourceMetrics{}, err
+	}
+	return DeepCopyMetric(tmp), nil
+}
+
 func DeepCopyMetric(mrs metricdata.ResourceMetrics) metricdata.ResourceMetrics {
 	// do a deep copy in before each metric verifier executed
 	mrsCpy := deepcopy.Copy(mrs).(metricdata.ResourceMetrics)
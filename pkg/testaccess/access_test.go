@@ -110,6 +110,23 @@ func TestGetTestSpans(t *testing.T) {
 	}
 }
 
+func TestSpans(t *testing.T) {
+	ResetTestSpans()
+	err := GetSpanExporter().ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+		testSpan{name: "foo"},
+		testSpan{name: "bar"},
+		testSpan{name: "foo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, Spans(), 3)
+	assert.Len(t, SpansNamed("foo"), 2)
+	assert.Empty(t, SpansNamed("baz"))
+	ResetTestSpans()
+	assert.Empty(t, Spans())
+}
+
 func TestIsInTest(t *testing.T) {
 	t.Setenv(IS_IN_TEST, "true")
 	result := IsInTest()
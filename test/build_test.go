@@ -15,7 +15,13 @@
 package test
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
 	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
 )
 
 func TestBuildProject(t *testing.T) {
@@ -108,3 +114,111 @@ func TestDisableAllRules(t *testing.T) {
 	ExpectDebugLogNotContains(t, "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/rules/gorm")
 	ExpectDebugLogNotContains(t, "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/rules/redis")
 }
+
+func TestBuildCache(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	cache := []string{"OTELTOOL_CACHE_DIR=" + t.TempDir()}
+	RunSet(t, "-rule=")
+	RunGoBuildWithEnv(t, cache, "go", "build")
+	ExpectDebugLogContains(t, `Apply bundle {"PackageName":"runtime"`)
+	// Nothing changed, all instrumented packages are reused
+	RunGoBuildWithEnv(t, cache, "go", "build")
+	ExpectDebugLogNotContains(t, "Apply bundle")
+	stdout, _ := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stdout, "helloworld")
+	// Rule set changed, packages whose rules changed are rebuilt, while the
+	// others are reused
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuildWithEnv(t, cache, "go", "build")
+	ExpectDebugLogContains(t, "Rule set hash of fmt")
+	ExpectDebugLogNotContains(t, `Apply bundle {"PackageName":"runtime"`)
+	stdout, _ = RunApp(t, HelloworldAppName)
+	ExpectContains(t, stdout, "olleH")
+}
+
+func TestBuildUntouched(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	files := []string{"go.mod", "go.sum", "app2.go"}
+	before := map[string]string{}
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		before[file] = string(bs)
+	}
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuild(t, "go", "build")
+	ExpectDebugLogContains(t, "-modfile=")
+	ExpectDebugLogContains(t, "-overlay=")
+	stdout, _ := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stdout, "olleH")
+	// Module files are copied rather than modified, and the importer is
+	// added via overlay, the working tree is never touched
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		ExpectSame(t, before[file], string(bs))
+	}
+	if _, err := os.Stat("otel_importer.go"); err == nil {
+		t.Fatalf("unexpected otel_importer.go in the working tree")
+	}
+}
+
+func TestBuildWorkspaceUntouched(t *testing.T) {
+	const AppName = "build"
+	UseApp(AppName)
+
+	files := []string{"go.work", "go.work.sum", "go.mod", "go.sum",
+		filepath.Join("mod1", "go.mod")}
+	before := map[string]string{}
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		before[file] = string(bs)
+	}
+	RunSet(t, "-disable=all", UseTestRules("test_fmt.json"))
+	RunGoBuild(t, "go", "build", "m1")
+	// The go command does not accept -modfile in workspace mode, a private
+	// go.work is used instead
+	ExpectDebugLogContains(t, "Workspace mode, use private")
+	ExpectDebugLogNotContains(t, "-modfile=")
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		ExpectSame(t, before[file], string(bs))
+	}
+}
+
+func TestBuildConcurrently(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	RunSet(t, UseTestRules("test_fmt.json"))
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	apps := []string{"concurrent-a", "concurrent-b"}
+	outputs := make([]string, len(apps))
+	errs := make([]error, len(apps))
+	var wg sync.WaitGroup
+	// Builds from the same directory work in their own build directories
+	for i, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(path, "go", "build", "-o", app)
+			cmd.Env = append(os.Environ(), "OTELTOOL_CACHE_DIR=off")
+			out, err := cmd.CombinedOutput()
+			outputs[i], errs[i] = string(out), err
+		}()
+	}
+	wg.Wait()
+	for i, app := range apps {
+		if errs[i] != nil {
+			t.Fatalf("failed to build %s: %v\n%s", app, errs[i], outputs[i])
+		}
+		stdout, _ := RunApp(t, app)
+		ExpectContains(t, stdout, "olleH")
+	}
+	// Files of the latest build are kept for inspection, and build
+	// directories are cleaned up
+	ExpectDebugLogContains(t, "-modfile=")
+	dirs, _ := filepath.Glob(filepath.Join(util.TempBuildDir, "build-*"))
+	if len(dirs) != 0 {
+		t.Fatalf("unexpected build directories left: %v", dirs)
+	}
+}
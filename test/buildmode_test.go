@@ -0,0 +1,151 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func expectELF(t *testing.T, path string, typ elf.Type, machine elf.Machine) {
+	f, err := elf.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if f.Type != typ || f.Machine != machine {
+		t.Fatalf("expect %v %v, got %v %v", typ, machine, f.Type, f.Machine)
+	}
+}
+
+func requireCgo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only tested on linux")
+	}
+	out, err := exec.Command("go", "env", "CGO_ENABLED").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "1" {
+		t.Skip("cgo is not enabled")
+	}
+}
+
+func TestBuildCrossCompile(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only tested on linux")
+	}
+	UseApp(HelloworldAppName)
+
+	goarch, machine := "arm64", elf.EM_AARCH64
+	if runtime.GOARCH == "arm64" {
+		goarch, machine = "amd64", elf.EM_X86_64
+	}
+	RunSet(t, "-rule=")
+	app := filepath.Join(t.TempDir(), HelloworldAppName)
+	RunGoBuildWithEnv(t, []string{"GOOS=linux", "GOARCH=" + goarch},
+		"go", "build", "-o", app)
+	expectELF(t, app, elf.ET_EXEC, machine)
+	// Raw rules of the runtime depend on its internals, which are shared by
+	// all architectures
+	text := ReadInstrumentLog(t, filepath.Join("runtime", "proc.go"))
+	ExpectContains(t, text, "otel_trace_context")
+	ExpectContains(t, text, "ExitHook()")
+}
+
+func TestBuildPIE(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only tested on linux")
+	}
+	UseApp(HelloworldAppName)
+
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuild(t, "go", "build", "-buildmode=pie")
+	expectELF(t, HelloworldAppName, elf.ET_DYN, machineOf(t))
+	_, stderr := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stderr, "Entering hook1")
+}
+
+func TestBuildTrimpath(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuild(t, "go", "build", "-trimpath")
+	_, stderr := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stderr, "Entering hook1")
+	// Neither the source files nor the local replacements of hook modules
+	// are recorded with paths of this machine
+	content, err := os.ReadFile(HelloworldAppName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectNotContains(t, string(content), wd)
+	info, err := buildinfo.ReadFile(HelloworldAppName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil && filepath.IsAbs(dep.Replace.Path) {
+			t.Fatalf("absolute replacement %s => %s", dep.Path,
+				dep.Replace.Path)
+		}
+	}
+}
+
+func TestBuildCShared(t *testing.T) {
+	requireCgo(t)
+	UseApp(HelloworldAppName)
+
+	RunSet(t, "-rule=")
+	lib := filepath.Join(t.TempDir(), "libhelloworld.so")
+	RunGoBuild(t, "go", "build", "-buildmode=c-shared", "-o", lib)
+	expectELF(t, lib, elf.ET_DYN, machineOf(t))
+	text := ReadInstrumentLog(t, filepath.Join("runtime", "proc.go"))
+	ExpectContains(t, text, "otel_trace_context")
+}
+
+func TestBuildPlugin(t *testing.T) {
+	requireCgo(t)
+	UseApp(HelloworldAppName)
+
+	RunSet(t, "-rule=")
+	plugin := filepath.Join(t.TempDir(), "helloworld.so")
+	RunGoBuild(t, "go", "build", "-buildmode=plugin", "-o", plugin)
+	expectELF(t, plugin, elf.ET_DYN, machineOf(t))
+
+	// Plugins named by files are identified by their content on disk, where
+	// the importer does not exist
+	RunGoBuildFallible(t, "go", "build", "-buildmode=plugin", "-o", plugin,
+		"app2.go")
+	ExpectDebugLogContains(t, "requires a package rather than named files")
+}
+
+func machineOf(t *testing.T) elf.Machine {
+	switch runtime.GOARCH {
+	case "amd64":
+		return elf.EM_X86_64
+	case "arm64":
+		return elf.EM_AARCH64
+	}
+	t.Skipf("unsupported %s", runtime.GOARCH)
+	return elf.EM_NONE
+}
@@ -0,0 +1,5 @@
+module callsite
+
+go 1.22.0
+
+replace callsitehook => ./hook
@@ -0,0 +1,3 @@
+module callsitehook
+
+go 1.22
@@ -0,0 +1,33 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+// Hooks of call rules are plain functions, they are pulled by the callers
+
+func getenvOnEnter(call api.CallContext, key string) {
+	println("getenvOnEnter", call.GetPackageName(), call.GetFuncName(), key)
+}
+
+func getenvOnExit(call api.CallContext, val string) {
+	call.SetReturnVal(0, "hooked-"+val)
+}
+
+func builderOnEnter(call api.CallContext) {
+	println("builderOnEnter", call.GetFuncName())
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"callsite/other"
+	"callsite/quiet"
+)
+
+func main() {
+	_ = os.Setenv("CALLSITE_KEY", "value")
+	fmt.Println("main", os.Getenv("CALLSITE_KEY"))
+	fmt.Println("other", other.Getenv())
+	quiet.Setenv()
+	// Pointer method called on addressable value
+	var b strings.Builder
+	b.WriteString("foo")
+	_ = b.WriteByte('-')
+	p := &b
+	p.WriteString("bar")
+	fmt.Println("builder", b.String())
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+func TestCallRule(t *testing.T) {
+	const AppName = "callsite"
+	UseApp(AppName)
+	RunSet(t, "-rule=rule.json")
+	RunGoBuild(t, "go", "build")
+	stdout, stderr := RunApp(t, AppName)
+	// Call sites in main package are wrapped, the hooks see the callee
+	ExpectContains(t, stderr, "getenvOnEnter os Getenv CALLSITE_KEY")
+	ExpectContains(t, stdout, "main hooked-value")
+	// Callers outside of the rule are left untouched
+	ExpectContains(t, stdout, "other value")
+	// Callers that import the package without calling the function are not
+	// matched
+	ExpectDebugLogNotContains(t, "with callsite/quiet")
+	// Pointer methods are matched by pattern, including the ones called on
+	// addressable values
+	ExpectContains(t, stderr, "builderOnEnter WriteString")
+	ExpectContains(t, stderr, "builderOnEnter WriteByte")
+	ExpectContains(t, stdout, "builder foo-bar")
+	ExpectDebugLogContains(t, "Wrap 4 call sites")
+}
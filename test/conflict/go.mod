@@ -0,0 +1,3 @@
+module conflict
+
+go 1.22.0
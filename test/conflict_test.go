@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+const ConflictAppName = "conflict"
+
+func TestRuleConflictWarn(t *testing.T) {
+	UseApp(ConflictAppName)
+
+	RunSet(t, "-rule=func.json")
+	RunGoBuild(t, "go", "build")
+	ExpectStderrContains(t, "warning: rule conflict: runtime: func newproc1 "+
+		"is hooked by multiple rule files: base.json (Order 0)")
+	ExpectStderrContains(t, "func.json (Order 1)")
+	stdout, _ := RunApp(t, ConflictAppName)
+	ExpectContains(t, stdout, "Hello, World!")
+	RunSet(t, "-rule=")
+}
+
+func TestRuleConflictError(t *testing.T) {
+	UseApp(ConflictAppName)
+
+	RunSet(t, "-rule=func.json", "-conflict=error")
+	RunGoBuildFallible(t, "go", "build")
+	ExpectDebugLogContains(t, "Rule conflict: runtime: func newproc1")
+	RunSet(t, "-rule=", "-conflict=")
+}
+
+func TestRulesExplainConflict(t *testing.T) {
+	UseApp(ConflictAppName)
+
+	RunSet(t, "-rule=struct.json,file.json")
+	RunRules(t, "explain")
+	ExpectStdoutContains(t, "2 conflicts")
+	ExpectStdoutContains(t, "runtime: struct g.otel_trace_context is added by "+
+		"multiple rules")
+	ExpectStdoutContains(t, "struct.json")
+	ExpectStdoutContains(t, "runtime: file extern.go is replaced by multiple rules")
+	RunSet(t, "-rule=")
+}
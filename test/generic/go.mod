@@ -0,0 +1,5 @@
+module generic
+
+go 1.22.0
+
+replace generichook => ./hook
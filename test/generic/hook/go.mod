@@ -0,0 +1,3 @@
+module generichook
+
+go 1.22
@@ -0,0 +1,54 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"fmt"
+	_ "unsafe"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+//go:linkname sumOnEnter main.sumOnEnter
+func sumOnEnter(call api.CallContext, xs interface{}) {
+	println("sumOnEnter", fmt.Sprint(xs))
+}
+
+//go:linkname sumOnExit main.sumOnExit
+func sumOnExit(call api.CallContext, sum any) {
+	println("sumOnExit", fmt.Sprint(sum), fmt.Sprint(call.GetReturnVal(0)))
+}
+
+//go:linkname getOnEnter main.getOnEnter
+func getOnEnter(call api.CallContext, c interface{}, k interface{}) {
+	println("getOnEnter", fmt.Sprintf("%T", c), fmt.Sprint(k))
+	// Redirect to another key
+	call.SetParam(1, "b")
+}
+
+//go:linkname getOnExit main.getOnExit
+func getOnExit(call api.CallContext, v interface{}, ok bool) {
+	println("getOnExit", fmt.Sprint(v), ok)
+}
+
+//go:linkname mapOnEnter main.mapOnEnter
+func mapOnEnter(call api.CallContext, s interface{}, f interface{}) {
+	println("mapOnEnter", fmt.Sprint(s))
+}
+
+//go:linkname cacheOnEnter main.cacheOnEnter
+func cacheOnEnter(call api.CallContext) {
+	println("cacheOnEnter", call.GetFuncName(), fmt.Sprint(call.GetParam(1)))
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Number interface {
+	~int | ~float64
+}
+
+func Sum[T Number](xs ...T) T {
+	var sum T
+	for _, x := range xs {
+		sum += x
+	}
+	return sum
+}
+
+// Constraint refers to another type parameter
+func Map[S ~[]E, E any, R any](s S, f func(E) R) []R {
+	r := make([]R, 0, len(s))
+	for _, e := range s {
+		r = append(r, f(e))
+	}
+	return r
+}
+
+type Cache[K comparable, V any] struct {
+	m map[K]V
+}
+
+func (c *Cache[K, V]) Put(k K, v V) {
+	c.m[k] = v
+}
+
+// Type parameters of receiver are named differently from the declaration
+func (c *Cache[Key, Val]) Get(k Key) (Val, bool) {
+	v, ok := c.m[k]
+	return v, ok
+}
+
+func (c Cache[_, V]) Len() int {
+	return len(c.m)
+}
+
+func main() {
+	fmt.Println("sum", Sum(1, 2, 3))
+	fmt.Println("sumf", Sum(1.5, 2.5))
+	fmt.Println("map", Map([]int{1, 2}, strconv.Itoa))
+	c := &Cache[string, int]{m: make(map[string]int)}
+	c.Put("a", 1)
+	c.Put("b", 2)
+	v, ok := c.Get("a")
+	fmt.Println("get", v, ok)
+	fmt.Println("len", c.Len())
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+func TestGenericRule(t *testing.T) {
+	const AppName = "generic"
+	UseApp(AppName)
+	RunSet(t, "-rule=rule.json")
+	RunGoBuild(t, "go", "build")
+	stdout, stderr := RunApp(t, AppName)
+	// Generic functions, values of type parameters are boxed
+	ExpectContains(t, stderr, "sumOnEnter [1 2 3]")
+	ExpectContains(t, stderr, "sumOnExit 6 6")
+	ExpectContains(t, stderr, "sumOnEnter [1.5 2.5]")
+	ExpectContains(t, stderr, "mapOnEnter [1 2]")
+	ExpectContains(t, stdout, "map [1 2]")
+	// Methods of generic types, parameters can be rewritten
+	ExpectContains(t, stderr, "getOnEnter *main.Cache[string,int] a")
+	ExpectContains(t, stderr, "getOnExit 2 true")
+	ExpectContains(t, stdout, "get 2 true")
+	// Pattern rules match methods of generic types as well
+	ExpectContains(t, stderr, "cacheOnEnter Put a")
+	ExpectContains(t, stderr, "cacheOnEnter Len <nil>")
+	ExpectContains(t, stdout, "len 2")
+}
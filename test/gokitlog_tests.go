@@ -23,6 +23,7 @@ import (
 func init() {
 	TestCases = append(TestCases,
 		NewGeneralTestCase("gokitlog-test", "gokitlog", "v0.1.0", "v0.2.1", "1.18", "1.24", TestGoKitLog),
+		NewGeneralTestCase("gokitlog-test-log-bridge", "gokitlog", "v0.1.0", "v0.2.1", "1.18", "1.24", TestGoKitLogBridge),
 	)
 }
 
@@ -41,3 +42,28 @@ func TestGoKitLog(t *testing.T, env ...string) {
 		ExpectContains(t, line, "span_id")
 	}
 }
+
+func TestGoKitLogBridge(t *testing.T, env ...string) {
+	UseApp("gokitlog")
+	RunGoBuild(t, "go", "build", "test_gokitlog_bridge.go")
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_gokitlog_bridge", env...)
+	records := FindLogRecords(t, stdout, "go-kit bridge")
+	if len(records) != 1 {
+		t.Fatalf("expect 1 log record, got %d: %s", len(records), stdout)
+	}
+	ExpectContains(t, records[0].Scope.Name, "github.com/go-kit/log")
+	ExpectContains(t, records[0].SeverityText, "info")
+	if records[0].Attribute("user") != "alice" {
+		t.Fatalf("expect user attribute, got %v", records[0].Attributes)
+	}
+	// The context value decides the span, and is not copied as an attribute
+	contexts := FindLogRecords(t, stdout, "go-kit context")
+	if len(contexts) != 1 {
+		t.Fatalf("expect 1 log record with context, got %d: %s", len(contexts), stdout)
+	}
+	ExpectSame(t, records[0].SpanID, contexts[0].SpanID)
+	if contexts[0].Attribute("ctx") != nil {
+		t.Fatalf("expect no ctx attribute, got %v", contexts[0].Attributes)
+	}
+}
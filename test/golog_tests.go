@@ -23,6 +23,7 @@ import (
 func init() {
 	TestCases = append(TestCases,
 		NewGeneralTestCase("golog-test", "golog", "", "", "1.18", "", TestGoLog),
+		NewGeneralTestCase("golog-test-log-bridge", "golog", "", "", "1.21", "", TestGoLogBridge),
 	)
 }
 
@@ -41,3 +42,30 @@ func TestGoLog(t *testing.T, env ...string) {
 		ExpectContains(t, line, "span_id")
 	}
 }
+
+func TestGoLogBridge(t *testing.T, env ...string) {
+	UseApp("golog")
+	RunGoBuild(t, "go", "build", "test_glog_bridge.go")
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_glog_bridge", env...)
+	records := FindLogRecords(t, stdout, "go log")
+	if len(records) != 1 {
+		t.Fatalf("expect 1 log record of log, got %d: %s", len(records), stdout)
+	}
+	ExpectSame(t, "log", records[0].Scope.Name)
+	records = FindLogRecords(t, stdout, "slog logger")
+	if len(records) != 1 {
+		t.Fatalf("expect 1 log record of slog, got %d: %s", len(records), stdout)
+	}
+	ExpectSame(t, "log/slog", records[0].Scope.Name)
+	ExpectContains(t, records[0].SeverityText, "INFO")
+	if records[0].Attribute("user") != "alice" {
+		t.Fatalf("expect user attribute, got %v", records[0].Attributes)
+	}
+	// The record belongs to the span of the context passed to slog
+	contexts := FindLogRecords(t, stdout, "slog context")
+	if len(contexts) != 1 {
+		t.Fatalf("expect 1 log record with context, got %d: %s", len(contexts), stdout)
+	}
+	ExpectSame(t, records[0].SpanID, contexts[0].SpanID)
+}
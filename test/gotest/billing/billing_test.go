@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/testaccess"
+)
+
+func TestCheckout(t *testing.T) {
+	testaccess.ResetTestSpans()
+	err := Checkout(context.Background(), "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := testaccess.SpansNamed("billing.Checkout")
+	if len(spans) != 1 {
+		t.Fatalf("expect 1 span, got %d", len(spans))
+	}
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "order.id" && attr.Value.AsString() == "42" {
+			return
+		}
+	}
+	t.Fatalf("expect order.id attribute, got %v", spans[0].Attributes)
+}
+
+func TestCheckoutError(t *testing.T) {
+	testaccess.ResetTestSpans()
+	err := Checkout(context.Background(), "")
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	spans := testaccess.SpansNamed("billing.Checkout")
+	if len(spans) != 1 || spans[0].Status.Description != "empty order id" {
+		t.Fatalf("expect error span, got %v", spans)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	if os.Getenv("GOTEST_FAIL") != "" {
+		t.Fatal("failed on purpose")
+	}
+}
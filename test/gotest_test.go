@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// With the build cache off, every otel go test rebuilds the standard library
+// under toolexec from scratch, which takes minutes, so the steps share a build
+// cache and each of them is bounded on its own
+const goTestStepTimeout = 6 * time.Minute
+
+// runGoTestStep runs otel go test with the given build cache, and kills it if
+// it does not finish in time
+func runGoTestStep(t *testing.T, cacheDir string, args ...string) error {
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path, "go", "test", "-count=1"}, args...))
+	cmd.Env = append(cmd.Env, "OTELTOOL_CACHE_DIR="+cacheDir)
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := time.AfterFunc(goTestStepTimeout, func() {
+		_ = cmd.Process.Kill()
+	})
+	err = cmd.Wait()
+	if !timer.Stop() {
+		t.Fatalf("%v did not finish in %v\n%s", args, goTestStepTimeout,
+			readStdoutLog(t))
+	}
+	return err
+}
+
+func TestGoTest(t *testing.T) {
+	const AppName = "gotest"
+	UseApp(AppName)
+	RunSet(t, "-rule=rule.json")
+	cacheDir := t.TempDir()
+
+	t.Run("Verbose", func(t *testing.T) {
+		err := runGoTestStep(t, cacheDir, "-v", "./...")
+		if err != nil {
+			t.Fatal(err, readStderrLog(t), ReadLog(t))
+		}
+		ExpectStdoutContains(t, "--- PASS: TestCheckout")
+		ExpectStdoutContains(t, "--- PASS: TestCheckoutError")
+		ExpectStdoutContains(t, "ok  \tgotest/billing")
+	})
+
+	t.Run("Cover", func(t *testing.T) {
+		err := runGoTestStep(t, cacheDir, "-cover", "-run", "TestCheckout",
+			"./billing")
+		if err != nil {
+			t.Fatal(err, readStderrLog(t), ReadLog(t))
+		}
+		ExpectStdoutContains(t, "coverage:")
+		ExpectDebugLogContains(t, "billing.cover.go")
+	})
+
+	t.Run("Race", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("rebuilds the standard library with -race")
+		}
+		err := runGoTestStep(t, cacheDir, "-race", "./...")
+		if err != nil {
+			t.Fatal(err, readStderrLog(t), ReadLog(t))
+		}
+		ExpectStdoutContains(t, "ok  \tgotest/billing")
+	})
+
+	// Test failures are reported as they are
+	t.Run("Failure", func(t *testing.T) {
+		t.Setenv("GOTEST_FAIL", "1")
+		err := runGoTestStep(t, cacheDir, "./...")
+		if err == nil {
+			t.Fatal("expected failure")
+		}
+		ExpectStdoutContains(t, "--- FAIL: TestFailure")
+		ExpectStdoutContains(t, "FAIL\tgotest/billing")
+		ExpectNotContains(t, readStderrLog(t), "Fatal Error")
+	})
+}
@@ -0,0 +1,5 @@
+module hooksig
+
+go 1.22.0
+
+replace hooksighook => ./hook
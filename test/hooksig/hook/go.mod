@@ -0,0 +1,3 @@
+module hooksighook
+
+go 1.22
@@ -0,0 +1,72 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"context"
+	_ "unsafe"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+//go:linkname greetOnEnter main.greetOnEnter
+func greetOnEnter(call api.CallContext, ctx context.Context, name string,
+	tags ...string) {
+	println("greetOnEnter", name, len(tags))
+}
+
+//go:linkname greetOnExit main.greetOnExit
+func greetOnExit(call api.CallContext, greeting string, err error) {
+	println("greetOnExit", greeting)
+}
+
+//go:linkname sumOnEnter main.sumOnEnter
+func sumOnEnter(call api.CallContext, a, b int) {
+	println("sumOnEnter", a, b)
+}
+
+//go:linkname sumOnExit main.sumOnExit
+func sumOnExit(call api.CallContext, ret any) {
+	println("sumOnExit", ret.(int))
+}
+
+//go:linkname helloOnEnter main.helloOnEnter
+func helloOnEnter(call api.CallContext, s interface{}, n int) {
+	println("helloOnEnter", n)
+}
+
+//go:linkname greetMissingParam main.greetMissingParam
+func greetMissingParam(call api.CallContext, ctx context.Context, name string) {}
+
+//go:linkname greetWrongType main.greetWrongType
+func greetWrongType(call api.CallContext, ctx context.Context, name []byte,
+	tags ...string) {
+}
+
+//go:linkname greetSliceTags main.greetSliceTags
+func greetSliceTags(call api.CallContext, ctx context.Context, name string,
+	tags []string) {
+}
+
+//go:linkname greetWrongExit main.greetWrongExit
+func greetWrongExit(call api.CallContext, greeting string, err string) {}
+
+//go:linkname sumNoContext main.sumNoContext
+func sumNoContext(a, b int) {}
+
+//go:linkname sumReturns main.sumReturns
+func sumReturns(call api.CallContext, a, b int) bool {
+	return true
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Server struct {
+	prefix string
+}
+
+func (s *Server) Hello(n int) string {
+	return strings.Repeat(s.prefix, n)
+}
+
+func Greet(ctx context.Context, name string, tags ...string) (string, error) {
+	return "Hello, " + name + strings.Join(tags, ""), ctx.Err()
+}
+
+func Sum(a, b int) int {
+	return a + b
+}
+
+func main() {
+	greeting, _ := Greet(context.Background(), "World", "!")
+	fmt.Println(greeting)
+	fmt.Println(Sum(1, 2))
+	fmt.Println((&Server{prefix: "ha"}).Hello(2))
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+const HookSigAppName = "hooksig"
+
+func TestHookSignature(t *testing.T) {
+	UseApp(HookSigAppName)
+
+	RunSet(t, "-rule=good.json")
+	RunGoBuild(t, "go", "build")
+	stdout, stderr := RunApp(t, HookSigAppName)
+	ExpectContains(t, stdout, "Hello, World!")
+	ExpectContains(t, stderr, "greetOnEnter World 1")
+	ExpectContains(t, stderr, "greetOnExit Hello, World!")
+	ExpectContains(t, stderr, "sumOnEnter 1 2")
+	ExpectContains(t, stderr, "sumOnExit 3")
+	ExpectContains(t, stderr, "helloOnEnter 2")
+	RunSet(t, "-rule=")
+}
+
+func TestHookSignatureMismatch(t *testing.T) {
+	UseApp(HookSigAppName)
+
+	cases := []struct {
+		ruleFile string
+		hook     string
+		expect   string
+	}{
+		{"count.json", "greetMissingParam", "func Greet: hook has 3 " +
+			"parameters, expect 4, i.e. api.CallContext followed by " +
+			"(context.Context, string, ...string)"},
+		{"type.json", "greetWrongType", "func Greet: parameter #2 name has " +
+			"type []byte, expect string or interface{}"},
+		{"variadic.json", "greetSliceTags", "func Greet: parameter #3 tags " +
+			"should be variadic, i.e. ...string"},
+		{"exit.json", "greetWrongExit", "func Greet: parameter #2 err has " +
+			"type string, expect error or interface{}"},
+		{"context.json", "sumNoContext", "func Sum: hook should accept " +
+			"api.CallContext as the first parameter"},
+		{"returns.json", "sumReturns", "func Sum: hook should not return " +
+			"anything"},
+	}
+	for _, c := range cases {
+		RunSet(t, "-rule="+c.ruleFile)
+		RunGoBuildFallible(t, "go", "build")
+		ExpectDebugLogContains(t, "hook "+c.hook+" of rule ")
+		ExpectDebugLogContains(t, c.ruleFile+" does not match "+c.expect)
+	}
+	RunSet(t, "-rule=")
+}
@@ -15,6 +15,8 @@
 package test
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -41,6 +43,9 @@ func runCmd(args []string) *exec.Cmd {
 	args = args[1:]
 	cmd := exec.Command(path, args...)
 	cmd.Env = os.Environ()
+	// Tests inspect logs of the instrument phase, which is skipped for cached
+	// packages, so the persistent build cache is off unless asked explicitly
+	cmd.Env = append(cmd.Env, "OTELTOOL_CACHE_DIR=off")
 	stdoutFile := filepath.Join("stdout.log")
 	stdout, _ := os.Create(stdoutFile)
 	stderrFile := filepath.Join("stderr.log")
@@ -70,6 +75,15 @@ func ReadPreprocessLog(t *testing.T, fileName string) string {
 	return content
 }
 
+func ReadBuildReport(t *testing.T, fileName string) string {
+	path := filepath.Join(util.TempBuildDir, fileName)
+	content, err := util.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
 func ReadLog(t *testing.T) string {
 	path := filepath.Join(util.TempBuildDir, util.DebugLogFile)
 	content, err := util.ReadFile(path)
@@ -107,6 +121,26 @@ func RunSet(t *testing.T, args ...string) {
 	}
 }
 
+func RunRules(t *testing.T, args ...string) {
+	util.Assert(pwd != "", "pwd is empty")
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path, "rules"}, args...))
+	err := cmd.Run()
+	if err != nil {
+		t.Fatal(err, readStderrLog(t))
+	}
+}
+
+func RunRulesFallible(t *testing.T, args ...string) {
+	util.Assert(pwd != "", "pwd is empty")
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path, "rules"}, args...))
+	err := cmd.Run()
+	if err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
 func RunGoBuild(t *testing.T, args ...string) {
 	util.Assert(pwd != "", "pwd is empty")
 	path := filepath.Join(filepath.Dir(pwd), getExecName())
@@ -144,6 +178,38 @@ func RunGoBuildWithEnv(t *testing.T, envs []string, args ...string) {
 	}
 }
 
+func RunGoBuildWithEnvFallible(t *testing.T, envs []string, args ...string) {
+	util.Assert(pwd != "", "pwd is empty")
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path}, args...))
+	cmd.Env = append(cmd.Env, envs...)
+	err := cmd.Run()
+	if err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func RunVendor(t *testing.T, args ...string) {
+	util.Assert(pwd != "", "pwd is empty")
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path, "vendor"}, args...))
+	err := cmd.Run()
+	if err != nil {
+		t.Fatal(err, readStderrLog(t), ReadLog(t))
+	}
+}
+
+func RunToolexec(t *testing.T, args ...string) string {
+	util.Assert(pwd != "", "pwd is empty")
+	path := filepath.Join(filepath.Dir(pwd), getExecName())
+	cmd := runCmd(append([]string{path, "toolexec"}, args...))
+	err := cmd.Run()
+	if err != nil {
+		t.Fatal(err, readStderrLog(t), ReadLog(t))
+	}
+	return readStdoutLog(t)
+}
+
 func RunGoBuildFallible(t *testing.T, args ...string) {
 	util.Assert(pwd != "", "pwd is empty")
 	path := filepath.Join(filepath.Dir(pwd), getExecName())
@@ -242,6 +308,54 @@ func ExpectNotContains(t *testing.T, text, expect string) {
 	}
 }
 
+// LogRecord is a log record printed by the console log exporter, i.e. when
+// OTEL_LOGS_EXPORTER=console
+type LogRecord struct {
+	SeverityText string
+	Body         struct{ Value interface{} }
+	Attributes   []struct {
+		Key   string
+		Value struct{ Value interface{} }
+	}
+	TraceID string
+	SpanID  string
+	Scope   struct{ Name string }
+}
+
+// Attribute returns the value of the attribute, or nil if it's absent
+func (r *LogRecord) Attribute(key string) interface{} {
+	for _, attr := range r.Attributes {
+		if attr.Key == key {
+			return attr.Value.Value
+		}
+	}
+	return nil
+}
+
+// FindLogRecords returns log records in stdout whose body is the given text,
+// each of them must be correlated with a span
+func FindLogRecords(t *testing.T, stdout, body string) []*LogRecord {
+	records := make([]*LogRecord, 0)
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		record := &LogRecord{}
+		if json.Unmarshal(scanner.Bytes(), record) != nil {
+			continue
+		}
+		if record.Body.Value != body {
+			continue
+		}
+		if strings.Trim(record.TraceID, "0") == "" ||
+			strings.Trim(record.SpanID, "0") == "" {
+			t.Fatalf("log record is not correlated with span: %s",
+				scanner.Text())
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
 func ExpectSame(t *testing.T, expected, actual string) {
 	if expected != actual {
 		t.Fatalf("expected: %s, actual: %s", expected, actual)
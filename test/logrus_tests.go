@@ -23,6 +23,7 @@ import (
 func init() {
 	TestCases = append(TestCases,
 		NewGeneralTestCase("logrus-test", "logrus", "", "", "1.21", "", TestLogrus),
+		NewGeneralTestCase("logrus-test-log-bridge", "logrus", "", "", "1.21", "", TestLogrusLogBridge),
 	)
 }
 
@@ -41,3 +42,18 @@ func TestLogrus(t *testing.T, env ...string) {
 		ExpectContains(t, line, "span_id")
 	}
 }
+
+func TestLogrusLogBridge(t *testing.T, env ...string) {
+	UseApp("logrus")
+	RunGoBuild(t, "go", "build", "test_logrus.go", "http_server.go")
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_logrus", env...)
+	records := FindLogRecords(t, stdout, "warn info")
+	if len(records) == 0 {
+		t.Fatalf("expect log records, got none: %s", stdout)
+	}
+	for _, record := range records {
+		ExpectContains(t, record.Scope.Name, "github.com/sirupsen/logrus")
+		ExpectContains(t, record.SeverityText, "warning")
+	}
+}
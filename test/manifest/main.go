@@ -0,0 +1,33 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/manifest"
+)
+
+func main() {
+	_ = http.DefaultClient
+	m := manifest.Get()
+	fmt.Printf("instrumented: %v\n", manifest.Instrumented())
+	fmt.Printf("integrations: %s\n", strings.Join(m.Integrations(), ","))
+	if m != nil {
+		fmt.Printf("manifest: %s\n", m.JSON())
+	}
+}
@@ -22,6 +22,7 @@ func init() {
 		NewGeneralTestCase("nethttp-http-2-test", "nethttp", "", "", "1.18", "", TestHttp2),
 		NewGeneralTestCase("nethttp-https-test", "nethttp", "", "", "1.18", "", TestHttps),
 		NewGeneralTestCase("nethttp-metric-test", "nethttp", "", "", "1.18", "", TestHttpMetric),
+		NewGeneralTestCase("nethttp-propagators-test", "nethttp", "", "", "1.18", "", TestHttpPropagators),
 	)
 }
 
@@ -48,3 +49,10 @@ func TestHttpMetric(t *testing.T, env ...string) {
 	RunGoBuild(t, "go", "build", "test_http_metrics.go", "http_server.go")
 	RunApp(t, "test_http_metrics", env...)
 }
+
+func TestHttpPropagators(t *testing.T, env ...string) {
+	UseApp("nethttp")
+	RunGoBuild(t, "go", "build", "test_http_propagators.go", "http_server.go")
+	env = append(env, "OTEL_PROPAGATORS=tracecontext,baggage,b3multi,jaeger")
+	RunApp(t, "test_http_propagators", env...)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/test/verifier"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	jaegerTraceId = "5b8efff798038103d269b633813fc60c"
+	b3TraceId     = "a3ce929d0e0e47364bf92f3577b34da6"
+)
+
+func headersHandler(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	_, _ = w.Write([]byte(strings.Join(names, ",")))
+}
+
+func setupPropagatorsHttp() {
+	http.HandleFunc("/headers", headersHandler)
+	http.HandleFunc("/legacy", helloHandler)
+	var err error
+	port, err = verifier.GetFreePort()
+	if err != nil {
+		panic(err)
+	}
+	err = http.ListenAndServe(":"+strconv.Itoa(port), nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sendRaw sends the request through a plain connection, so that the headers
+// are exactly what a legacy upstream would send
+func sendRaw(headers ...string) {
+	conn, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+	req := "GET /legacy HTTP/1.1\r\nHost: 127.0.0.1\r\nConnection: close\r\n"
+	for _, header := range headers {
+		req += header + "\r\n"
+	}
+	_, err = fmt.Fprint(conn, req+"\r\n")
+	if err != nil {
+		panic(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		panic(err)
+	}
+	_ = resp.Body.Close()
+}
+
+func main() {
+	go setupPropagatorsHttp()
+	time.Sleep(1 * time.Second)
+	// The client injects every configured format
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(port) + "/headers")
+	if err != nil {
+		panic(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range []string{"traceparent", "x-b3-traceid", "uber-trace-id"} {
+		if !strings.Contains(string(body), name) {
+			log.Fatalf("expect %s to be injected, got %s", name, body)
+		}
+	}
+	// The server extracts whichever format the upstream speaks
+	sendRaw("uber-trace-id: " + jaegerTraceId + ":eee19b7ec3c1b174:0:1")
+	sendRaw("X-B3-TraceId: "+b3TraceId, "X-B3-SpanId: 4bf92f3577b34da6",
+		"X-B3-Sampled: 1")
+	verifier.WaitAndAssertTraces(func(stubs []tracetest.SpanStubs) {
+		parents := map[string]bool{}
+		for _, trace := range stubs {
+			for _, span := range trace {
+				if span.Name == "GET /legacy" {
+					parents[span.Parent.TraceID().String()] = true
+				}
+			}
+		}
+		if !parents[jaegerTraceId] || !parents[b3TraceId] {
+			log.Fatalf("expect parents of jaeger and b3 upstreams, got %v", parents)
+		}
+	}, 3)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const offlineEnv = "OTELTOOL_OFFLINE=true"
+
+// newModCache creates a module cache that serves modules of the real one
+// except the given module paths
+func newModCache(t *testing.T, excludes ...string) string {
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := filepath.Join(strings.TrimSpace(string(out)), "cache", "download")
+	modCache := t.TempDir()
+	var link func(rel string)
+	link = func(rel string) {
+		entries, err := os.ReadDir(filepath.Join(origin, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(rel, entry.Name())
+			prefix := false
+			skip := false
+			for _, exclude := range excludes {
+				if exclude == filepath.ToSlash(path) {
+					skip = true
+				} else if strings.HasPrefix(exclude, filepath.ToSlash(path)+"/") {
+					prefix = true
+				}
+			}
+			if skip {
+				continue
+			}
+			target := filepath.Join(modCache, "cache", "download", path)
+			if prefix {
+				link(path)
+				continue
+			}
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.Symlink(filepath.Join(origin, path), target)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	link("")
+	return modCache
+}
+
+func TestBuildOffline(t *testing.T) {
+	UseApp(HelloworldAppName)
+	_ = os.RemoveAll("vendor") // Build with the module cache
+
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuildWithEnv(t, []string{offlineEnv}, "go", "build")
+	ExpectDebugLogContains(t, "GOPROXY=off")
+	stdout, _ := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stdout, "olleH")
+}
+
+func TestBuildOfflineMissingModules(t *testing.T) {
+	UseApp(HelloworldAppName)
+	_ = os.RemoveAll("vendor")
+
+	// zipkin-go is required by the pkg module only, the project itself is
+	// still buildable without it
+	modCache := newModCache(t, "github.com/openzipkin")
+	envs := []string{
+		offlineEnv,
+		"GOMODCACHE=" + modCache,
+		"GOFLAGS=-mod=mod -modcacherw",
+	}
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuildWithEnvFallible(t, envs, "go", "build")
+	ExpectStderrContains(t, "modules are missing in offline mode")
+	ExpectStderrContains(t, "github.com/openzipkin/zipkin-go@v0.4.3")
+}
+
+func TestBuildOfflineMissingSources(t *testing.T) {
+	UseApp(HelloworldAppName)
+	_ = os.RemoveAll("vendor")
+
+	// go.mod of zipkin-go is there to load the module graph, while the source
+	// is not
+	modCache := newModCache(t,
+		"github.com/openzipkin/zipkin-go/@v/v0.4.3.zip")
+	envs := []string{
+		offlineEnv,
+		"GOMODCACHE=" + modCache,
+		"GOFLAGS=-mod=mod -modcacherw",
+	}
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunGoBuildWithEnvFallible(t, envs, "go", "build")
+	ExpectStderrContains(t, "modules are missing in offline mode")
+	ExpectStderrContains(t, "github.com/openzipkin/zipkin-go@v0.4.3")
+}
+
+func TestBuildOfflineWithVendor(t *testing.T) {
+	UseApp(HelloworldAppName)
+	t.Cleanup(func() {
+		_ = os.RemoveAll("vendor")
+		_ = os.RemoveAll(".otel-vendor")
+	})
+
+	// The project vendors its own dependencies
+	cmd := exec.Command("go", "mod", "vendor")
+	cmd.Env = append(os.Environ(), "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	files := []string{"go.mod", "go.sum", filepath.Join("vendor", "modules.txt")}
+	before := map[string]string{}
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		before[file] = string(bs)
+	}
+	RunSet(t, UseTestRules("test_fmt.json"))
+	RunVendor(t)
+	ExpectStdoutContains(t, "Vendored dependencies")
+	for _, file := range files {
+		bs, _ := os.ReadFile(file)
+		ExpectSame(t, before[file], string(bs))
+	}
+	sdk := filepath.Join(".otel-vendor", "cache", "download", "go.opentelemetry.io",
+		"otel", "sdk", "@v", "v1.35.0.zip")
+	if _, err = os.Stat(sdk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plain builds still agree with the vendor directory
+	cmd = exec.Command("go", "build", "-o", filepath.Join(t.TempDir(), "app"))
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=vendor")
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+
+	// Nothing but the vendored module cache is available
+	envs := []string{
+		offlineEnv,
+		"GOMODCACHE=" + t.TempDir(),
+		"GOFLAGS=-mod=vendor -modcacherw",
+	}
+	RunGoBuildWithEnv(t, envs, "go", "build")
+	ExpectDebugLogContains(t, "Use vendored module cache")
+	stdout, _ := RunApp(t, HelloworldAppName)
+	ExpectContains(t, stdout, "olleH")
+}
@@ -0,0 +1,5 @@
+module pattern
+
+go 1.22.0
+
+replace patternhook => ./hook
@@ -0,0 +1,3 @@
+module patternhook
+
+go 1.22
@@ -0,0 +1,36 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	_ "unsafe"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+//go:linkname handlerOnEnter main.handlerOnEnter
+func handlerOnEnter(call api.CallContext) {
+	println("handlerOnEnter", call.GetFuncName())
+}
+
+//go:linkname handlerOnExit main.handlerOnExit
+func handlerOnExit(call api.CallContext) {
+	println("handlerOnExit", call.GetFuncName())
+}
+
+//go:linkname serverOnEnter main.serverOnEnter
+func serverOnEnter(call api.CallContext) {
+	println("serverOnEnter", call.GetFuncName())
+}
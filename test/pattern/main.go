@@ -0,0 +1,55 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type Server struct{}
+
+func (s *Server) ServeFoo() { fmt.Println("ServeFoo") }
+
+func (s *Server) ServeBar() { fmt.Println("ServeBar") }
+
+func (s *Server) Stop() { fmt.Println("Stop") }
+
+func HandleOrder(ctx context.Context, id int) error {
+	fmt.Println("HandleOrder", id)
+	return nil
+}
+
+func HandleNoContext(id int) error {
+	fmt.Println("HandleNoContext", id)
+	return nil
+}
+
+func HandleNoError(ctx context.Context) {
+	fmt.Println("HandleNoError")
+}
+
+func main() {
+	ctx := context.Background()
+	_ = HandleOrder(ctx, 1)
+	_ = HandlePayment(ctx)
+	_ = HandleNoContext(2)
+	HandleNoError(ctx)
+	_ = handleInternal(ctx)
+	s := &Server{}
+	s.ServeFoo()
+	s.ServeBar()
+	s.Stop()
+}
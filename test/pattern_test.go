@@ -0,0 +1,42 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+func TestPatternRule(t *testing.T) {
+	const AppName = "pattern"
+	UseApp(AppName)
+	RunSet(t, "-rule=rule.json")
+	RunGoBuild(t, "go", "build")
+	_, stderr := RunApp(t, AppName)
+	// Handlers across files are matched by glob and filters
+	ExpectContains(t, stderr, "handlerOnEnter HandleOrder")
+	ExpectContains(t, stderr, "handlerOnExit HandleOrder")
+	ExpectContains(t, stderr, "handlerOnEnter HandlePayment")
+	ExpectNotContains(t, stderr, "HandleNoContext")
+	ExpectNotContains(t, stderr, "HandleNoError")
+	ExpectNotContains(t, stderr, "handleInternal")
+	// Methods are matched by regexp
+	ExpectContains(t, stderr, "serverOnEnter ServeFoo")
+	ExpectContains(t, stderr, "serverOnEnter ServeBar")
+	ExpectNotContains(t, stderr, "serverOnEnter Stop")
+	// Matched functions are reported in the build log
+	ExpectDebugLogContains(t, "HandleOrder, HandlePayment")
+	text := ReadBuildReport(t, "otel_report.json")
+	ExpectContains(t, text, `"(*Server).ServeBar"`)
+}
@@ -0,0 +1,11 @@
+module reachable
+
+go 1.23.0
+
+replace github.com/alibaba/opentelemetry-go-auto-instrumentation => ../../
+
+replace github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg => ../../pkg
+
+replace github.com/alibaba/opentelemetry-go-auto-instrumentation/test/verifier => ../../test/verifier
+
+require golang.org/x/time v0.11.0
@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+const ReachableAppName = "reachable"
+
+func TestRulesExplainReachable(t *testing.T) {
+	UseApp(ReachableAppName)
+
+	RunSet(t, "-disable=", UseTestRules("test_fmt.json"),
+		"-reachable=reachable/cmd/server")
+	RunRules(t, "explain", "./...")
+	ExpectStdoutContains(t, "[unreachable]  func Every")
+	ExpectStdoutContains(t, "[matched]    func Printf")
+	// Base rules are never filtered out
+	ExpectStdoutContains(t, "[matched]  func newproc1")
+
+	RunSet(t, "-reachable=reachable/cmd/...")
+	RunRules(t, "explain", "./...")
+	ExpectStdoutContains(t, "0 unreachable")
+	RunSet(t, "-reachable=")
+}
+
+func TestBuildReachable(t *testing.T) {
+	UseApp(ReachableAppName)
+
+	RunSet(t, "-disable=", UseTestRules("test_fmt.json"),
+		"-reachable=reachable/cmd/server", "-report=")
+	RunGoBuild(t, "go", "build", "-o", "tool", "./cmd/tool")
+	text := ReadBuildReport(t, "otel_report.json")
+	ExpectContains(t, text, `"ImportPath": "golang.org/x/time/rate"`)
+	ExpectContains(t, text, `"Reason": "unreachable"`)
+	_, stderr := RunApp(t, "tool")
+	ExpectNotContains(t, stderr, "GOOD")
+
+	// Packages reachable from main are still instrumented
+	RunGoBuild(t, "go", "build", "-o", "server", "./cmd/server")
+	stdout, _ := RunApp(t, "server")
+	ExpectContains(t, stdout, "olleH")
+
+	RunSet(t, "-reachable=reachable/cmd/...")
+	RunGoBuild(t, "go", "build", "-o", "tool", "./cmd/tool")
+	text = ReadBuildReport(t, "otel_report.json")
+	ExpectNotContains(t, text, `"Reason": "unreachable"`)
+	_, stderr = RunApp(t, "tool")
+	ExpectContains(t, stderr, "GOOD")
+
+	RunSet(t, "-reachable=no/such/cmd")
+	RunGoBuildFallible(t, "go", "build", "-o", "tool", "./cmd/tool")
+	ExpectDebugLogContains(t, "no main package is found")
+	RunSet(t, "-reachable=")
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+func TestBuildReport(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	RunSet(t, "-disable=", UseTestRules("test_fmt.json"), "-report=")
+	RunGoBuild(t, "go", "build")
+	text := ReadBuildReport(t, "otel_report.json")
+	ExpectContains(t, text, `"ImportPath": "net/http"`)
+	ExpectContains(t, text, `"Function": "RoundTrip"`)
+	ExpectContains(t, text, `"RuleFile": "test_fmt.json"`)
+	ExpectContains(t, text, `"Reason": "`)
+	// Hook paths must not leak local paths
+	ExpectNotContains(t, text, util.TempBuildDir)
+	// Report never pollutes the project
+	if util.PathExists("otel_report.json") {
+		t.Fatal("build report should be written under " + util.TempBuildDir)
+	}
+
+	RunSet(t, "-report=sarif")
+	RunGoBuild(t, "go", "build")
+	text = ReadBuildReport(t, "otel_report.sarif")
+	ExpectContains(t, text, `"version": "2.1.0"`)
+	ExpectContains(t, text, `"level": "note"`)
+
+	// Report is placed where it's explicitly asked to be
+	RunSet(t, "-report="+filepath.Join("bin", "app.sarif"))
+	RunGoBuild(t, "go", "build", "-o", filepath.Join("bin", "app"))
+	text = readLog(t, filepath.Join("bin", "app.sarif"))
+	ExpectContains(t, text, `"version": "2.1.0"`)
+	_ = os.RemoveAll("bin")
+
+	RunSet(t, "-report=none")
+	_ = os.Remove(filepath.Join(util.TempBuildDir, "otel_report.json"))
+	RunGoBuild(t, "go", "build")
+	if util.PathExists(filepath.Join(util.TempBuildDir, "otel_report.json")) {
+		t.Fatal("build report should not be written")
+	}
+	RunSet(t, "-report=")
+}
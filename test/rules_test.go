@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+func TestRulesList(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	RunSet(t, "-disable=", "-rule=")
+	RunRules(t, "list")
+	ExpectStdoutContains(t, "IMPORT PATH")
+	ExpectStdoutContains(t, "func (\\*Transport).RoundTrip")
+	ExpectStdoutContains(t, "nethttp.json")
+
+	RunRules(t, "list", "-json")
+	ExpectStdoutContains(t, `"Kind": "struct"`)
+}
+
+func TestRulesExplain(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	RunSet(t, "-disable=", UseTestRules("test_fmt.json"))
+	RunRules(t, "explain")
+	ExpectStdoutContains(t, "net/http (go")
+	ExpectStdoutContains(t, "[matched]")
+	ExpectStdoutContains(t, "test_fmt.json")
+
+	RunSet(t, "-disable=", "-rule=", "-exclude=net/http")
+	RunRules(t, "explain")
+	ExpectStdoutContains(t, "[filtered]")
+	RunSet(t, "-exclude=")
+}
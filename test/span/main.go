@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/test/verifier"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Store struct {
+	stock map[string]int
+}
+
+func (s *Store) Get(ctx context.Context, key string) (int, error) {
+	return s.stock[key], nil
+}
+
+func Checkout(ctx context.Context, orderId string, amount int) error {
+	store := &Store{stock: map[string]int{"apple": 1}}
+	n, err := store.Get(ctx, "apple")
+	if err != nil {
+		return err
+	}
+	if n < amount {
+		return errors.New("out of stock")
+	}
+	return nil
+}
+
+func main() {
+	_ = Checkout(context.Background(), "order-1", 3)
+	verifier.WaitAndAssertTraces(func(stubs []tracetest.SpanStubs) {
+		checkout, get := stubs[0][0], stubs[0][1]
+		verifier.Assert(checkout.Name == "main.Checkout",
+			"Expect span name main.Checkout, got %s", checkout.Name)
+		verifier.Assert(checkout.SpanKind == trace.SpanKindInternal,
+			"Expect internal span, got %v", checkout.SpanKind)
+		verifier.Assert(checkout.Status.Code == codes.Error,
+			"Expect error status, got %v", checkout.Status.Code)
+		verifier.Assert(checkout.Status.Description == "out of stock",
+			"Expect error out of stock, got %s", checkout.Status.Description)
+		verifier.Assert(len(checkout.Events) == 1 && checkout.Events[0].Name == "exception",
+			"Expect exception event, got %v", checkout.Events)
+		verifier.Assert(verifier.GetAttribute(checkout.Attributes, "order.id").AsString() == "order-1",
+			"Expect order.id attribute")
+		verifier.Assert(verifier.GetAttribute(checkout.Attributes, "order.amount").AsInt64() == 3,
+			"Expect order.amount attribute")
+		verifier.Assert(get.Name == "main.(*Store).Get",
+			"Expect span name main.(*Store).Get, got %s", get.Name)
+		verifier.Assert(get.Parent.SpanID() == checkout.SpanContext.SpanID(),
+			"Expect Get to be the child of Checkout")
+		verifier.Assert(verifier.GetAttribute(get.Attributes, "store.key").AsString() == "apple",
+			"Expect store.key attribute")
+		verifier.Assert(get.Status.Code != codes.Error,
+			"Expect no error status for Get")
+	}, 1)
+}
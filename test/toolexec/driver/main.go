@@ -0,0 +1,128 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// The driver is passed to go build -toolexec, it instruments every compiled
+// package by otel toolexec and feeds the result back to the compiler, just as
+// a build system that compiles packages itself would do
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type toolexecResult struct {
+	Files []string
+}
+
+func fatal(err error, args ...string) {
+	println("driver:", err.Error(), strings.Join(args, " "))
+	os.Exit(1)
+}
+
+// otelToolexec runs otel toolexec with the given arguments and returns files
+// to be compiled
+func otelToolexec(args ...string) []string {
+	args = append([]string{"toolexec"}, args...)
+	cmd := exec.Command(os.Getenv("TOOLEXEC_OTEL"), args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		fatal(err, args...)
+	}
+	result := &toolexecResult{}
+	err = json.Unmarshal(out, result)
+	if err != nil {
+		fatal(err, string(out))
+	}
+	return result.Files
+}
+
+func instrument(args []string) []string {
+	importPath, importCfg := "", ""
+	flags := make([]string, 0, len(args))
+	files := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-p" && i+1 < len(args):
+			importPath = args[i+1]
+		case args[i] == "-importcfg" && i+1 < len(args):
+			importCfg = args[i+1]
+		case args[i] == "-complete":
+			// Instrumented packages declare functions without bodies
+			continue
+		case strings.HasSuffix(args[i], ".go") && !strings.HasPrefix(args[i], "-"):
+			files = append(files, args[i])
+			continue
+		}
+		flags = append(flags, args[i])
+	}
+	if importPath == "" || len(files) == 0 {
+		return args
+	}
+
+	resultDir := os.Getenv("TOOLEXEC_RESULTS")
+	sum := sha256.Sum256([]byte(importPath))
+	name := hex.EncodeToString(sum[:8])
+	outDir := filepath.Join(resultDir, name)
+	if importPath == "main" {
+		// All other packages are compiled before the main package
+		results, err := filepath.Glob(filepath.Join(resultDir, "*.json"))
+		if err != nil {
+			fatal(err)
+		}
+		importer := otelToolexec(append([]string{"-importer", "-o=" + outDir},
+			results...)...)
+		files = append(files, importer...)
+	}
+	cmd := exec.Command(os.Getenv("TOOLEXEC_OTEL"), append([]string{"toolexec",
+		"-importpath=" + importPath, "-importcfg=" + importCfg,
+		"-o=" + outDir, "-rule=" + os.Getenv("TOOLEXEC_RULE"),
+		"-replace=" + os.Getenv("TOOLEXEC_REPLACE")}, files...)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		fatal(err, importPath)
+	}
+	err = os.WriteFile(filepath.Join(resultDir, name+".json"), out, 0644)
+	if err != nil {
+		fatal(err)
+	}
+	result := &toolexecResult{}
+	err = json.Unmarshal(out, result)
+	if err != nil {
+		fatal(err, string(out))
+	}
+	return append(flags, result.Files...)
+}
+
+func main() {
+	tool, args := os.Args[1], os.Args[2:]
+	name := strings.TrimSuffix(filepath.Base(tool), ".exe")
+	if name == "compile" && len(args) > 0 && args[0] != "-V=full" {
+		args = instrument(args)
+	}
+	cmd := exec.Command(tool, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	} else if err != nil {
+		fatal(err, tool)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+// Packages imported by the importer of the main package must be part of the
+// build, the build system adds them by ToolexecResult.Imports, while the go
+// command only knows imports in the source code
+import (
+	_ "log"
+	_ "runtime/debug"
+
+	_ "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg"
+	_ "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/rules/otel-context"
+	_ "go.opentelemetry.io/otel"
+	_ "go.opentelemetry.io/otel/baggage"
+	_ "go.opentelemetry.io/otel/sdk/trace"
+	_ "toolexecapp/hook"
+)
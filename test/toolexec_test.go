@@ -0,0 +1,181 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/preprocess"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+func runToolexec(t *testing.T, args ...string) *preprocess.ToolexecResult {
+	stdout := RunToolexec(t, args...)
+	result := &preprocess.ToolexecResult{}
+	err := json.Unmarshal([]byte(stdout), result)
+	if err != nil {
+		t.Fatal(err, stdout)
+	}
+	return result
+}
+
+func TestToolexec(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	// Instrument the fmt package as if it was compiled by a build system
+	// other than the go command
+	fmtDir := filepath.Join(runtime.GOROOT(), "src", "fmt")
+	files, err := filepath.Glob(filepath.Join(fmtDir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"-importpath=fmt", UseTestRules("test_fmt.json")}
+	for _, file := range files {
+		if !strings.HasSuffix(file, "_test.go") {
+			args = append(args, file)
+		}
+	}
+	outDir := t.TempDir()
+	result := runToolexec(t, append([]string{"-o=" + outDir}, args...)...)
+	if !result.Instrumented || result.PackageName != "fmt" {
+		t.Fatalf("fmt is not instrumented: %+v", result)
+	}
+	printGo := filepath.Join(fmtDir, "print.go")
+	if !strings.HasPrefix(result.Replaced[printGo], outDir) {
+		t.Fatalf("print.go is not replaced: %v", result.Replaced)
+	}
+	for _, file := range result.Files {
+		if file == printGo {
+			t.Fatalf("print.go is still compiled: %v", result.Files)
+		}
+	}
+	trampoline := ""
+	for _, file := range result.Added {
+		if filepath.Base(file) == "otel_trampoline.go" {
+			trampoline = file
+		}
+	}
+	if trampoline == "" {
+		t.Fatalf("trampoline is not added: %v", result.Added)
+	}
+	content, err := os.ReadFile(trampoline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectContains(t, string(content), "OtelGetStackImpl")
+	ExpectContains(t, strings.Join(result.Hooks, ","), "pkg/rules/test/fmt1")
+
+	// Generate the importer of the main package from the result
+	resultFile := filepath.Join(outDir, "fmt.json")
+	writeToolexecResult(t, result, resultFile)
+	importerDir := t.TempDir()
+	importer := runToolexec(t, "-importer", "-o="+importerDir, resultFile)
+	if len(importer.Added) != 1 {
+		t.Fatalf("importer is not added: %+v", importer)
+	}
+	content, err = os.ReadFile(importer.Added[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectContains(t, string(content), "fmt.OtelGetStackImpl")
+	ExpectContains(t, string(content), "pkg/rules/test/fmt1")
+}
+
+func writeToolexecResult(t *testing.T, result *preprocess.ToolexecResult,
+	path string) {
+	bs, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, bs, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToolexecCompileAndLink(t *testing.T) {
+	const AppName = "toolexec"
+	UseApp(AppName)
+
+	// Every package is instrumented by otel toolexec and compiled with the
+	// result by the driver, as a build system that compiles packages itself
+	// would do, then they are linked into the binary
+	outDir := t.TempDir()
+	driver := filepath.Join(outDir, "driver")
+	cmd := exec.Command("go", "build", "-o", driver, "./driver")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	rule, err := filepath.Abs("rule.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := filepath.Abs("hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resultDir := filepath.Join(outDir, "results")
+	err = os.MkdirAll(resultDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(outDir, "app")
+	cmd = exec.Command("go", "build", "-toolexec="+driver, "-o", app, ".")
+	cmd.Env = append(os.Environ(),
+		// Everything is compiled by the driver rather than found in the cache
+		"GOCACHE="+filepath.Join(outDir, "gocache"),
+		"OTELTOOL_DISABLE_RULES=all",
+		"TOOLEXEC_OTEL="+filepath.Join(filepath.Dir(pwd), getExecName()),
+		"TOOLEXEC_RULE="+rule,
+		"TOOLEXEC_REPLACE=toolexecapp/hook="+hook,
+		"TOOLEXEC_RESULTS="+resultDir,
+	)
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	if util.PathExists(filepath.Join(".", util.TempBuildDir)) {
+		t.Fatal("toolexec should not write the temp build directory")
+	}
+
+	// The hook rewrites the parameter
+	out, err = exec.Command(app).CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	ExpectContains(t, string(out), "hello toolexec")
+}
+
+func TestToolexecNotMatched(t *testing.T) {
+	UseApp(HelloworldAppName)
+
+	main, err := filepath.Abs("app2.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := runToolexec(t, "-importpath=example.com/none",
+		"-o="+t.TempDir(), UseTestRules("test_fmt.json"), main)
+	if result.Instrumented || len(result.Files) != 1 ||
+		result.Files[0] != main {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
@@ -0,0 +1,7 @@
+module validate
+
+go 1.22.0
+
+replace validatehook => ./hook
+
+replace validateotelhook => ./otelhook
@@ -0,0 +1,3 @@
+module validatehook
+
+go 1.22
@@ -0,0 +1,43 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	_ "unsafe"
+
+	otelapi "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+//go:linkname greetOnEnter main.greetOnEnter
+func greetOnEnter(call otelapi.CallContext, name string) {
+	println("greetOnEnter", name)
+}
+
+//go:linkname greetOnExit main.greetOnExit
+func greetOnExit(call otelapi.CallContext, ret string) {
+	println("greetOnExit", ret)
+}
+
+//go:linkname greetReturns main.greetReturns
+func greetReturns(call otelapi.CallContext, name string) bool {
+	return true
+}
+
+//go:linkname greetNoContext main.greetNoContext
+func greetNoContext(name string) {}
+
+func greetWrongType(call otelapi.CallContext, name []byte) {}
+
+func greetExtraResult(call otelapi.CallContext, ret string, err error) {}
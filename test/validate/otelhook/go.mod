@@ -0,0 +1,12 @@
+module validateotelhook
+
+go 1.23.0
+
+replace github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg => ../../../pkg
+
+require (
+	github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require go.opentelemetry.io/otel v1.35.0 // indirect
@@ -0,0 +1,36 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelhook
+
+import (
+	"net/http"
+	_ "unsafe"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/inst-api/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var urlFilter = utils.DefaultUrlFilter{}
+
+// clientOnEnter is shaped like the built-in hook of net/http, but accepts the
+// span rather than the request, which is not a dependency of net/http
+//
+//go:linkname clientOnEnter net/http.clientOnEnter
+func clientOnEnter(call api.CallContext, t *http.Transport, span trace.Span) {
+	if !urlFilter.FilterUrl(nil) {
+		span.End()
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+)
+
+const ValidateAppName = "validate"
+
+func TestRulesValidate(t *testing.T) {
+	UseApp(ValidateAppName)
+
+	RunRules(t, "validate", "-default", "good.json")
+	ExpectStdoutContains(t, "base.json: 16 rules, ok")
+	ExpectStdoutContains(t, "good.json: 1 rules, ok")
+
+	// Configured custom rules are validated by default
+	RunSet(t, "-rule=good.json")
+	RunRules(t, "validate")
+	ExpectStdoutContains(t, "good.json: 1 rules, ok")
+	RunSet(t, "-rule=")
+}
+
+func TestRulesValidateProblems(t *testing.T) {
+	UseApp(ValidateAppName)
+
+	RunRulesFallible(t, "validate", "bad.json", "future.json")
+	ExpectStdoutContains(t, "bad.json: 9 rules, 9 problems")
+	ExpectStdoutContains(t, `#0: json: unknown field "OnEntr"`)
+	ExpectStdoutContains(t, "#1: bad version range [v1.0.0,)")
+	ExpectStdoutContains(t, "hook nosuchOnEnter is not found in validatehook")
+	ExpectStdoutContains(t, "hook greetReturns should not return anything")
+	ExpectStdoutContains(t, "hook greetNoContext should accept "+
+		"api.CallContext as the first parameter")
+	ExpectStdoutContains(t, `#5 func Greet: bad raw code "if {"`)
+	ExpectStdoutContains(t, "file nosuch.go is not found in validatehook")
+	// Hooks are type checked against the target function
+	ExpectStdoutContains(t, "#7 func Greet: hook greetWrongType does not "+
+		"match the target function: parameter #1 name has type []byte, "+
+		"expect string or interface{}")
+	ExpectStdoutContains(t, "#8 func Greet: hook greetExtraResult does not "+
+		"match the target function: hook has 3 parameters, expect 2, i.e. "+
+		"api.CallContext followed by (string)")
+	ExpectStdoutContains(t, "unsupported rule schema version 2, expect 1")
+	ExpectStderrContains(t, "10 problems are found in rule files")
+}
+
+func TestRulesValidateHookImports(t *testing.T) {
+	UseApp(ValidateAppName)
+
+	// Dependencies of hooks are resolved, so that parameters of their types
+	// are compared rather than skipped
+	RunRulesFallible(t, "validate", "builtin.json")
+	ExpectStdoutContains(t, "builtin.json: 1 rules, 1 problems")
+	ExpectStdoutContains(t, "#0 func (\\*Transport).RoundTrip: hook "+
+		"clientOnEnter does not match the target function: parameter #2 "+
+		"span has type trace.Span, expect *http.Request or interface{}")
+}
+
+func TestBuildVersionedRuleFile(t *testing.T) {
+	UseApp(ValidateAppName)
+
+	RunSet(t, "-rule=good.json")
+	RunGoBuild(t, "go", "build")
+	_, stderr := RunApp(t, ValidateAppName)
+	ExpectContains(t, stderr, "greetOnEnter World")
+	ExpectContains(t, stderr, "greetOnExit Hello, World")
+	RunSet(t, "-rule=")
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	reqCtx := make(chan context.Context, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("zap handler")
+		reqCtx <- r.Context()
+		_, _ = w.Write([]byte("success"))
+	}))
+	defer ts.Close()
+
+	_, err = http.Get(ts.URL)
+	if err != nil {
+		panic(err)
+	}
+	// The goroutine has no span, the record belongs to the span of ctx
+	logger.Info("zap context", zap.Any("ctx", <-reqCtx), zap.String("user", "alice"))
+	logger.Sync()
+}
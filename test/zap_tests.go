@@ -24,6 +24,8 @@ func init() {
 	TestCases = append(TestCases,
 		NewGeneralTestCase("zap-test", "zap", "", "", "1.21", "", TestZap),
 		NewGeneralTestCase("zap-test-with-field", "zap", "", "", "1.21", "", TestZapWithField),
+		NewGeneralTestCase("zap-test-log-bridge", "zap", "", "", "1.21", "", TestZapLogBridge),
+		NewGeneralTestCase("zap-test-log-bridge-context", "zap", "", "", "1.21", "", TestZapLogBridgeContext),
 	)
 }
 
@@ -57,3 +59,43 @@ func TestZapWithField(t *testing.T, env ...string) {
 		ExpectContains(t, line, "span_id")
 	}
 }
+
+func TestZapLogBridge(t *testing.T, env ...string) {
+	UseApp("zap")
+	RunGoBuild(t, "go", "build", "test_zap.go", "http_server.go")
+	// Records are printed to stdout by the console exporter, while the app
+	// keeps writing to stderr
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_zap", env...)
+	records := FindLogRecords(t, stdout, "warn info")
+	if len(records) != 2 {
+		t.Fatalf("expect 2 log records, got %d: %s", len(records), stdout)
+	}
+	for _, record := range records {
+		ExpectContains(t, record.Scope.Name, "go.uber.org/zap")
+		ExpectContains(t, record.SeverityText, "info")
+	}
+}
+
+func TestZapLogBridgeContext(t *testing.T, env ...string) {
+	UseApp("zap")
+	RunGoBuild(t, "go", "build", "test_zap_ctx.go")
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_zap_ctx", env...)
+	handlers := FindLogRecords(t, stdout, "zap handler")
+	if len(handlers) != 1 {
+		t.Fatalf("expect 1 log record in handler, got %d: %s", len(handlers), stdout)
+	}
+	// The context field decides the span, and is not copied as an attribute
+	records := FindLogRecords(t, stdout, "zap context")
+	if len(records) != 1 {
+		t.Fatalf("expect 1 log record with context, got %d: %s", len(records), stdout)
+	}
+	ExpectSame(t, handlers[0].SpanID, records[0].SpanID)
+	if records[0].Attribute("ctx") != nil {
+		t.Fatalf("expect no ctx attribute, got %v", records[0].Attributes)
+	}
+	if records[0].Attribute("user") != "alice" {
+		t.Fatalf("expect user attribute, got %v", records[0].Attributes)
+	}
+}
@@ -23,6 +23,7 @@ import (
 func init() {
 	TestCases = append(TestCases,
 		NewGeneralTestCase("zerolog-test", "zerolog", "", "", "1.21", "", TestZeroLog),
+		NewGeneralTestCase("zerolog-test-log-bridge", "zerolog", "", "", "1.21", "", TestZeroLogBridge),
 	)
 }
 
@@ -41,3 +42,22 @@ func TestZeroLog(t *testing.T, env ...string) {
 		ExpectContains(t, line, "span_id")
 	}
 }
+
+func TestZeroLogBridge(t *testing.T, env ...string) {
+	UseApp("zerolog")
+	RunGoBuild(t, "go", "build", "test_zerolog.go", "http_server.go")
+	env = append(env, "OTEL_LOGS_EXPORTER=console")
+	stdout, _ := RunApp(t, "test_zerolog", env...)
+	records := FindLogRecords(t, stdout, "abcde")
+	if len(records) == 0 {
+		t.Fatalf("expect log records, got none: %s", stdout)
+	}
+	for _, record := range records {
+		ExpectContains(t, record.Scope.Name, "github.com/rs/zerolog")
+		ExpectContains(t, record.SeverityText, "debug")
+		// Fields of the logger context are carried by the event
+		if record.Attribute("role") != "my-service" {
+			t.Fatalf("expect role attribute, got %v", record.Attributes)
+		}
+	}
+}
@@ -16,11 +16,13 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"unicode"
 
@@ -56,8 +58,133 @@ type BuildConfig struct {
 	// Note that base.json is inevitable to be enabled, even if it is explicitly
 	// disabled.
 	DisableRules string
+
+	// IncludePackages restricts instrumentation to packages matching these
+	// comma-separated patterns, e.g. "github.com/foo/...,net/http". Empty
+	// means all packages are candidates.
+	IncludePackages string
+
+	// ExcludePackages prevents packages matching these comma-separated patterns
+	// from being instrumented, it always takes precedence over IncludePackages.
+	ExcludePackages string
+
+	// ReachableFrom restricts instrumentation to packages that are imported,
+	// directly or indirectly, by the main packages matching these comma-separated
+	// patterns, e.g. "github.com/foo/cmd/server". Empty means all packages are
+	// candidates.
+	ReachableFrom string
+
+	// EnvDefaults specifies the default runtime environment variables of the
+	// instrumented binary, they are only applied when the variable is not set
+	// at runtime. It can be overwritten by environment variable in the format
+	// of "K1=V1,K2=V2".
+	EnvDefaults map[string]string `json:",omitempty"`
+
+	// SamplingRules specifies the sampling rules of the instrumented binary,
+	// which take precedence over the sampler configured by OTEL_TRACES_SAMPLER
+	// for spans they match. It can be overwritten by environment variable in
+	// the format of JSON array.
+	SamplingRules []SamplingRule `json:",omitempty"`
+
+	// ReportFormat specifies the format of the build report written under
+	// .otel-build. It can be "json" (default), "sarif" to additionally write a
+	// SARIF-like report, "none" to disable the build report, or the path of
+	// the report, where the extension .sarif selects the SARIF-like format.
+	ReportFormat string
+
+	// CacheDir specifies the directory of the persistent build cache, where
+	// instrumented compile outputs are reused across builds as long as neither
+	// the package nor the matched rules change. It can be shared by multiple
+	// builds, e.g. CI runners. Empty means the default directory under the
+	// user cache directory, and "off" disables the cache, i.e. every build
+	// recompiles all packages from scratch.
+	CacheDir string
+
+	// Offline true means the build never reaches the network. All required
+	// modules must be available in the module cache or the vendor directory,
+	// otherwise the build fails with the list of missing modules.
+	Offline bool
+
+	// RuleConflict specifies how conflicting rules are reported, e.g. a custom
+	// rule hooks the same function as a default rule, or two rules replace the
+	// same file. It can be "warn" (default) to print warnings and build anyway,
+	// or "error" to fail the build.
+	RuleConflict string
 }
 
+// SamplingRule decides how spans it matches are sampled, a span matches the
+// rule if it matches all non-empty patterns of the rule.
+type SamplingRule struct {
+	// Name is a regular expression matching the span name
+	Name string `json:",omitempty"`
+	// Route is a regular expression matching the http.route attribute, or the
+	// url.path attribute if the route is unknown when the span starts
+	Route string `json:",omitempty"`
+	// Scope is a regular expression matching the instrumentation scope name,
+	// e.g. "redis"
+	Scope string `json:",omitempty"`
+	// Ratio is the ratio of matched traces to sample, 0 drops all of them. It
+	// defaults to 1 if absent.
+	Ratio float64
+	// Rate limits the number of sampled spans per second, 0 means no limit
+	Rate float64 `json:",omitempty"`
+}
+
+// UnmarshalJSON defaults the ratio to 1, as the project config does, a rule
+// that leaves it out is meant to limit the rate rather than to drop spans
+func (rule *SamplingRule) UnmarshalJSON(data []byte) error {
+	type plain SamplingRule
+	r := plain{Ratio: 1}
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+	*rule = SamplingRule(r)
+	return nil
+}
+
+// validate tells what is wrong with the sampling rule, along with the key of
+// the bad field, which is empty if the rule is wrong as a whole
+func (rule *SamplingRule) validate() (string, error) {
+	if rule.Name == "" && rule.Route == "" && rule.Scope == "" {
+		return "", errors.New("expect name, route or scope")
+	}
+	for _, field := range []struct{ key, pattern string }{
+		{"name", rule.Name},
+		{"route", rule.Route},
+		{"scope", rule.Scope},
+	} {
+		if _, err := regexp.Compile(field.pattern); err != nil {
+			return field.key, err
+		}
+	}
+	if rule.Ratio < 0 || rule.Ratio > 1 {
+		return "ratio", fmt.Errorf("%v is not in [0, 1]", rule.Ratio)
+	}
+	if rule.Rate < 0 {
+		return "rate", fmt.Errorf("%v is negative", rule.Rate)
+	}
+	return "", nil
+}
+
+const (
+	CacheOff        = "off"
+	DefaultCacheDir = "opentelemetry-go-auto-instrumentation"
+)
+
+const (
+	ReportFormatJson  = "json"
+	ReportFormatSarif = "sarif"
+	ReportFormatNone  = "none"
+	ReportExtJson     = ".json"
+	ReportExtSarif    = ".sarif"
+)
+
+const (
+	RuleConflictWarn  = "warn"
+	RuleConflictError = "error"
+)
+
 // @@This value is specified by the build system.
 // This is the version of the tool, which will be printed when the -version flag
 // is passed.
@@ -65,13 +192,28 @@ var ToolVersion = "1.0.0"
 
 var conf *BuildConfig
 
+// flagItems maps the command line flags of "otel set" to config items
+var flagItems = map[string]string{
+	"verbose":   "Verbose",
+	"debug":     "Debug",
+	"rule":      "RuleJsonFiles",
+	"disable":   "DisableRules",
+	"include":   "IncludePackages",
+	"exclude":   "ExcludePackages",
+	"reachable": "ReachableFrom",
+	"report":    "ReportFormat",
+	"cache":     "CacheDir",
+	"offline":   "Offline",
+	"conflict":  "RuleConflict",
+}
+
 func GetConf() *BuildConfig {
 	util.Assert(conf != nil, "build config is not initialized")
 	return conf
 }
 
 func (bc *BuildConfig) IsDisableAll() bool {
-	return bc.DisableRules == "all"
+	return bc.DisableRules == DisableAllRules
 }
 
 // GetDisabledRules returns a set of rule file names that should be disabled
@@ -79,6 +221,109 @@ func (bc *BuildConfig) GetDisabledRules() string {
 	return bc.DisableRules
 }
 
+// GetReportFormat returns the format of the build report
+func (bc *BuildConfig) GetReportFormat() string {
+	switch {
+	case bc.ReportFormat == "":
+		return ReportFormatJson
+	case isReportPath(bc.ReportFormat):
+		if filepath.Ext(bc.ReportFormat) == ReportExtSarif {
+			return ReportFormatSarif
+		}
+		return ReportFormatJson
+	}
+	return bc.ReportFormat
+}
+
+// GetReportPath returns the path of the build report if it's explicitly
+// given, or an empty string to write it under .otel-build
+func (bc *BuildConfig) GetReportPath() string {
+	if !isReportPath(bc.ReportFormat) {
+		return ""
+	}
+	return bc.ReportFormat
+}
+
+func isReportPath(report string) bool {
+	ext := filepath.Ext(report)
+	return ext == ReportExtJson || ext == ReportExtSarif
+}
+
+// GetRuleConflict returns how conflicting rules are reported
+func (bc *BuildConfig) GetRuleConflict() string {
+	if bc.RuleConflict == "" {
+		return RuleConflictWarn
+	}
+	return bc.RuleConflict
+}
+
+// IsCacheOff checks if the persistent build cache is disabled
+func (bc *BuildConfig) IsCacheOff() bool {
+	return bc.CacheDir == CacheOff
+}
+
+// GetCacheDir returns the absolute path of the persistent build cache
+func (bc *BuildConfig) GetCacheDir() (string, error) {
+	util.Assert(!bc.IsCacheOff(), "build cache is off")
+	dir := bc.CacheDir
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", errc.New(errc.ErrInvalidConfig, err.Error())
+		}
+		dir = filepath.Join(userCache, DefaultCacheDir)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errc.New(errc.ErrAbsPath, err.Error())
+	}
+	return dir, nil
+}
+
+func (bc *BuildConfig) checkReportFormat() error {
+	switch bc.GetReportFormat() {
+	case ReportFormatJson, ReportFormatSarif, ReportFormatNone:
+		return nil
+	}
+	return errc.New(errc.ErrInvalidConfig,
+		"unknown report format "+bc.ReportFormat).
+		With("expect", "json, sarif, none or a .json/.sarif path")
+}
+
+func (bc *BuildConfig) checkRuleConflict() error {
+	switch bc.GetRuleConflict() {
+	case RuleConflictWarn, RuleConflictError:
+		return nil
+	}
+	return errc.New(errc.ErrInvalidConfig,
+		"unknown rule conflict mode "+bc.RuleConflict).
+		With("expect", "warn or error")
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// GetIncludePackages returns the package patterns that are allowed to be
+// instrumented, nil means all packages are allowed
+func (bc *BuildConfig) GetIncludePackages() []string {
+	return splitList(bc.IncludePackages)
+}
+
+// GetExcludePackages returns the package patterns that are never instrumented
+func (bc *BuildConfig) GetExcludePackages() []string {
+	return splitList(bc.ExcludePackages)
+}
+
+// GetReachableFrom returns the patterns of main packages whose dependencies
+// are allowed to be instrumented, nil means all packages are allowed
+func (bc *BuildConfig) GetReachableFrom() []string {
+	return splitList(bc.ReachableFrom)
+}
+
 func (bc *BuildConfig) makeRuleAbs(file string) (string, error) {
 	if util.PathNotExists(file) {
 		return "", errc.New(errc.ErrNotExist, file)
@@ -124,14 +369,32 @@ func getConfPath(name string) string {
 	return util.GetTempBuildDirWith(name)
 }
 
-func storeConfig(bc *BuildConfig) error {
+// storeConfig persists the given config items of build config. Only the items
+// that are explicitly configured are stored, so that they overwrite the project
+// config precisely instead of resetting everything to zero values.
+func storeConfig(bc *BuildConfig, items map[string]bool) error {
 	util.Assert(bc != nil, "build config is not initialized")
 
-	file := getConfPath(BuildConfFile)
 	bs, err := json.Marshal(bc)
 	if err != nil {
 		return errc.New(errc.ErrInvalidJSON, err.Error())
 	}
+	all := make(map[string]json.RawMessage)
+	err = json.Unmarshal(bs, &all)
+	if err != nil {
+		return errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	stored := make(map[string]json.RawMessage)
+	for item := range items {
+		if v, exist := all[item]; exist {
+			stored[item] = v
+		}
+	}
+	bs, err = json.Marshal(stored)
+	if err != nil {
+		return errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	file := getConfPath(BuildConfFile)
 	_, err = util.WriteFile(file, string(bs))
 	if err != nil {
 		return err
@@ -139,25 +402,33 @@ func storeConfig(bc *BuildConfig) error {
 	return nil
 }
 
-func loadConfig() (*BuildConfig, error) {
-	util.Assert(conf == nil, "build config is already initialized")
-	// If the build config file does not exist, return a default build config
+// loadConfig loads config items persisted by "otel set" into the given build
+// config and returns the names of these items.
+func loadConfig(bc *BuildConfig) (map[string]bool, error) {
+	items := make(map[string]bool)
+	// If the build config file does not exist, leave build config untouched
 	confFile := getConfPath(BuildConfFile)
 	if util.PathNotExists(confFile) {
-		return &BuildConfig{}, nil
+		return items, nil
 	}
 	// Load build config from json file
-	file := getConfPath(BuildConfFile)
-	data, err := util.ReadFile(file)
+	data, err := util.ReadFile(confFile)
 	if err != nil {
-		return &BuildConfig{}, err
+		return items, err
+	}
+	stored := make(map[string]json.RawMessage)
+	err = json.Unmarshal([]byte(data), &stored)
+	if err != nil {
+		return items, errc.New(errc.ErrInvalidJSON, err.Error())
 	}
-	bc := &BuildConfig{}
 	err = json.Unmarshal([]byte(data), bc)
 	if err != nil {
-		return nil, errc.New(errc.ErrInvalidJSON, err.Error())
+		return items, errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	for item := range stored {
+		items[item] = true
 	}
-	return bc, nil
+	return items, nil
 }
 
 func toUpperSnakeCase(input string) string {
@@ -176,7 +447,7 @@ func toUpperSnakeCase(input string) string {
 
 	return string(result)
 }
-func loadConfigFromEnv(conf *BuildConfig) {
+func loadConfigFromEnv(conf *BuildConfig) error {
 	// Environment variables are able to overwrite the config items even if the
 	// config file sets them. The environment variable name is the upper snake
 	// case of the config item name, prefixed with "OTELTOOL_". For example, the
@@ -198,38 +469,104 @@ func loadConfigFromEnv(conf *BuildConfig) {
 				f.SetBool(envVal == "true")
 			case reflect.String:
 				f.SetString(envVal)
+			case reflect.Map:
+				m := make(map[string]string)
+				for _, kv := range strings.Split(envVal, ",") {
+					k, v, _ := strings.Cut(kv, "=")
+					if k != "" {
+						m[k] = v
+					}
+				}
+				f.Set(reflect.ValueOf(m))
+			case reflect.Slice:
+				val := reflect.New(f.Type())
+				err := json.Unmarshal([]byte(envVal), val.Interface())
+				if err != nil {
+					return errc.New(errc.ErrInvalidJSON, err.Error()).
+						With("env", envKey)
+				}
+				f.Set(val.Elem())
 			default:
 				util.ShouldNotReachHere()
 			}
 		}
 	}
+	return conf.checkSamplingRules()
 }
 
-func InitConfig() (err error) {
-	// Load build config from json file
-	conf, err = loadConfig()
-	if err != nil {
-		return err
+// checkSamplingRules validates sampling rules that come from anywhere but the
+// project config, which reports errors of its own against the file
+func (bc *BuildConfig) checkSamplingRules() error {
+	for i := range bc.SamplingRules {
+		key, err := bc.SamplingRules[i].validate()
+		if err != nil {
+			if key != "" {
+				key = "." + key
+			}
+			return errc.New(errc.ErrInvalidConfig,
+				fmt.Sprintf("sampling rules[%d]%s: %v", i, key, err))
+		}
 	}
-	loadConfigFromEnv(conf)
+	return nil
+}
 
-	err = conf.parseRuleFiles()
-	if err != nil {
-		return err
-	}
+func InitConfig() (err error) {
+	util.Assert(conf == nil, "build config is already initialized")
+	bc := &BuildConfig{}
 
 	mode := os.O_WRONLY | os.O_APPEND
 	if util.InPreprocess() {
 		// We always create log file in preprocess phase, but in further
-		// instrument phase, we append log content to the existing file.
-		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		// instrument phase, we append log content to the existing file. The
+		// preprocess phase keeps appending as well, otherwise it overwrites
+		// what the instrument phase appended meanwhile.
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC | os.O_APPEND
 	}
-	// Always redirect log to debug log file
-	debugLogPath := util.GetTempBuildDirWith(util.DebugLogFile)
+	// Always redirect log to debug log file, before anything is logged, as the
+	// standard output may be consumed by the caller
+	debugLogPath := util.GetBuildDirWith(util.DebugLogFile)
 	debugLog, _ := os.OpenFile(debugLogPath, mode, 0777)
 	if debugLog != nil {
 		util.SetLogger(debugLog)
 	}
+
+	// Load build config from project config file, if any
+	pc, err := loadProjectConfig()
+	if err != nil {
+		return err
+	}
+	if pc != nil {
+		pc.applyTo(bc)
+	}
+
+	// Load build config from json file, it overwrites the project config
+	_, err = loadConfig(bc)
+	if err != nil {
+		return err
+	}
+	// Load build config from environment variables, it overwrites all above
+	err = loadConfigFromEnv(bc)
+	if err != nil {
+		return err
+	}
+	conf = bc
+
+	err = conf.parseRuleFiles()
+	if err != nil {
+		return err
+	}
+	err = conf.checkReportFormat()
+	if err != nil {
+		return err
+	}
+	err = conf.checkRuleConflict()
+	if err != nil {
+		return err
+	}
+
+	if pc != nil && util.InPreprocess() {
+		util.Log("Use project config %s", pc.path)
+	}
 	return nil
 }
 
@@ -244,9 +581,11 @@ func PrintVersion() error {
 
 func Configure() error {
 	// Parse command line flags to get build config
-	bc, err := loadConfig()
+	bc := &BuildConfig{}
+	items, err := loadConfig(bc)
 	if err != nil {
 		bc = &BuildConfig{}
+		items = make(map[string]bool)
 	}
 	flag.BoolVar(&bc.Verbose, "verbose", bc.Verbose,
 		"Print verbose log")
@@ -256,12 +595,40 @@ func Configure() error {
 		"Use custom.json rules. Multiple rules are separated by comma.")
 	flag.StringVar(&bc.DisableRules, "disable", bc.DisableRules,
 		"Disable specific rules. Use 'all' to disable all default rules, or comma-separated list of rule file names to disable specific rules")
+	flag.StringVar(&bc.IncludePackages, "include", bc.IncludePackages,
+		"Only instrument packages matching these patterns. Multiple patterns are separated by comma.")
+	flag.StringVar(&bc.ExcludePackages, "exclude", bc.ExcludePackages,
+		"Never instrument packages matching these patterns. Multiple patterns are separated by comma.")
+	flag.StringVar(&bc.ReachableFrom, "reachable", bc.ReachableFrom,
+		"Only instrument packages reachable from main packages matching these patterns. Multiple patterns are separated by comma.")
+	flag.StringVar(&bc.ReportFormat, "report", bc.ReportFormat,
+		"Format of the build report written under .otel-build, one of json, sarif or none, or the path of the report")
+	flag.StringVar(&bc.CacheDir, "cache", bc.CacheDir,
+		"Directory of the persistent build cache, or 'off' to rebuild all packages every time")
+	flag.BoolVar(&bc.Offline, "offline", bc.Offline,
+		"Never reach the network, all required modules must be in the module cache or the vendor directory")
+	flag.StringVar(&bc.RuleConflict, "conflict", bc.RuleConflict,
+		"How conflicting rules are reported, one of warn or error")
 	flag.CommandLine.Parse(os.Args[2:])
+	err = bc.checkReportFormat()
+	if err != nil {
+		return err
+	}
+	err = bc.checkRuleConflict()
+	if err != nil {
+		return err
+	}
+
+	// Remember which config items are explicitly set, either by this time or
+	// by previous "otel set" commands
+	flag.Visit(func(f *flag.Flag) {
+		items[flagItems[f.Name]] = true
+	})
 
 	util.Log("Configured in %s", getConfPath(BuildConfFile))
 
 	// Store build config for future phases
-	err = storeConfig(bc)
+	err = storeConfig(bc, items)
 	if err != nil {
 		return err
 	}
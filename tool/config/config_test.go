@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadSamplingRulesFromEnv(t *testing.T) {
+	const env = EnvPrefix + "SAMPLING_RULES"
+	tests := []struct {
+		name    string
+		value   string
+		expect  []SamplingRule
+		wantErr string
+	}{
+		{
+			name:  "ratio defaults to 1",
+			value: `[{"Route":"^/healthz$","Ratio":0},{"Scope":"redis","Rate":10}]`,
+			expect: []SamplingRule{
+				{Route: "^/healthz$", Ratio: 0},
+				{Scope: "redis", Ratio: 1, Rate: 10},
+			},
+		},
+		{
+			name:    "bad json",
+			value:   `[{"Route":`,
+			wantErr: env,
+		},
+		{
+			name:    "empty rule",
+			value:   `[{"Ratio":0.5}]`,
+			wantErr: "expect name, route or scope",
+		},
+		{
+			name:    "bad pattern",
+			value:   `[{"Name":"a"},{"Scope":"("}]`,
+			wantErr: "rules[1].scope",
+		},
+		{
+			name:    "bad ratio",
+			value:   `[{"Name":"a","Ratio":2}]`,
+			wantErr: "is not in [0, 1]",
+		},
+		{
+			name:    "bad rate",
+			value:   `[{"Name":"a","Rate":-1}]`,
+			wantErr: "is negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(env, tt.value)
+			bc := &BuildConfig{}
+			err := loadConfigFromEnv(bc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expect error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(bc.SamplingRules, tt.expect) {
+				t.Fatalf("unexpected sampling rules %v", bc.SamplingRules)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/data"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// -----------------------------------------------------------------------------
+// Project Config
+//
+// The project config is a declarative, checked-in counterpart of "otel set".
+// It is discovered by walking up from the directory where go.mod is located,
+// so that every CI job builds with the same configuration without replaying
+// "otel set" commands beforehand. The final build config is resolved in the
+// following order, where latter ones take precedence over former ones:
+//
+//  1. Built-in defaults
+//  2. Project config file (otel.yaml)
+//  3. Configurations persisted by "otel set" command line flags
+//  4. OTELTOOL_* environment variables
+
+const (
+	ProjectConfigFile    = "otel.yaml"
+	ProjectConfigFileAlt = "otel.yml"
+	ProjectConfigVersion = 1
+	DisableAllRules      = "all"
+	BaseRuleFile         = "base.json"
+)
+
+type ProjectConfig struct {
+	// Version is the schema version of the project config file
+	Version int `yaml:"version"`
+	// Verbose true means print verbose log
+	Verbose *bool `yaml:"verbose"`
+	// Debug true means debug mode
+	Debug *bool `yaml:"debug"`
+	// Rules configures which instrumentation rules are used
+	Rules ProjectRules `yaml:"rules"`
+	// Packages configures which packages are instrumented
+	Packages ProjectPackages `yaml:"packages"`
+	// Env specifies the default runtime environment variables of the
+	// instrumented binary, e.g. OTEL_SERVICE_NAME, they never overwrite the
+	// environment variables that are explicitly set at runtime
+	Env map[string]string `yaml:"env"`
+	// Sampling configures how traces are sampled at runtime
+	Sampling ProjectSampling `yaml:"sampling"`
+	// Report specifies the format of the build report, i.e. json, sarif or
+	// none, or the path of the report, relative paths are resolved against
+	// the directory of the project config file
+	Report string `yaml:"report"`
+	// Cache specifies the directory of the persistent build cache, relative
+	// paths are resolved against the directory of the project config file,
+	// "off" disables the cache
+	Cache string `yaml:"cache"`
+	// Offline true means the build never reaches the network
+	Offline *bool `yaml:"offline"`
+
+	// Where the project config file is located
+	path string
+}
+
+type ProjectRules struct {
+	// Files is a list of custom rule files, relative paths are resolved against
+	// the directory of the project config file
+	Files []string `yaml:"files"`
+	// Disable is a list of default rule files to disable, "all" disables all
+	// default rules except base.json
+	Disable []string `yaml:"disable"`
+	// Enable toggles default rule files one by one, e.g. "redis.json: false"
+	Enable map[string]bool `yaml:"enable"`
+	// Conflict specifies how conflicting rules are reported, i.e. warn or
+	// error
+	Conflict string `yaml:"conflict"`
+}
+
+type ProjectPackages struct {
+	// Include restricts instrumentation to packages matching these patterns
+	Include []string `yaml:"include"`
+	// Exclude prevents packages matching these patterns from instrumentation
+	Exclude []string `yaml:"exclude"`
+	// Reachable restricts instrumentation to packages imported by the main
+	// packages matching these patterns
+	Reachable []string `yaml:"reachable"`
+}
+
+type ProjectSampling struct {
+	// Rules are applied in order before the sampler configured by
+	// OTEL_TRACES_SAMPLER, the first rule a span matches decides whether it is
+	// sampled
+	Rules []ProjectSamplingRule `yaml:"rules"`
+}
+
+type ProjectSamplingRule struct {
+	// Name is a regular expression matching the span name
+	Name string `yaml:"name"`
+	// Route is a regular expression matching the http route or url path
+	Route string `yaml:"route"`
+	// Scope is a regular expression matching the instrumentation scope name
+	Scope string `yaml:"scope"`
+	// Ratio is the ratio of matched traces to sample, which defaults to 1
+	Ratio *float64 `yaml:"ratio"`
+	// Rate limits the number of sampled spans per second, 0 means no limit
+	Rate float64 `yaml:"rate"`
+}
+
+// toSamplingRule converts the rule to the one of the build config, where the
+// ratio defaults to 1
+func (rule ProjectSamplingRule) toSamplingRule() SamplingRule {
+	ratio := 1.0
+	if rule.Ratio != nil {
+		ratio = *rule.Ratio
+	}
+	return SamplingRule{
+		Name:  rule.Name,
+		Route: rule.Route,
+		Scope: rule.Scope,
+		Ratio: ratio,
+		Rate:  rule.Rate,
+	}
+}
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func newConfigError(path, format string, args ...interface{}) error {
+	return errc.New(errc.ErrInvalidConfig, fmt.Sprintf(format, args...)).
+		With("config", path)
+}
+
+// findProjectConfig finds the project config file by walking up from the
+// directory where go.mod is located. If there is no go.mod, the lookup starts
+// from the current working directory instead.
+func findProjectConfig() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errc.New(errc.ErrGetwd, err.Error())
+	}
+	start := wd
+	for dir := wd; ; {
+		if util.PathExists(filepath.Join(dir, util.GoModFile)) {
+			start = dir
+			break
+		}
+		par := filepath.Dir(dir)
+		if par == dir {
+			break
+		}
+		dir = par
+	}
+	for dir := start; ; {
+		for _, name := range []string{ProjectConfigFile, ProjectConfigFileAlt} {
+			path := filepath.Join(dir, name)
+			if util.PathExists(path) {
+				return path, nil
+			}
+		}
+		par := filepath.Dir(dir)
+		if par == dir {
+			break
+		}
+		dir = par
+	}
+	return "", nil
+}
+
+func parseProjectConfig(path string, content []byte) (*ProjectConfig, error) {
+	pc := &ProjectConfig{path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	// Unknown keys are most likely typos, reject them rather than silently
+	// ignoring them
+	decoder.KnownFields(true)
+	err := decoder.Decode(pc)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, newConfigError(path, "%v", err)
+	}
+	err = pc.validate()
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+func loadProjectConfig() (*ProjectConfig, error) {
+	path, err := findProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errc.New(errc.ErrOpenFile, err.Error())
+	}
+	return parseProjectConfig(path, content)
+}
+
+func validatePackagePattern(pattern string) bool {
+	if pattern == "" || strings.Contains(pattern, ",") {
+		return false
+	}
+	// Wildcards are allowed anywhere in the pattern, replace them with a
+	// placeholder to check the remaining path elements
+	path := strings.ReplaceAll(pattern, "...", "x")
+	return module.CheckImportPath(path) == nil
+}
+
+func (pc *ProjectConfig) validate() error {
+	if pc.Version != 0 && pc.Version != ProjectConfigVersion {
+		return newConfigError(pc.path, "unsupported version %d, expect %d",
+			pc.Version, ProjectConfigVersion)
+	}
+	defaults, err := data.ListRuleFiles()
+	if err != nil {
+		return errc.New(errc.ErrNotExist, err.Error())
+	}
+	for i, file := range pc.Rules.Files {
+		if file == "" {
+			return newConfigError(pc.path, "rules.files[%d] is empty", i)
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(pc.path), file)
+		}
+		if util.PathNotExists(file) {
+			return newConfigError(pc.path, "rules.files[%d]: %s does not exist",
+				i, file)
+		}
+		pc.Rules.Files[i] = file
+	}
+	for i, name := range pc.Rules.Disable {
+		if name == DisableAllRules {
+			continue
+		}
+		if !slices.Contains(defaults, name) {
+			return newConfigError(pc.path, "rules.disable[%d]: unknown rule %s",
+				i, name)
+		}
+	}
+	for name := range pc.Rules.Enable {
+		if !slices.Contains(defaults, name) {
+			return newConfigError(pc.path, "rules.enable: unknown rule %s", name)
+		}
+	}
+	switch pc.Rules.Conflict {
+	case "", RuleConflictWarn, RuleConflictError:
+	default:
+		return newConfigError(pc.path, "rules.conflict: unknown mode %q",
+			pc.Rules.Conflict)
+	}
+	for key, patterns := range map[string][]string{
+		"packages.include":   pc.Packages.Include,
+		"packages.exclude":   pc.Packages.Exclude,
+		"packages.reachable": pc.Packages.Reachable,
+	} {
+		for i, pattern := range patterns {
+			if !validatePackagePattern(pattern) {
+				return newConfigError(pc.path, "%s[%d]: bad package pattern %q",
+					key, i, pattern)
+			}
+		}
+	}
+	for name := range pc.Env {
+		if !envNameRegexp.MatchString(name) {
+			return newConfigError(pc.path, "env: bad variable name %q", name)
+		}
+	}
+	for i, rule := range pc.Sampling.Rules {
+		sr := rule.toSamplingRule()
+		key, err := sr.validate()
+		if err != nil {
+			if key != "" {
+				key = "." + key
+			}
+			return newConfigError(pc.path, "sampling.rules[%d]%s: %v", i, key,
+				err)
+		}
+	}
+	if pc.Cache != "" && pc.Cache != CacheOff && !filepath.IsAbs(pc.Cache) {
+		pc.Cache = filepath.Join(filepath.Dir(pc.path), pc.Cache)
+	}
+	switch {
+	case pc.Report == "", pc.Report == ReportFormatJson,
+		pc.Report == ReportFormatSarif, pc.Report == ReportFormatNone:
+	case isReportPath(pc.Report):
+		if !filepath.IsAbs(pc.Report) {
+			pc.Report = filepath.Join(filepath.Dir(pc.path), pc.Report)
+		}
+	default:
+		return newConfigError(pc.path, "report: unknown format %q", pc.Report)
+	}
+	return nil
+}
+
+// disabledRules resolves the final disabled rule list from rules.disable and
+// rules.enable, where rules.enable always wins.
+func (pc *ProjectConfig) disabledRules() string {
+	disabled := make([]string, 0)
+	if slices.Contains(pc.Rules.Disable, DisableAllRules) {
+		enabled := false
+		for _, on := range pc.Rules.Enable {
+			enabled = enabled || on
+		}
+		if !enabled {
+			return DisableAllRules
+		}
+		files, _ := data.ListRuleFiles()
+		disabled = append(disabled, files...)
+	} else {
+		disabled = append(disabled, pc.Rules.Disable...)
+	}
+	for name, on := range pc.Rules.Enable {
+		if on {
+			disabled = slices.DeleteFunc(disabled, func(s string) bool {
+				return s == name
+			})
+		} else if !slices.Contains(disabled, name) {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(disabled)
+	return strings.Join(disabled, ",")
+}
+
+// applyTo applies the project config to the build config, only the items that
+// are explicitly specified in the project config are applied.
+func (pc *ProjectConfig) applyTo(bc *BuildConfig) {
+	if pc.Verbose != nil {
+		bc.Verbose = *pc.Verbose
+	}
+	if pc.Debug != nil {
+		bc.Debug = *pc.Debug
+	}
+	if len(pc.Rules.Files) > 0 {
+		bc.RuleJsonFiles = strings.Join(pc.Rules.Files, ",")
+	}
+	if len(pc.Rules.Disable) > 0 || len(pc.Rules.Enable) > 0 {
+		bc.DisableRules = pc.disabledRules()
+	}
+	if pc.Rules.Conflict != "" {
+		bc.RuleConflict = pc.Rules.Conflict
+	}
+	if len(pc.Packages.Include) > 0 {
+		bc.IncludePackages = strings.Join(pc.Packages.Include, ",")
+	}
+	if len(pc.Packages.Exclude) > 0 {
+		bc.ExcludePackages = strings.Join(pc.Packages.Exclude, ",")
+	}
+	if len(pc.Packages.Reachable) > 0 {
+		bc.ReachableFrom = strings.Join(pc.Packages.Reachable, ",")
+	}
+	if len(pc.Env) > 0 {
+		bc.EnvDefaults = make(map[string]string, len(pc.Env))
+		for k, v := range pc.Env {
+			bc.EnvDefaults[k] = v
+		}
+	}
+	if len(pc.Sampling.Rules) > 0 {
+		bc.SamplingRules = make([]SamplingRule, 0, len(pc.Sampling.Rules))
+		for _, rule := range pc.Sampling.Rules {
+			bc.SamplingRules = append(bc.SamplingRules, rule.toSamplingRule())
+		}
+	}
+	if pc.Report != "" {
+		bc.ReportFormat = pc.Report
+	}
+	if pc.Cache != "" {
+		bc.CacheDir = pc.Cache
+	}
+	if pc.Offline != nil {
+		bc.Offline = *pc.Offline
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectConfigFile)
+	err := os.WriteFile(filepath.Join(dir, "custom.json"), []byte("[]"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "empty config",
+			content: "",
+		},
+		{
+			name: "full config",
+			content: `
+version: 1
+verbose: true
+rules:
+  files: [custom.json]
+  disable: [gorm.json]
+  enable:
+    redis.json: false
+  conflict: error
+packages:
+  include: ["github.com/foo/..."]
+  exclude: [net/http]
+env:
+  OTEL_SERVICE_NAME: foo
+sampling:
+  rules:
+    - route: ^/healthz$
+      ratio: 0
+    - scope: redis
+      ratio: 0.01
+      rate: 100
+report: sarif
+cache: .otel-cache
+offline: true
+`,
+		},
+		{
+			name:    "unsupported version",
+			content: "version: 2",
+			wantErr: "unsupported version",
+		},
+		{
+			name:    "unknown field",
+			content: "verbos: true",
+			wantErr: "field verbos not found",
+		},
+		{
+			name:    "missing rule file",
+			content: "rules:\n  files: [missing.json]",
+			wantErr: "does not exist",
+		},
+		{
+			name:    "unknown disabled rule",
+			content: "rules:\n  disable: [nope.json]",
+			wantErr: "unknown rule nope.json",
+		},
+		{
+			name:    "bad rule conflict mode",
+			content: "rules:\n  conflict: ignore",
+			wantErr: "unknown mode",
+		},
+		{
+			name:    "bad package pattern",
+			content: "packages:\n  exclude: [\"a,b\"]",
+			wantErr: "bad package pattern",
+		},
+		{
+			name:    "bad report format",
+			content: "report: xml",
+			wantErr: "unknown format",
+		},
+		{
+			name:    "bad env name",
+			content: "env:\n  1FOO: bar",
+			wantErr: "bad variable name",
+		},
+		{
+			name:    "empty sampling rule",
+			content: "sampling:\n  rules:\n    - ratio: 0",
+			wantErr: "expect name, route or scope",
+		},
+		{
+			name:    "bad sampling pattern",
+			content: "sampling:\n  rules:\n    - name: \"(\"",
+			wantErr: "sampling.rules[0].name",
+		},
+		{
+			name:    "bad sampling ratio",
+			content: "sampling:\n  rules:\n    - name: a\n      ratio: 2",
+			wantErr: "is not in [0, 1]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProjectConfig(path, []byte(tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expect error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProjectConfigApply(t *testing.T) {
+	verbose := true
+	pc := &ProjectConfig{
+		Verbose: &verbose,
+		Rules: ProjectRules{
+			Disable:  []string{"gorm.json", "redis.json"},
+			Enable:   map[string]bool{"redis.json": true, "mongo.json": false},
+			Conflict: RuleConflictError,
+		},
+		Packages: ProjectPackages{
+			Exclude:   []string{"net/http"},
+			Reachable: []string{"example.com/cmd/a", "example.com/cmd/b"},
+		},
+		Env: map[string]string{"OTEL_SERVICE_NAME": "foo"},
+		Sampling: ProjectSampling{Rules: []ProjectSamplingRule{
+			{Route: "^/healthz$", Ratio: new(float64)},
+			{Scope: "redis", Rate: 10},
+		}},
+	}
+	bc := &BuildConfig{Debug: true}
+	pc.applyTo(bc)
+	if !bc.Verbose || !bc.Debug {
+		t.Fatalf("unexpected verbose/debug %v/%v", bc.Verbose, bc.Debug)
+	}
+	if bc.DisableRules != "gorm.json,mongo.json" {
+		t.Fatalf("unexpected disabled rules %s", bc.DisableRules)
+	}
+	if bc.ExcludePackages != "net/http" || bc.IncludePackages != "" {
+		t.Fatalf("unexpected package filters %s/%s",
+			bc.IncludePackages, bc.ExcludePackages)
+	}
+	if bc.ReachableFrom != "example.com/cmd/a,example.com/cmd/b" {
+		t.Fatalf("unexpected reachable from %s", bc.ReachableFrom)
+	}
+	if bc.RuleConflict != RuleConflictError {
+		t.Fatalf("unexpected rule conflict mode %s", bc.RuleConflict)
+	}
+	if bc.EnvDefaults["OTEL_SERVICE_NAME"] != "foo" {
+		t.Fatalf("unexpected env defaults %v", bc.EnvDefaults)
+	}
+	expect := []SamplingRule{
+		{Route: "^/healthz$", Ratio: 0},
+		{Scope: "redis", Ratio: 1, Rate: 10},
+	}
+	if !slices.Equal(bc.SamplingRules, expect) {
+		t.Fatalf("unexpected sampling rules %v", bc.SamplingRules)
+	}
+
+	offline := false
+	pc = &ProjectConfig{
+		Rules:   ProjectRules{Disable: []string{"all"}},
+		Cache:   CacheOff,
+		Offline: &offline,
+	}
+	bc = &BuildConfig{Offline: true}
+	pc.applyTo(bc)
+	if bc.Offline {
+		t.Fatalf("expect offline overridden by project config")
+	}
+	if !bc.IsDisableAll() {
+		t.Fatalf("expect all rules disabled, got %s", bc.DisableRules)
+	}
+	if !bc.IsCacheOff() {
+		t.Fatalf("expect cache off, got %s", bc.CacheDir)
+	}
+}
+
+func TestProjectConfigCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectConfigFile)
+	pc, err := parseProjectConfig(path, []byte("cache: .otel-cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Cache != filepath.Join(dir, ".otel-cache") {
+		t.Fatalf("unexpected cache dir %s", pc.Cache)
+	}
+	pc, err = parseProjectConfig(path, []byte("cache: off"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Cache != CacheOff {
+		t.Fatalf("unexpected cache dir %s", pc.Cache)
+	}
+}
@@ -44,6 +44,7 @@ const (
 	ErrGetExecutable
 	ErrInstrument
 	ErrPreprocess
+	ErrInvalidConfig
 )
 
 var errMessages = map[int]string{
@@ -70,6 +71,7 @@ var errMessages = map[int]string{
 	ErrNotModularized: "Not a modularized project",
 	ErrGetExecutable:  "Failed to get executable",
 	ErrInstrument:     "Failed to instrument",
+	ErrInvalidConfig:  "Invalid config",
 }
 
 type PlentifulError struct {
@@ -0,0 +1,261 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"github.com/dave/dst"
+)
+
+// -----------------------------------------------------------------------------
+// Generic Trampoline
+//
+// Generic functions and methods of generic types are instrumented by generic
+// trampolines, i.e. trampoline functions and the CallContextImpl share the
+// type parameters of the target function, and the trampoline-jump-if calls
+// them with explicit type arguments
+//
+//	func Get[K comparable, V any](k K) (v V) {
+//	    if ctx, skip := OtelOnEnterTrampoline_Get[K, V](&k); skip { ... }
+//	    ...
+//	}
+//	func OtelOnEnterTrampoline_Get[K comparable, V any](k *K) (CallContext, bool)
+//	type CallContextImpl[K comparable, V any] struct { ... }
+//
+// As hook functions are linked by name, they can not be generic. Parameters of
+// hooks whose types refer to type parameters must be declared as interface{}
+// (or any), the values are then passed as they are, boxed in the interface.
+
+// typeParamsOf returns the type parameters of the target function, or nil if
+// it's not generic. Type parameters of methods come from the receiver, while
+// their constraints come from the declaration of the receiver type
+func (rp *RuleProcessor) typeParamsOf(funcDecl *dst.FuncDecl) (*dst.FieldList, error) {
+	if funcDecl.Type.TypeParams != nil {
+		return dst.Clone(funcDecl.Type.TypeParams).(*dst.FieldList), nil
+	}
+	if !util.HasReceiver(funcDecl) {
+		return nil, nil
+	}
+	typ := funcDecl.Recv.List[0].Type
+	if star, ok := typ.(*dst.StarExpr); ok {
+		typ = star.X
+	}
+	var base dst.Expr
+	var indices []dst.Expr
+	switch t := typ.(type) {
+	case *dst.IndexExpr:
+		base, indices = t.X, []dst.Expr{t.Index}
+	case *dst.IndexListExpr:
+		base, indices = t.X, t.Indices
+	default:
+		return nil, nil
+	}
+	typeName := base.(*dst.Ident).Name
+	// Blank type parameters can not be referenced by trampolines, name them
+	names := make([]string, 0, len(indices))
+	for i, index := range indices {
+		ident, ok := index.(*dst.Ident)
+		if !ok {
+			return nil, errc.New(errc.ErrInstrument,
+				"bad receiver type of "+funcDecl.Name.Name)
+		}
+		if ident.Name == "_" {
+			ident.Name = fmt.Sprintf("OtelTypeParam%d", i)
+		}
+		names = append(names, ident.Name)
+	}
+	spec, err := rp.findTypeSpec(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if spec.TypeParams == nil {
+		return nil, errc.New(errc.ErrInstrument,
+			"type "+typeName+" is not generic")
+	}
+	// Type parameters of the receiver may be named differently from the type
+	// declaration, rename them in constraints as well
+	rename := make(map[string]string)
+	idx := 0
+	for _, field := range spec.TypeParams.List {
+		for _, name := range field.Names {
+			if idx < len(names) {
+				rename[name.Name] = names[idx]
+			}
+			idx++
+		}
+	}
+	if idx != len(names) {
+		return nil, errc.New(errc.ErrInstrument,
+			"mismatched type parameters of "+typeName)
+	}
+	params := &dst.FieldList{List: []*dst.Field{}}
+	idx = 0
+	for _, field := range spec.TypeParams.List {
+		constraint := dst.Clone(field.Type).(dst.Expr)
+		renameIdents(constraint, rename)
+		newField := &dst.Field{Type: constraint}
+		for range field.Names {
+			newField.Names = append(newField.Names, util.Ident(names[idx]))
+			idx++
+		}
+		params.List = append(params.List, newField)
+	}
+	return params, nil
+}
+
+// findTypeSpec finds the declaration of the named type within the package
+func (rp *RuleProcessor) findTypeSpec(name string) (*dst.TypeSpec, error) {
+	find := func(root *dst.File) *dst.TypeSpec {
+		for _, decl := range root.Decls {
+			genDecl, ok := decl.(*dst.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				if ts, ok := spec.(*dst.TypeSpec); ok && ts.Name.Name == name {
+					return ts
+				}
+			}
+		}
+		return nil
+	}
+	if rp.target != nil {
+		if spec := find(rp.target); spec != nil {
+			return spec, nil
+		}
+	}
+	for _, arg := range rp.compileArgs {
+		if !util.IsGoFile(arg) {
+			continue
+		}
+		root, err := util.ParseAstFromFileFast(rp.tryRelocated(arg))
+		if err != nil {
+			return nil, err
+		}
+		if spec := find(root); spec != nil {
+			return spec, nil
+		}
+	}
+	return nil, errc.New(errc.ErrNotExist, "can not find type "+name)
+}
+
+func renameIdents(node dst.Node, rename map[string]string) {
+	dst.Inspect(node, func(node dst.Node) bool {
+		switch n := node.(type) {
+		case *dst.SelectorExpr:
+			// Qualified identifiers never refer to type parameters
+			return false
+		case *dst.Ident:
+			if newName, ok := rename[n.Name]; ok {
+				n.Name = newName
+			}
+		}
+		return true
+	})
+}
+
+// typeParamNames returns names of all type parameters
+func typeParamNames(params *dst.FieldList) []string {
+	if params == nil {
+		return nil
+	}
+	return getNames(params)
+}
+
+// instantiate instantiates the generic function or type with type parameters
+// as type arguments, e.g. Foo -> Foo[K, V]
+func instantiate(x dst.Expr, params *dst.FieldList) dst.Expr {
+	names := typeParamNames(params)
+	switch len(names) {
+	case 0:
+		return x
+	case 1:
+		return &dst.IndexExpr{X: x, Index: util.Ident(names[0])}
+	}
+	indices := make([]dst.Expr, 0, len(names))
+	for _, name := range names {
+		indices = append(indices, util.Ident(name))
+	}
+	return &dst.IndexListExpr{X: x, Indices: indices}
+}
+
+// typeArgsLiteral returns the type arguments in source form, e.g. "[K, V]"
+func typeArgsLiteral(params *dst.FieldList) string {
+	names := typeParamNames(params)
+	if len(names) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// refersTypeParam checks if the type refers to any of the type parameters
+func refersTypeParam(typ dst.Expr, params *dst.FieldList) bool {
+	names := typeParamNames(params)
+	if len(names) == 0 {
+		return false
+	}
+	found := false
+	dst.Inspect(typ, func(node dst.Node) bool {
+		switch n := node.(type) {
+		case *dst.SelectorExpr:
+			return false
+		case *dst.Ident:
+			for _, name := range names {
+				if n.Name == name {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// genericCallContextImpl instantiates the references to CallContextImpl in
+// trampolines, i.e. &CallContextImpl{} and callContext.(*CallContextImpl)
+func (rp *RuleProcessor) genericCallContextImpl(implName string) {
+	if rp.typeParams == nil {
+		return
+	}
+	structType := rp.callCtxDecl.Specs[0].(*dst.TypeSpec)
+	structType.TypeParams = dst.Clone(rp.typeParams).(*dst.FieldList)
+	for _, method := range rp.callCtxMethods {
+		star := method.Recv.List[0].Type.(*dst.StarExpr)
+		star.X = instantiate(star.X, rp.typeParams)
+	}
+	isImpl := func(x dst.Expr) bool {
+		ident, ok := x.(*dst.Ident)
+		return ok && ident.Name == implName
+	}
+	for _, node := range []dst.Node{rp.onEnterHookFunc, rp.onExitHookFunc} {
+		dst.Inspect(node, func(node dst.Node) bool {
+			switch n := node.(type) {
+			case *dst.CompositeLit:
+				if isImpl(n.Type) {
+					n.Type = instantiate(n.Type, rp.typeParams)
+				}
+			case *dst.StarExpr:
+				if isImpl(n.X) {
+					n.X = instantiate(n.X, rp.typeParams)
+				}
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"github.com/dave/dst"
+)
+
+// -----------------------------------------------------------------------------
+// Golden Test
+//
+// Each directory under testdata/golden is a test case, which consists of
+//
+//	rules.json          rules to apply, local paths are relative to the case
+//	*.go                the package to be instrumented
+//	hook/               hooks of the rules, or wherever rules refer to
+//	*.golden            expected content of rewritten and generated files
+//
+// The package is instrumented the same way as the instrument phase does, but
+// without invoking the go command. Every rewritten or generated file, except
+// otel_api.go, is compared with the golden file of the same name, where random
+// suffixes of rules are replaced with stable ones. The instrumented package is
+// then type checked to make sure it compiles. Run the following command to
+// update golden files after changing the instrumentation:
+//
+//	go test ./tool/instrument -run TestGolden -update
+//
+// Call rules are not supported, as they require export data of dependencies.
+
+const (
+	goldenRuleFile = "rules.json"
+	goldenSuffix   = ".golden"
+)
+
+// loadGoldenRules loads rules of the case, local paths of rules are resolved
+// against the case directory
+func loadGoldenRules(t *testing.T, dir string) []resource.InstRule {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, goldenRuleFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raws []json.RawMessage
+	err = json.Unmarshal(content, &raws)
+	if err != nil {
+		t.Fatalf("bad %s: %v", goldenRuleFile, err)
+	}
+	rules := make([]resource.InstRule, 0, len(raws))
+	for _, raw := range raws {
+		probe := &struct {
+			Call       bool
+			Function   string
+			StructType string
+			FileName   string
+		}{}
+		err = json.Unmarshal(raw, probe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rule resource.InstRule
+		switch {
+		case probe.Call:
+			t.Fatalf("call rules are not supported: %s", raw)
+		case probe.StructType != "":
+			rule = &resource.InstStructRule{}
+		case probe.Function != "":
+			rule = &resource.InstFuncRule{}
+		case probe.FileName != "":
+			rule = &resource.InstFileRule{}
+		default:
+			t.Fatalf("unknown rule kind: %s", raw)
+		}
+		err = json.Unmarshal(raw, rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rule.Verify()
+		if err != nil {
+			t.Fatalf("bad rule %s: %v", raw, err)
+		}
+		if r, ok := rule.(*resource.InstFuncRule); ok && r.Span {
+			t.Fatalf("span rules are not supported: %s", raw)
+		}
+		if rule.GetPath() != "" {
+			rule.SetPath(filepath.Join(dir, rule.GetPath()))
+		}
+		if r, ok := rule.(*resource.InstFileRule); ok {
+			r.FileName = filepath.Join(r.GetPath(), r.FileName)
+		}
+		rule.SetRuleFile(goldenRuleFile)
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matchGoldenRules matches rules with source files of the package, as the
+// preprocess phase does
+func matchGoldenRules(t *testing.T, rules []resource.InstRule,
+	files []string) *resource.RuleBundle {
+	t.Helper()
+	var bundle *resource.RuleBundle
+	for _, file := range files {
+		root, err := util.ParseAstFromFileFast(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rule := range rules {
+			if bundle == nil {
+				bundle = resource.NewRuleBundle(rule.GetImportPath())
+				bundle.SetPackageName(root.Name.Name)
+			}
+			if rule.GetImportPath() != bundle.ImportPath {
+				t.Fatalf("rules target different packages %s and %s",
+					rule.GetImportPath(), bundle.ImportPath)
+			}
+			switch rl := rule.(type) {
+			case *resource.InstFileRule:
+				// File rules are applied once per package
+				if file == files[0] {
+					bundle.AddFileRule(rl)
+				}
+			case *resource.InstStructRule:
+				for _, decl := range root.Decls {
+					if util.MatchStructDecl(decl, rl.StructType) {
+						err = bundle.AddFile2StructRule(file, rl)
+						if err != nil {
+							t.Fatal(err)
+						}
+						break
+					}
+				}
+			case *resource.InstFuncRule:
+				for _, decl := range root.Decls {
+					if _, ok := decl.(*dst.FuncDecl); !ok {
+						continue
+					}
+					if rl.MatchFuncDecl(decl) {
+						err = bundle.AddFile2FuncRule(file, rl)
+						if err != nil {
+							t.Fatal(err)
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+	if bundle == nil || !bundle.IsValid() {
+		t.Fatal("no rule matched")
+	}
+	return bundle
+}
+
+// Every rule applied to a function has its own CallContext implementation,
+// which is named after the random suffix of the rule application
+var suffixRegexp = regexp.MustCompile(TrampolineCallContextImplType +
+	`(\d{5})\b`)
+
+// normalizeImplSuffixes replaces random suffixes of rule applications with
+// stable ones in the order they first appear in the text. Unlike suffixes of
+// rules, they are distinct even if a pattern rule matches many functions
+func normalizeImplSuffixes(text string) string {
+	var suffixes []string
+	seen := make(map[string]bool)
+	for _, m := range suffixRegexp.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			suffixes = append(suffixes, m[1])
+		}
+	}
+	for i, suffix := range suffixes {
+		text = strings.ReplaceAll(text, suffix, fmt.Sprintf("_%d_", i))
+	}
+	return text
+}
+
+func runGoldenCase(t *testing.T, dir string) {
+	rules := loadGoldenRules(t, dir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make([]string, 0, len(files))
+	for _, file := range files {
+		if !util.IsGoTestFile(file) {
+			sources = append(sources, file)
+		}
+	}
+	bundle := matchGoldenRules(t, rules, sources)
+
+	workDir := t.TempDir()
+	rp := newRuleProcessorAt(sources, bundle.PackageName, workDir)
+	err = rp.applyRules(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare rewritten and generated files with golden files, stale golden
+	// files are reported as well
+	goldens := make(map[string]bool)
+	for _, arg := range rp.compileArgs {
+		name := filepath.Base(arg)
+		if !strings.HasPrefix(arg, workDir) || name == OtelAPIFile {
+			continue
+		}
+		text, err := os.ReadFile(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := filepath.Join(dir, name+goldenSuffix)
+		checkGolden(t, golden, normalizeImplSuffixes(string(text)))
+		goldens[golden] = true
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*"+goldenSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, golden := range existing {
+		if goldens[golden] {
+			continue
+		}
+		if *update {
+			_ = os.Remove(golden)
+		} else {
+			t.Errorf("%s is not generated", filepath.Base(golden))
+		}
+	}
+	typeCheckFiles(t, rp.compileArgs)
+}
+
+func TestGolden(t *testing.T) {
+	cases, err := os.ReadDir(filepath.Join(testdata, "golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+		t.Run(c.Name(), func(t *testing.T) {
+			runGoldenCase(t, filepath.Join(testdata, "golden", c.Name()))
+		})
+	}
+}
@@ -0,0 +1,681 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+)
+
+// -----------------------------------------------------------------------------
+// Call Site Instrumentation
+//
+// Call rules instrument call sites rather than the called function. Each call
+// matched by the call rule is redirected to a generated wrapper function that
+// has the same signature as the called function, i.e. the receiver comes first
+// if any, and then the wrapper is instrumented as if it's the target of an
+// ordinary func rule. Therefore, the hooks of call rules follow exactly the
+// same convention as func rules, and the trampoline and CallContext generation
+// are shared between them.
+//
+//	data, err := os.ReadFile(name)
+//
+// is rewritten to
+//
+//	data, err := otelCall0_ReadFile(name)
+//	func otelCall0_ReadFile(p0 string) ([]byte, error) {
+//		return otel_pkg0.ReadFile(p0)
+//	}
+//
+// Finding the called function requires type information, the package is type
+// checked against the export data of its dependencies listed in importcfg.
+
+const (
+	OtelCallSitesFile = "otel_call_sites.go"
+	callWrapperPrefix = "otelCall"
+	callImportPrefix  = "otel_pkg"
+	callRecvParam     = "recv"
+)
+
+type callWrapper struct {
+	name    string
+	callee  *types.Func
+	recv    types.Type // type of receiver parameter, nil for plain functions
+	rules   []*resource.InstCallRule
+	content string
+}
+
+// callee is what the CallContext of wrapper reports, i.e. the called function
+// rather than the generated wrapper
+type callee struct {
+	pkgName  string
+	funcName string
+}
+
+type cfgImporter struct {
+	gc        types.Importer
+	importMap map[string]string
+	files     map[string]string
+	sources   map[string]string // actual import path -> import path in source
+}
+
+func newCfgImporter() *cfgImporter {
+	return &cfgImporter{
+		importMap: make(map[string]string),
+		files:     make(map[string]string),
+		sources:   make(map[string]string),
+	}
+}
+
+// parseImportCfg parses the importcfg file passed to the compiler, it lists the
+// export data of all direct dependencies of the package being compiled
+func parseImportCfg(path string) (*cfgImporter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errc.New(errc.ErrOpenFile, err.Error())
+	}
+	defer func() { _ = file.Close() }()
+	ci := newCfgImporter()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		verb, args, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		k, v, found := strings.Cut(strings.TrimSpace(args), "=")
+		if !found {
+			continue
+		}
+		switch verb {
+		case "packagefile":
+			ci.files[k] = v
+		case "importmap":
+			ci.importMap[k] = v
+			ci.sources[v] = k
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errc.New(errc.ErrReadDir, err.Error())
+	}
+	return ci, nil
+}
+
+func (ci *cfgImporter) Import(path string) (*types.Package, error) {
+	if actual, ok := ci.importMap[path]; ok {
+		path = actual
+	}
+	return ci.gc.Import(path)
+}
+
+// importable checks if the package can be imported by generated code
+func (ci *cfgImporter) importable(path string) bool {
+	_, ok := ci.files[path]
+	return ok
+}
+
+// sourcePath returns the import path used in source code for the package
+func (ci *cfgImporter) sourcePath(path string) string {
+	if p, ok := ci.sources[path]; ok {
+		return p
+	}
+	return path
+}
+
+func findCompileFlag(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return ""
+}
+
+// loadImportCfg loads the importcfg of the package being compiled
+func (rp *RuleProcessor) loadImportCfg() (*cfgImporter, error) {
+	cfg := findCompileFlag(rp.compileArgs, "-importcfg")
+	if cfg == "" {
+		return nil, errc.New(errc.ErrInstrument, "no importcfg found")
+	}
+	return parseImportCfg(cfg)
+}
+
+// parseCompileFiles parses source files being compiled, where relocated files
+// are parsed instead of the original ones
+func (rp *RuleProcessor) parseCompileFiles(fset *token.FileSet) ([]*ast.File,
+	[]string, error) {
+	files := make([]*ast.File, 0)
+	paths := make([]string, 0)
+	for _, arg := range rp.compileArgs {
+		if !util.IsGoFile(arg) {
+			continue
+		}
+		path, err := filepath.Abs(rp.tryRelocated(arg))
+		if err != nil {
+			return nil, nil, errc.New(errc.ErrAbsPath, err.Error())
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, errc.New(errc.ErrParseCode, err.Error())
+		}
+		files = append(files, file)
+		paths = append(paths, path)
+	}
+	return files, paths, nil
+}
+
+// typesConfig returns the config to type check the package being compiled,
+// dependencies are imported from their export data listed in importcfg
+func (rp *RuleProcessor) typesConfig(importPath string, fset *token.FileSet,
+	ci *cfgImporter) *types.Config {
+	ci.gc = importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := ci.files[path]
+		if !ok {
+			return nil, fmt.Errorf("can not find export data of %s", path)
+		}
+		return os.Open(file)
+	})
+	goarch := os.Getenv("GOARCH")
+	if goarch == "" {
+		goarch = build.Default.GOARCH
+	}
+	return &types.Config{
+		Importer:  ci,
+		GoVersion: findCompileFlag(rp.compileArgs, "-lang"),
+		Sizes:     types.SizesFor("gc", goarch),
+		// The package is compilable, type errors are most likely caused by
+		// instrumentation or cgo, collect as much as we can anyway
+		Error: func(err error) {
+			util.Log("Type check %s: %v", importPath, err)
+		},
+	}
+}
+
+func (rp *RuleProcessor) typeCheck(importPath string, fset *token.FileSet,
+	files []*ast.File, ci *cfgImporter) (*types.Package, *types.Info) {
+	conf := rp.typesConfig(importPath, fset, ci)
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	pkg, _ := conf.Check(importPath, fset, files, info)
+	return pkg, info
+}
+
+// resolveCallee finds the called function and the receiver expression of the
+// call, generic functions and calls to function values are not supported
+func resolveCallee(info *types.Info, call *ast.CallExpr) (*types.Func, ast.Expr) {
+	var fn *types.Func
+	var recv ast.Expr
+	switch f := call.Fun.(type) {
+	case *ast.Ident:
+		fn, _ = info.Uses[f].(*types.Func)
+	case *ast.SelectorExpr:
+		if sel, ok := info.Selections[f]; ok {
+			if sel.Kind() != types.MethodVal {
+				return nil, nil
+			}
+			fn, _ = sel.Obj().(*types.Func)
+			recv = f.X
+		} else {
+			// Qualified identifier, i.e. pkg.Func
+			fn, _ = info.Uses[f.Sel].(*types.Func)
+		}
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return nil, nil
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || sig.RecvTypeParams().Len() > 0 {
+		return nil, nil
+	}
+	if sig.Recv() != nil {
+		if recv == nil {
+			return nil, nil
+		}
+		if named, ok := recvNamed(sig.Recv().Type()); ok &&
+			(named.TypeParams().Len() > 0 || named.TypeArgs().Len() > 0) {
+			return nil, nil
+		}
+	}
+	return fn, recv
+}
+
+func recvNamed(t types.Type) (*types.Named, bool) {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	return named, ok
+}
+
+// recvTypeName returns the receiver type name of the method in the form of
+// func rules, e.g. "*Client" or "Client"
+func recvTypeName(fn *types.Func) string {
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() == nil {
+		return ""
+	}
+	named, ok := recvNamed(sig.Recv().Type())
+	if !ok {
+		return ""
+	}
+	if _, ok := types.Unalias(sig.Recv().Type()).(*types.Pointer); ok {
+		return "*" + named.Obj().Name()
+	}
+	return named.Obj().Name()
+}
+
+func isContextType(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+func matchCallRule(rule *resource.InstCallRule, fn *types.Func) bool {
+	if !rule.MatchCallee(fn.Pkg().Path(), fn.Name(), recvTypeName(fn)) {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if rule.ContextFirst &&
+		(sig.Params().Len() == 0 || !isContextType(sig.Params().At(0).Type())) {
+		return false
+	}
+	if rule.ReturnsError {
+		n := sig.Results().Len()
+		if n == 0 || !types.Identical(sig.Results().At(n-1).Type(),
+			types.Universe.Lookup("error").Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameable checks if the type can be spelled out in the generated code of the
+// package, i.e. all the named types it refers to are exported and importable
+func nameable(t types.Type, pkg *types.Package, ci *cfgImporter) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Alias:
+		return nameable(types.Unalias(t), pkg, ci)
+	case *types.Pointer:
+		return nameable(t.Elem(), pkg, ci)
+	case *types.Slice:
+		return nameable(t.Elem(), pkg, ci)
+	case *types.Array:
+		return nameable(t.Elem(), pkg, ci)
+	case *types.Chan:
+		return nameable(t.Elem(), pkg, ci)
+	case *types.Map:
+		return nameable(t.Key(), pkg, ci) && nameable(t.Elem(), pkg, ci)
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if !nameable(tuple.At(i).Type(), pkg, ci) {
+					return false
+				}
+			}
+		}
+		return true
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			if !f.Exported() && f.Pkg() != pkg {
+				return false
+			}
+			if !nameable(f.Type(), pkg, ci) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			if !m.Exported() && m.Pkg() != pkg {
+				return false
+			}
+			if !nameable(m.Type(), pkg, ci) {
+				return false
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if !nameable(t.EmbeddedType(i), pkg, ci) {
+				return false
+			}
+		}
+		return true
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return true // error, comparable
+		}
+		// Types declared inside function are invisible to generated code
+		if obj.Parent() != obj.Pkg().Scope() {
+			return false
+		}
+		if obj.Pkg() != pkg {
+			if !obj.Exported() || !ci.importable(obj.Pkg().Path()) {
+				return false
+			}
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if !nameable(t.TypeArgs().At(i), pkg, ci) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// callSites collects wrappers of the package and generates the wrapper file
+type callSites struct {
+	pkg      *types.Package
+	ci       *cfgImporter
+	imports  map[string]string // import path -> alias
+	wrappers map[string]*callWrapper
+	ordered  []*callWrapper
+}
+
+func (cs *callSites) qualifier(p *types.Package) string {
+	if p == cs.pkg {
+		return ""
+	}
+	alias, ok := cs.imports[p.Path()]
+	if !ok {
+		alias = fmt.Sprintf("%s%d", callImportPrefix, len(cs.imports))
+		cs.imports[p.Path()] = alias
+	}
+	return alias
+}
+
+func (cs *callSites) typeString(t types.Type) string {
+	return types.TypeString(t, cs.qualifier)
+}
+
+// wrapperOf finds or creates the wrapper for the called function, it returns
+// nil if the wrapper can not be generated
+func (cs *callSites) wrapperOf(info *types.Info, call *ast.CallExpr,
+	fn *types.Func, recv ast.Expr, rules []*resource.InstCallRule) *callSite {
+	sig := fn.Type().(*types.Signature)
+	var recvType types.Type
+	recvOp := ""
+	if recv != nil {
+		// The wrapper always accepts the declared receiver of the method, the
+		// receiver at call site is adapted to it, i.e. the address is taken
+		// for pointer methods called on addressable values, and vice versa.
+		// Methods promoted from embedded fields are not supported.
+		recvType = sig.Recv().Type()
+		xt := info.TypeOf(recv)
+		if xt == nil {
+			return nil
+		}
+		ptr, isPtr := types.Unalias(xt).(*types.Pointer)
+		switch {
+		case types.IsInterface(recvType):
+			if !types.IsInterface(xt) {
+				return nil
+			}
+		case types.Identical(xt, recvType):
+		case types.Identical(types.NewPointer(xt), recvType):
+			recvOp = "&"
+		case isPtr && types.Identical(ptr.Elem(), recvType):
+			recvOp = "*"
+		default:
+			return nil
+		}
+		if !nameable(recvType, cs.pkg, cs.ci) {
+			return nil
+		}
+		// Multi-value expression can not be mixed with the receiver
+		if len(call.Args) == 1 {
+			if _, ok := info.TypeOf(call.Args[0]).(*types.Tuple); ok {
+				return nil
+			}
+		}
+	}
+	if !nameable(sig, cs.pkg, cs.ci) {
+		return nil
+	}
+	if w, ok := cs.wrappers[fn.FullName()]; ok {
+		return recvAt(w, recvOp)
+	}
+	w := &callWrapper{
+		name: fmt.Sprintf("%s%d_%s", callWrapperPrefix, len(cs.wrappers),
+			fn.Name()),
+		callee: fn,
+		recv:   recvType,
+		rules:  rules,
+	}
+	// Generate the wrapper function, i.e.
+	//   func otelCall0_Foo(recv *T, p0 int, p1 ...string) (int, error) {
+	//       return recv.Foo(p0, p1...)
+	//   }
+	params := make([]string, 0)
+	args := make([]string, 0)
+	target := ""
+	if recvType != nil {
+		params = append(params, callRecvParam+" "+cs.typeString(recvType))
+		target = callRecvParam + "." + fn.Name()
+	} else if q := cs.qualifier(fn.Pkg()); q != "" {
+		target = q + "." + fn.Name()
+	} else {
+		target = fn.Name()
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		name := fmt.Sprintf("p%d", i)
+		typ := sig.Params().At(i).Type()
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			elem := typ.(*types.Slice).Elem()
+			params = append(params, name+" ..."+cs.typeString(elem))
+			args = append(args, name+"...")
+			continue
+		}
+		params = append(params, name+" "+cs.typeString(typ))
+		args = append(args, name)
+	}
+	results := make([]string, 0)
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, cs.typeString(sig.Results().At(i).Type()))
+	}
+	body := target + "(" + strings.Join(args, ", ") + ")"
+	if len(results) > 0 {
+		body = "return " + body
+	}
+	content := fmt.Sprintf("func %s(%s) ", w.name, strings.Join(params, ", "))
+	if len(results) > 0 {
+		content += "(" + strings.Join(results, ", ") + ") "
+	}
+	content += "{\n\t" + body + "\n}\n"
+	w.content = content
+	cs.wrappers[fn.FullName()] = w
+	cs.ordered = append(cs.ordered, w)
+	return recvAt(w, recvOp)
+}
+
+// callSite is a call to be redirected to the wrapper
+type callSite struct {
+	wrapper *callWrapper
+	recvOp  string // operator applied to the receiver at call site
+}
+
+func recvAt(w *callWrapper, recvOp string) *callSite {
+	return &callSite{wrapper: w, recvOp: recvOp}
+}
+
+func (cs *callSites) source(pkgName string) string {
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package " + pkgName + "\n\n"
+	content += "import (\n\t_ \"unsafe\"\n"
+	for path, alias := range cs.imports {
+		content += fmt.Sprintf("\t%s %q\n", alias, cs.ci.sourcePath(path))
+	}
+	content += ")\n\n"
+	for _, w := range cs.ordered {
+		content += w.content + "\n"
+	}
+	return content
+}
+
+// rewriteCallSite redirects the call to the wrapper
+func rewriteCallSite(call *dst.CallExpr, site *callSite) {
+	if site.wrapper.recv != nil {
+		recv := call.Fun.(*dst.SelectorExpr).X
+		switch site.recvOp {
+		case "&":
+			recv = util.AddressOf(recv)
+		case "*":
+			recv = &dst.StarExpr{X: recv}
+		}
+		call.Args = append([]dst.Expr{recv}, call.Args...)
+	}
+	call.Fun = dst.NewIdent(site.wrapper.name)
+}
+
+// applyCallRules wraps call sites matched by call rules, the generated wrappers
+// are then instrumented by func rules, so it must be done before func rules
+func (rp *RuleProcessor) applyCallRules(bundle *resource.RuleBundle) error {
+	if len(bundle.CallRules) == 0 {
+		return nil
+	}
+	ci, err := rp.loadImportCfg()
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	files, paths, err := rp.parseCompileFiles(fset)
+	if err != nil {
+		return err
+	}
+	pkg, info := rp.typeCheck(bundle.ImportPath, fset, files, ci)
+	cs := &callSites{
+		pkg:      pkg,
+		ci:       ci,
+		imports:  make(map[string]string),
+		wrappers: make(map[string]*callWrapper),
+	}
+	for i, file := range files {
+		sites := make(map[*ast.CallExpr]*callSite)
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, recv := resolveCallee(info, call)
+			if fn == nil {
+				return true
+			}
+			matched := make([]*resource.InstCallRule, 0)
+			for _, rule := range bundle.CallRules {
+				if matchCallRule(rule, fn) {
+					matched = append(matched, rule)
+				}
+			}
+			if len(matched) == 0 {
+				return true
+			}
+			site := cs.wrapperOf(info, call, fn, recv, matched)
+			if site == nil {
+				util.Log("Skip call site of %s at %s", fn.FullName(),
+					fset.Position(call.Pos()))
+				return true
+			}
+			sites[call] = site
+			return true
+		})
+		if len(sites) == 0 {
+			continue
+		}
+		// Rewrite call sites on the decorated tree, which maps to the type
+		// checked tree node by node
+		dec := decorator.NewDecorator(fset)
+		root, err := dec.DecorateFile(file)
+		if err != nil {
+			return errc.New(errc.ErrParseCode, err.Error())
+		}
+		for call, site := range sites {
+			rewriteCallSite(dec.Dst.Nodes[call].(*dst.CallExpr), site)
+		}
+		newFile, err := rp.restoreAst(paths[i], root)
+		if err != nil {
+			return err
+		}
+		rp.saveDebugFile(newFile)
+		util.Log("Wrap %d call sites in %s", len(sites), paths[i])
+	}
+	if len(cs.ordered) == 0 {
+		return nil
+	}
+	// Generate wrappers and instrument them as ordinary functions
+	path := filepath.Join(rp.workDir, OtelCallSitesFile)
+	_, err = util.WriteFile(path, cs.source(bundle.PackageName))
+	if err != nil {
+		return err
+	}
+	rp.addCompileArg(path)
+	for _, w := range cs.ordered {
+		rp.callees[w.name] = &callee{
+			pkgName:  w.callee.Pkg().Name(),
+			funcName: w.callee.Name(),
+		}
+		for _, rule := range w.rules {
+			// Hooks of pattern rules are generic, keep the wrapper from being
+			// matched exactly so that they are not required to follow the
+			// signature of the called function
+			function := w.name
+			if rule.IsPattern() {
+				function = "^" + w.name + "$"
+			}
+			fr := &resource.InstFuncRule{
+				InstBaseRule: resource.InstBaseRule{
+					ImportPath: bundle.ImportPath,
+					Path:       rule.Path,
+					RuleFile:   rule.RuleFile,
+				},
+				Function: function,
+				Order:    rule.Order,
+				OnEnter:  rule.OnEnter,
+				OnExit:   rule.OnExit,
+			}
+			rp.callHooks[fr] = rule.HookImportPath
+			err = bundle.AddFile2FuncRule(path, fr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
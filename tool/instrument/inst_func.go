@@ -132,18 +132,10 @@ func (rp *RuleProcessor) insertTJump(t *resource.InstFuncRule,
 	var retVals []dst.Expr // nil by default
 	if retList := funcDecl.Type.Results; retList != nil {
 		retVals = make([]dst.Expr, 0)
-		// If return values are named, collect their names, otherwise we try to
-		// name them manually for further use
-		for _, field := range retList.List {
-			if field.Names != nil {
-				for _, name := range field.Names {
-					retVals = append(retVals, dst.NewIdent(name.Name))
-				}
-			} else {
-				retValIdent := dst.NewIdent("retVal" + util.RandomString(5))
-				field.Names = []*dst.Ident{retValIdent}
-				retVals = append(retVals, dst.Clone(retValIdent).(*dst.Ident))
-			}
+		// Return values are already named by nameParameters, collect their
+		// names for further use
+		for _, name := range getNames(retList) {
+			retVals = append(retVals, dst.NewIdent(name))
 		}
 	}
 
@@ -151,12 +143,8 @@ func (rp *RuleProcessor) insertTJump(t *resource.InstFuncRule,
 	args := make([]dst.Expr, 0)
 	// Receiver as argument for trampoline func, if any
 	if util.HasReceiver(funcDecl) {
-		if recv := funcDecl.Recv.List; recv != nil {
-			receiver := recv[0].Names[0].Name
-			args = append(args, util.AddressOf(util.Ident(receiver)))
-		} else {
-			util.Unimplemented()
-		}
+		receiver := funcDecl.Recv.List[0].Names[0].Name
+		args = append(args, util.AddressOf(util.Ident(receiver)))
 	}
 	// Original function arguments as arguments for trampoline func
 	for _, field := range funcDecl.Type.Params.List {
@@ -183,6 +171,10 @@ func (rp *RuleProcessor) insertTJump(t *resource.InstFuncRule,
 		}
 		return clone
 	}())
+	// Generic trampolines are called with explicit type arguments, as they
+	// can not be inferred from the call context
+	onEnterCall.Fun = instantiate(onEnterCall.Fun, rp.typeParams)
+	onExitCall.Fun = instantiate(onExitCall.Fun, rp.typeParams)
 	tjumpInit := util.DefineStmts(
 		util.Exprs(
 			util.Ident(TrampolineCallContextName+varSuffix),
@@ -199,9 +191,10 @@ func (rp *RuleProcessor) insertTJump(t *resource.InstFuncRule,
 	tjump := util.IfStmt(tjumpInit, tjumpCond, tjumpBody, tjumpElse)
 	// Add this trampoline-jump-if as optimization candidates
 	rp.trampolineJumps = append(rp.trampolineJumps, &TJump{
-		target: funcDecl,
-		ifStmt: tjump,
-		rule:   t,
+		target:     funcDecl,
+		ifStmt:     tjump,
+		rule:       t,
+		typeParams: rp.typeParams,
 	})
 	// Add label for trampoline-jump-if. Note that the label will be cleared
 	// during optimization pass, to make it pretty in the generated code
@@ -286,17 +279,52 @@ func (rp *RuleProcessor) insertRaw(r *resource.InstFuncRule, decl *dst.FuncDecl)
 	return nil
 }
 
-func nameReturnValues(funcDecl *dst.FuncDecl) {
-	if funcDecl.Type.Results != nil {
+// nameParameters names the receiver, parameters and results of the function
+// if they are unnamed or blank, so that trampolines can take their addresses,
+// e.g. func (Store) Len(int, _ string) int is rewritten as
+//
+//	func (recv0 Store) Len(param0 int, param1 string) (retVal0 int)
+//
+// Generated names never collide with identifiers used in the function, so
+// they do not shadow anything the function body refers to.
+func nameParameters(funcDecl *dst.FuncDecl) {
+	used := make(map[string]bool)
+	dst.Inspect(funcDecl, func(node dst.Node) bool {
+		if ident, ok := node.(*dst.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+	nameFields := func(list *dst.FieldList, prefix string) {
+		if list == nil {
+			return
+		}
 		idx := 0
-		for _, field := range funcDecl.Type.Results.List {
-			if field.Names == nil {
-				name := fmt.Sprintf("retVal%d", idx)
-				field.Names = []*dst.Ident{util.Ident(name)}
+		freshName := func() *dst.Ident {
+			for {
+				name := fmt.Sprintf("%s%d", prefix, idx)
 				idx++
+				if !used[name] {
+					used[name] = true
+					return util.Ident(name)
+				}
+			}
+		}
+		for _, field := range list.List {
+			if len(field.Names) == 0 {
+				field.Names = []*dst.Ident{freshName()}
+				continue
+			}
+			for i, name := range field.Names {
+				if util.IsUnusedIdent(name) {
+					field.Names[i] = freshName()
+				}
 			}
 		}
 	}
+	nameFields(funcDecl.Recv, "recv")
+	nameFields(funcDecl.Type.Params, "param")
+	nameFields(funcDecl.Type.Results, "retVal")
 }
 
 func sortFuncRules(fnRules []*resource.InstFuncRule) []*resource.InstFuncRule {
@@ -350,7 +378,15 @@ func (rp *RuleProcessor) applyFuncRules(bundle *resource.RuleBundle) (err error)
 	}
 	// Applied all matched func rules, either inserting raw code or inserting
 	// our trampoline calls.
-	for file, fn2rules := range bundle.File2FuncRules {
+	// Rules are applied in a stable order, so that the generated code is
+	// reproducible across builds
+	files := make([]string, 0, len(bundle.File2FuncRules))
+	for file := range bundle.File2FuncRules {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		fn2rules := bundle.File2FuncRules[file]
 		util.Assert(filepath.IsAbs(file), "file path must be absolute")
 		astRoot, err := rp.loadAst(file)
 		if err != nil {
@@ -362,34 +398,59 @@ func (rp *RuleProcessor) applyFuncRules(bundle *resource.RuleBundle) (err error)
 		// the generated function are exclued from the instrumented file.
 		oldDecls := make([]dst.Decl, len(astRoot.Decls))
 		copy(oldDecls, astRoot.Decls)
-		for fnName, rules := range fn2rules {
+		fnNames := make([]string, 0, len(fn2rules))
+		for fnName := range fn2rules {
+			fnNames = append(fnNames, fnName)
+		}
+		sort.Strings(fnNames)
+		for _, fnName := range fnNames {
+			rules := fn2rules[fnName]
 			for _, decl := range oldDecls {
-				nameAndRecvType := strings.Split(fnName, ",")
-				name := nameAndRecvType[0]
-				recvType := nameAndRecvType[1]
-				if util.MatchFuncDecl(decl, name, recvType) {
+				name := strings.Split(fnName, ",")[0]
+				// Rules sharing the same function pattern may differ in their
+				// filters, pick up the ones that actually match
+				matched := make([]*resource.InstFuncRule, 0, len(rules))
+				for _, rule := range rules {
+					if rule.MatchFuncDecl(decl) {
+						matched = append(matched, rule)
+					}
+				}
+				if len(matched) > 0 {
 					fnDecl := decl.(*dst.FuncDecl)
 					util.Assert(fnDecl.Body != nil, "target func boby is empty")
 					fnName := fnDecl.Name.Name
 					// Save raw function declaration
 					rp.rawFunc = fnDecl
+					rp.typeParams, err = rp.typeParamsOf(fnDecl)
+					if err != nil {
+						return err
+					}
 					// The func rule can either fully match the target function
 					// or use a regexp to match a batch of functions. The
 					// generation of tjump differs slightly between these two
 					// cases. In the former case, the hook function is required
 					// to have the same signature as the target function, while
 					// the latter does not have this requirement.
-					rp.exact = fnName == name
-					// Add explicit names for return values, they can be further
-					// referenced if we're willing
-					nameReturnValues(fnDecl)
+					exact := fnName == name
+					// Add explicit names for unnamed or blank parameters and
+					// return values, they are referenced by trampolines
+					nameParameters(fnDecl)
 
 					// Apply all matched rules for this function
-					fnRules := sortFuncRules(rules)
+					fnRules := sortFuncRules(matched)
 					for _, rule := range fnRules {
+						// Span rules are backed by the built-in generic hooks,
+						// which never follow the signature of target function
+						rp.exact = exact && !rule.Span
 						if rule.UseRaw {
 							err = rp.insertRaw(rule, fnDecl)
 						} else {
+							if !rule.Span {
+								err = rp.checkHooks(rule, fnDecl)
+								if err != nil {
+									return err
+								}
+							}
 							err = rp.insertTJump(rule, fnDecl)
 						}
 						if err != nil {
@@ -15,8 +15,12 @@
 package instrument
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -34,6 +38,9 @@ import (
 // predefined rules. It finds the rules that match the project dependencies and
 // applies the rules to the dependencies one by one.
 
+// Suffix of source files rewritten by "go test -cover"
+const coverFileSuffix = ".cover.go"
+
 type RuleProcessor struct {
 	// The package name of the target file
 	packageName string
@@ -49,6 +56,8 @@ type RuleProcessor struct {
 	rule2Suffix map[*resource.InstFuncRule]string
 	// The target function to be instrumented
 	rawFunc *dst.FuncDecl
+	// Type parameters of the target function, nil if it's not generic
+	typeParams *dst.FieldList
 	// Whether the rule is exact match with target functio, or it's a regexp match
 	exact bool
 	// The enter hook function, it should be inserted into the target source file
@@ -65,6 +74,14 @@ type RuleProcessor struct {
 	callCtxDecl *dst.GenDecl
 	// The methods of the call context
 	callCtxMethods []*dst.FuncDecl
+	// Hook functions that are already declared in the package
+	hookDecls map[string]bool
+	// Import paths of hooks from call rules, keyed by the synthesized rules
+	callHooks map[*resource.InstFuncRule]string
+	// The functions called by generated call site wrappers
+	callees map[string]*callee
+	// Type checker of hooks, created on demand
+	hookChecker *hookChecker
 }
 
 func newRuleProcessor(args []string, pkgName string) *RuleProcessor {
@@ -77,14 +94,22 @@ func newRuleProcessor(args []string, pkgName string) *RuleProcessor {
 		}
 	}
 	util.Assert(outputDir != "", "sanity check")
-	// Create a new rule processor
+	return newRuleProcessorAt(args, pkgName, outputDir)
+}
+
+// newRuleProcessorAt creates a rule processor that writes instrumented files to
+// the given working directory
+func newRuleProcessorAt(args []string, pkgName, workDir string) *RuleProcessor {
 	rp := &RuleProcessor{
 		packageName: pkgName,
-		workDir:     outputDir,
+		workDir:     workDir,
 		target:      nil,
 		compileArgs: args,
 		rule2Suffix: make(map[*resource.InstFuncRule]string),
 		relocated:   make(map[string]string),
+		hookDecls:   make(map[string]bool),
+		callHooks:   make(map[*resource.InstFuncRule]string),
+		callees:     make(map[string]*callee),
 	}
 	return rp
 }
@@ -193,6 +218,13 @@ func (rp *RuleProcessor) applyRules(bundle *resource.RuleBundle) (err error) {
 		return err
 	}
 
+	// Call rules generate wrappers which are then instrumented by func rules
+	err = rp.applyCallRules(bundle)
+	if err != nil {
+		err = errc.Adhere(err, "package", bundle.ImportPath)
+		return err
+	}
+
 	err = rp.applyFuncRules(bundle)
 	if err != nil {
 		err = errc.Adhere(err, "package", bundle.ImportPath)
@@ -211,8 +243,39 @@ func matchImportPath(importPath string, args []string) bool {
 	return false
 }
 
+// relocateCoverFiles relocates source files to their rewritten versions when
+// building tests with coverage, i.e. "go test -cover" compiles foo.cover.go in
+// the work directory instead of foo.go, which starts with a line directive
+// that refers to the original file, e.g. "//line /path/to/foo.go:1:1"
+func (rp *RuleProcessor) relocateCoverFiles() {
+	for _, arg := range rp.compileArgs {
+		if !strings.HasSuffix(arg, coverFileSuffix) {
+			continue
+		}
+		file, err := os.Open(arg)
+		if err != nil {
+			continue
+		}
+		line, _ := bufio.NewReader(file).ReadString('\n')
+		_ = file.Close()
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "//line ") {
+			continue
+		}
+		origin := strings.TrimPrefix(line, "//line ")
+		origin = strings.TrimSuffix(origin, ":1:1")
+		origin = strings.TrimSuffix(origin, ":1")
+		if !filepath.IsAbs(origin) {
+			continue
+		}
+		rp.setRelocated(origin, arg)
+		util.Log("Relocate %s to %s", origin, arg)
+	}
+}
+
 func compileRemix(bundle *resource.RuleBundle, args []string) error {
 	rp := newRuleProcessor(args, bundle.PackageName)
+	rp.relocateCoverFiles()
 	err := rp.applyRules(bundle)
 	if err != nil {
 		return err
@@ -230,11 +293,79 @@ func compileRemix(bundle *resource.RuleBundle, args []string) error {
 	return err
 }
 
+// InstrumentFiles applies the rule bundle to the package without compiling it,
+// which is used by build systems that invoke the compiler themselves. args are
+// the compile arguments, where source files are replaced by instrumented ones
+// in place and generated files are appended, the instrumented and generated
+// files are written to workDir.
+func InstrumentFiles(bundle *resource.RuleBundle, args []string,
+	workDir string) ([]string, error) {
+	args = append([]string{}, args...)
+	rp := newRuleProcessorAt(args, bundle.PackageName, workDir)
+	err := rp.applyRules(bundle)
+	if err != nil {
+		return nil, err
+	}
+	return rp.compileArgs, nil
+}
+
+// isCompilerIDQuery checks if the go command is querying the ID of compiler,
+// i.e. "compile -V=full", the ID is part of the cache key of compile outputs
+func isCompilerIDQuery(args []string) bool {
+	if len(args) != 2 || args[1] != "-V=full" {
+		return false
+	}
+	name := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+	return name == "compile"
+}
+
+// printCompilerID prints the compiler ID with the tool version appended, so
+// that outputs of other tool versions are never reused. Rules applied to each
+// package are part of its own inputs instead, see stripRuleSetFlag. The go
+// command takes the whole line as the ID for released toolchains, while it
+// takes the content ID of the trailing build ID for development toolchains.
+func printCompilerID(args []string) error {
+	sum := sha256.Sum256([]byte(config.ToolVersion))
+	hash := hex.EncodeToString(sum[:8])
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return errc.New(errc.ErrRunCmd, err.Error()).
+			With("command", fmt.Sprintf("%v", args))
+	}
+	line := strings.TrimSpace(string(out))
+	fields := strings.Fields(line)
+	if len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "buildID=") {
+		line += "." + hash
+	} else {
+		line += " otel=" + hash
+	}
+	fmt.Println(line)
+	return nil
+}
+
+// stripRuleSetFlag removes the flag carrying the rule set of the package, it's
+// meant for the build cache rather than the compiler
+func stripRuleSetFlag(args []string) []string {
+	stripped := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, resource.RuleSetFlag) {
+			stripped = append(stripped, arg)
+		}
+	}
+	return stripped
+}
+
 func Instrument() error {
 	// Remove the tool itself from the command line arguments
 	args := os.Args[2:]
+	if isCompilerIDQuery(args) {
+		return printCompilerID(args)
+	}
 	// Is compile command?
 	if util.IsCompileCommand(strings.Join(args, " ")) {
+		args = stripRuleSetFlag(args)
 		if config.GetConf().Verbose {
 			util.Log("RunCmd: %v", args)
 		}
@@ -94,9 +94,10 @@ import (
 
 // TJump describes a trampoline-jump-if optimization candidate
 type TJump struct {
-	target *dst.FuncDecl          // Target function we are hooking on
-	ifStmt *dst.IfStmt            // Trampoline-jump-if statement
-	rule   *resource.InstFuncRule // Rule associated with the trampoline-jump-if
+	target     *dst.FuncDecl          // Target function we are hooking on
+	ifStmt     *dst.IfStmt            // Trampoline-jump-if statement
+	rule       *resource.InstFuncRule // Rule associated with the trampoline-jump-if
+	typeParams *dst.FieldList         // Type parameters of generic target function
 }
 
 func mustTJump(ifStmt *dst.IfStmt) {
@@ -156,8 +157,8 @@ func (rp *RuleProcessor) newCallContextImpl(tjump *TJump) (dst.Expr, error) {
 	// TODO: This generated structure construction can also be marked via line
 	// directive
 	// One line please, otherwise debugging line number will be a nightmare
-	tmpl := fmt.Sprintf("&CallContextImpl%s{Params:[]interface{}{},ReturnVals:[]interface{}{}}",
-		rp.rule2Suffix[tjump.rule])
+	tmpl := fmt.Sprintf("&CallContextImpl%s%s{Params:[]interface{}{},ReturnVals:[]interface{}{}}",
+		rp.rule2Suffix[tjump.rule], typeArgsLiteral(tjump.typeParams))
 	p := util.NewAstParser()
 	astRoot, err := p.ParseSnippet(tmpl)
 	if err != nil {
@@ -0,0 +1,521 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"github.com/dave/dst"
+)
+
+// -----------------------------------------------------------------------------
+// Hook Signature Check
+//
+// Hook functions are never called directly by the target package. Instead, the
+// trampoline calls a function variable that has the signature of the target
+// function, and the variable is linked to the hook function by name. Neither
+// the compiler nor the linker compares the signature of the variable with the
+// hook function, a mismatched hook either breaks the generated trampoline in
+// an obscure way or, even worse, corrupts the stack at runtime. Therefore, each
+// hook is type checked against the target function before generating code:
+//
+//	func (s *Server) Greet(name string) (string, error)
+//	func onEnterGreet(call api.CallContext, s *Server, name string)
+//	func onExitGreet(call api.CallContext, greeting string, err error)
+//
+// The target package is type checked against importcfg, and the hook package
+// is type checked against the target package and importcfg as well, so that
+// types of both sides are comparable. Rule validation checks hooks the same
+// way, except that packages are loaded by go/packages instead, where hook
+// packages are loaded by their own modules and must be resolved completely. A
+// parameter of hook may be declared as interface{} to receive the value of any
+// type. A parameter whose type can not be resolved, e.g. its package is not a
+// dependency of the target package, is rejected as it can never be identical
+// to the type of the target. Only packages compiled without importcfg, whose
+// dependencies are unknown, are checked leniently.
+
+const apiImportPath = "github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+
+const unresolvedType = "that can not be resolved"
+
+type hookChecker struct {
+	fset *token.FileSet
+	// Imports dependencies of the target package
+	deps types.Importer
+	conf *types.Config
+	// The package being compiled
+	pkg *types.Package
+	// The api package, which is synthesized from APIDeclaration
+	api *types.Package
+	// Type checked hook packages, keyed by their local paths
+	hooks map[string]*types.Package
+	// Imports of hook packages that can not be resolved, keyed by import paths
+	importErrs map[string]error
+	// Whether dependencies are unknown, types that can not be resolved are
+	// not compared then
+	lenient bool
+}
+
+func newHookChecker(rp *RuleProcessor, importPath string) (*hookChecker, error) {
+	// Packages instrumented by otel toolexec may be compiled without importcfg,
+	// types of dependencies are then unknown and they are not compared
+	ci := newCfgImporter()
+	lenient := true
+	if findCompileFlag(rp.compileArgs, "-importcfg") != "" {
+		lenient = false
+		var err error
+		ci, err = rp.loadImportCfg()
+		if err != nil {
+			return nil, err
+		}
+	}
+	fset := token.NewFileSet()
+	files, _, err := rp.parseCompileFiles(fset)
+	if err != nil {
+		return nil, err
+	}
+	hc := &hookChecker{
+		fset:       fset,
+		deps:       ci,
+		conf:       rp.typesConfig(importPath, fset, ci),
+		hooks:      make(map[string]*types.Package),
+		importErrs: make(map[string]error),
+		lenient:    lenient,
+	}
+	hc.conf.IgnoreFuncBodies = true
+	hc.pkg, _ = hc.conf.Check(importPath, fset, files, nil)
+	hc.api, err = newAPIPackage(fset)
+	if err != nil {
+		return nil, err
+	}
+	return hc, nil
+}
+
+// newAPIPackage type checks the api package synthesized from APIDeclaration
+func newAPIPackage(fset *token.FileSet) (*types.Package, error) {
+	apiFile, err := parser.ParseFile(fset, OtelAPIFile,
+		"package api\n"+APIDeclaration, 0)
+	if err != nil {
+		return nil, errc.New(errc.ErrParseCode, err.Error())
+	}
+	api, err := (&types.Config{}).Check(apiImportPath, fset,
+		[]*ast.File{apiFile}, nil)
+	if err != nil {
+		return nil, errc.New(errc.ErrInternal, err.Error())
+	}
+	return api, nil
+}
+
+// packageImporter imports packages that are type checked already
+type packageImporter map[string]*types.Package
+
+func (pi packageImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := pi[path]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("package %s is not a dependency of the target", path)
+}
+
+// Import imports packages referenced by hooks, where the target package and
+// the api package are resolved by the checker itself
+func (hc *hookChecker) Import(path string) (*types.Package, error) {
+	switch path {
+	case hc.pkg.Path():
+		return hc.pkg, nil
+	case apiImportPath:
+		return hc.api, nil
+	}
+	pkg, err := hc.deps.Import(path)
+	if err != nil {
+		hc.importErrs[path] = err
+	}
+	return pkg, err
+}
+
+// importError describes imports of hook packages that can not be resolved
+func (hc *hookChecker) importError() string {
+	paths := make([]string, 0, len(hc.importErrs))
+	for path := range hc.importErrs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	problems := make([]string, 0, len(paths))
+	for _, path := range paths {
+		problems = append(problems, hc.importErrs[path].Error())
+	}
+	return strings.Join(problems, "; ")
+}
+
+// hookPackage type checks the hook package located at dir
+func (hc *hookChecker) hookPackage(dir string) (*types.Package, error) {
+	if pkg, ok := hc.hooks[dir]; ok {
+		return pkg, nil
+	}
+	paths, err := resource.ListHookFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*ast.File, 0)
+	for _, path := range paths {
+		ok, _ := build.Default.MatchFile(filepath.Dir(path), filepath.Base(path))
+		if !ok {
+			continue
+		}
+		file, err := parser.ParseFile(hc.fset, path, nil,
+			parser.SkipObjectResolution)
+		if err != nil {
+			return nil, errc.New(errc.ErrParseCode, err.Error())
+		}
+		files = append(files, file)
+	}
+	conf := &types.Config{
+		Importer:         hc,
+		Sizes:            hc.conf.Sizes,
+		IgnoreFuncBodies: true,
+		// Hooks may refer to packages that are not dependencies of the target
+		// package while instrumenting, only types used by hook parameters are
+		// of interest, they are rejected if they can not be resolved
+		Error: func(err error) {
+			util.Log("Type check hook %s: %v", dir, err)
+		},
+	}
+	pkg, _ := conf.Check(filepath.Base(dir), hc.fset, files, nil)
+	hc.hooks[dir] = pkg
+	return pkg, nil
+}
+
+// targetFunc finds the type checked target function
+func (hc *hookChecker) targetFunc(decl *dst.FuncDecl) *types.Func {
+	if hc.pkg == nil {
+		return nil
+	}
+	if !util.HasReceiver(decl) {
+		fn, _ := hc.pkg.Scope().Lookup(decl.Name.Name).(*types.Func)
+		return fn
+	}
+	typ := decl.Recv.List[0].Type
+	if star, ok := typ.(*dst.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *dst.IndexExpr:
+		typ = t.X
+	case *dst.IndexListExpr:
+		typ = t.X
+	}
+	ident, ok := typ.(*dst.Ident)
+	if !ok {
+		return nil
+	}
+	tn, ok := hc.pkg.Scope().Lookup(ident.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := types.Unalias(tn.Type()).(*types.Named)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if named.Method(i).Name() == decl.Name.Name {
+			return named.Method(i)
+		}
+	}
+	return nil
+}
+
+// describeTarget describes the target function in the form of func rules, e.g.
+// "func (*Server).Greet", call sites are described by the called function
+func (rp *RuleProcessor) describeTarget(decl *dst.FuncDecl) string {
+	if c, ok := rp.callees[decl.Name.Name]; ok {
+		return fmt.Sprintf("call %s.%s", c.pkgName, c.funcName)
+	}
+	if util.HasReceiver(decl) {
+		typ := decl.Recv.List[0].Type
+		recv := ""
+		if star, ok := typ.(*dst.StarExpr); ok {
+			recv, typ = "*", star.X
+		}
+		switch t := typ.(type) {
+		case *dst.IndexExpr:
+			typ = t.X
+		case *dst.IndexListExpr:
+			typ = t.X
+		}
+		if ident, ok := typ.(*dst.Ident); ok {
+			recv += ident.Name
+		}
+		return fmt.Sprintf("func (%s).%s", recv, decl.Name.Name)
+	}
+	return "func " + decl.Name.Name
+}
+
+func qualifier(pkg *types.Package) string {
+	return pkg.Name()
+}
+
+// isBrokenType checks if the type is not resolved by the type checker
+func isBrokenType(t types.Type) bool {
+	return strings.Contains(types.TypeString(t, qualifier), "invalid type")
+}
+
+func isCallContextType(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == apiImportPath &&
+		named.Obj().Name() == TrampolineCallContextType
+}
+
+// isEmptyInterface checks if the type is interface{} or any, named interfaces
+// are not considered as they are not boxed the same way
+func isEmptyInterface(t types.Type) bool {
+	iface, ok := types.Unalias(t).(*types.Interface)
+	return ok && iface.Empty()
+}
+
+func describeVar(v *types.Var, idx int) string {
+	if v.Name() == "" || v.Name() == "_" {
+		return fmt.Sprintf("#%d", idx)
+	}
+	return fmt.Sprintf("#%d %s", idx, v.Name())
+}
+
+func describeVars(vars []*types.Var, variadic bool) string {
+	names := make([]string, 0, len(vars))
+	for i, v := range vars {
+		if variadic && i == len(vars)-1 {
+			names = append(names, variadicString(v.Type()))
+		} else {
+			names = append(names, typeString(v.Type()))
+		}
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func typeString(t types.Type) string {
+	return types.TypeString(t, qualifier)
+}
+
+// variadicString formats the type of variadic parameter, i.e. ...T for []T
+func variadicString(t types.Type) string {
+	if slice, ok := t.(*types.Slice); ok {
+		return "..." + typeString(slice.Elem())
+	}
+	return typeString(t)
+}
+
+// expectedParams returns what the hook should accept after the CallContext,
+// i.e. the receiver and parameters of target function for OnEnter hook, or
+// its results for OnExit hook
+func expectedParams(sig *types.Signature, onEnter bool) ([]*types.Var, bool) {
+	vars := make([]*types.Var, 0)
+	if !onEnter {
+		for i := 0; i < sig.Results().Len(); i++ {
+			vars = append(vars, sig.Results().At(i))
+		}
+		return vars, false
+	}
+	if sig.Recv() != nil {
+		vars = append(vars, sig.Recv())
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		vars = append(vars, sig.Params().At(i))
+	}
+	return vars, sig.Variadic()
+}
+
+// checkParam checks if the hook parameter can receive the value of the target
+// parameter, the last parameter of both sides should agree on variadic
+func checkParam(param, expect *types.Var, variadic, expectVariadic bool,
+	idx int, lenient bool) string {
+	if isEmptyInterface(param.Type()) && !variadic {
+		return ""
+	}
+	if variadic != expectVariadic {
+		if expectVariadic {
+			return fmt.Sprintf("parameter %s should be variadic, i.e. %s",
+				describeVar(param, idx), variadicString(expect.Type()))
+		}
+		return fmt.Sprintf("parameter %s should not be variadic",
+			describeVar(param, idx))
+	}
+	// The target is type checked against complete dependencies, its broken
+	// types are not the fault of hooks
+	if isBrokenType(expect.Type()) {
+		return ""
+	}
+	if isBrokenType(param.Type()) {
+		if lenient {
+			return ""
+		}
+		return fmt.Sprintf("parameter %s has type %s %s",
+			describeVar(param, idx), typeString(param.Type()), unresolvedType)
+	}
+	if types.Identical(param.Type(), expect.Type()) {
+		return ""
+	}
+	typ := typeString(expect.Type())
+	if variadic {
+		typ = variadicString(expect.Type())
+	}
+	return fmt.Sprintf("parameter %s has type %s, expect %s or interface{}",
+		describeVar(param, idx), typeString(param.Type()), typ)
+}
+
+// checkHookSig checks the signature of hook against the target function, the
+// target is nil if the hook is generic
+func (hc *hookChecker) checkHookSig(hook, target *types.Signature,
+	onEnter bool) string {
+	problem := checkHookSig(hook, target, onEnter, hc.lenient)
+	if strings.HasSuffix(problem, unresolvedType) &&
+		len(hc.importErrs) > 0 {
+		problem += ", " + hc.importError()
+	}
+	return problem
+}
+
+func checkHookSig(hook, target *types.Signature, onEnter, lenient bool) string {
+	if hook.Results().Len() > 0 {
+		return "hook should not return anything"
+	}
+	params := make([]*types.Var, 0, hook.Params().Len())
+	for i := 0; i < hook.Params().Len(); i++ {
+		params = append(params, hook.Params().At(i))
+	}
+	if len(params) == 0 || !isCallContextType(params[0].Type()) {
+		if len(params) > 0 && isBrokenType(params[0].Type()) && lenient {
+			return ""
+		}
+		return "hook should accept api.CallContext as the first parameter"
+	}
+	if target == nil {
+		if len(params) > 1 {
+			return "hook of the pattern rule should accept api.CallContext only"
+		}
+		return ""
+	}
+	expect, expectVariadic := expectedParams(target, onEnter)
+	if len(params)-1 != len(expect) {
+		return fmt.Sprintf("hook has %d parameters, expect %d, i.e. "+
+			"api.CallContext followed by %s", len(params), len(expect)+1,
+			describeVars(expect, expectVariadic))
+	}
+	for i, param := range params[1:] {
+		last := i == len(expect)-1
+		problem := checkParam(param, expect[i], last && hook.Variadic(),
+			last && expectVariadic, i+1, lenient)
+		if problem != "" {
+			return problem
+		}
+	}
+	return ""
+}
+
+// CheckHookSig type checks the hook in the hook package located at dir against
+// the target function, where deps are type checked packages that the target
+// and hook packages depend on, keyed by import paths. It returns the problem
+// found, or an empty string if the hook matches or is not found. Imports of the
+// hook package that can not be resolved are errors.
+func CheckHookSig(dir, hook string, target *types.Func,
+	deps map[string]*types.Package, onEnter bool) (string, error) {
+	fset := token.NewFileSet()
+	api, err := newAPIPackage(fset)
+	if err != nil {
+		return "", err
+	}
+	hc := &hookChecker{
+		fset:       fset,
+		deps:       packageImporter(deps),
+		conf:       &types.Config{Sizes: types.SizesFor("gc", build.Default.GOARCH)},
+		pkg:        target.Pkg(),
+		api:        api,
+		hooks:      make(map[string]*types.Package),
+		importErrs: make(map[string]error),
+	}
+	pkg, err := hc.hookPackage(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(hc.importErrs) > 0 {
+		return "", errc.New(errc.ErrInvalidRule,
+			"could not import dependencies of hook package: "+
+				hc.importError()).With("dir", dir)
+	}
+	fn, ok := pkg.Scope().Lookup(hook).(*types.Func)
+	if !ok {
+		return "", nil
+	}
+	return hc.checkHookSig(fn.Type().(*types.Signature),
+		target.Type().(*types.Signature), onEnter), nil
+}
+
+// checkHooks type checks OnEnter and OnExit hooks of the rule against the
+// target function before generating any code
+func (rp *RuleProcessor) checkHooks(t *resource.InstFuncRule,
+	decl *dst.FuncDecl) error {
+	if rp.hookChecker == nil {
+		hc, err := newHookChecker(rp, t.ImportPath)
+		if err != nil {
+			return err
+		}
+		rp.hookChecker = hc
+	}
+	hc := rp.hookChecker
+	var target *types.Signature
+	if rp.exact {
+		fn := hc.targetFunc(decl)
+		if fn == nil {
+			util.Log("Skip hook check of %s, no type information",
+				decl.Name.Name)
+			return nil
+		}
+		target = fn.Type().(*types.Signature)
+	}
+	pkg, err := hc.hookPackage(t.GetPath())
+	if err != nil {
+		return err
+	}
+	for _, onEnter := range []bool{true, false} {
+		name := makeOnXName(t, onEnter)
+		if name == "" {
+			continue
+		}
+		// Missing hooks are reported when generating code
+		fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+		if !ok {
+			continue
+		}
+		problem := hc.checkHookSig(fn.Type().(*types.Signature), target,
+			onEnter)
+		if problem == "" {
+			continue
+		}
+		return errc.New(errc.ErrInvalidRule,
+			fmt.Sprintf("hook %s of rule %s does not match %s: %s", name,
+				t.GetRuleFile(), rp.describeTarget(decl), problem)).
+			With("rule", t.String())
+	}
+	return nil
+}
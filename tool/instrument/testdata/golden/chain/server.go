@@ -0,0 +1,39 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import "net/http"
+
+type Server struct {
+	addr string
+}
+
+// Instrumented by two rules in the given order
+func (s *Server) Serve(req *http.Request) (int, error) {
+	if req == nil {
+		return http.StatusBadRequest, nil
+	}
+	return http.StatusOK, nil
+}
+
+// Instrumented by a rule without OnExit hook
+func Listen(addr string) *Server {
+	return &Server{addr: addr}
+}
+
+// Instrumented by raw code
+func Close(s *Server) {
+	s.addr = ""
+}
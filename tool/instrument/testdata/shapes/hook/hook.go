@@ -0,0 +1,91 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api"
+)
+
+func printfOnEnter(call api.CallContext, format string, args ...interface{}) {}
+
+func printfOnExit(call api.CallContext, n int, err error) {}
+
+func joinOnEnter(call api.CallContext, sep string, parts ...any) {}
+
+func joinOnExit(call api.CallContext, ret string) {}
+
+func walkOnEnter(call api.CallContext, root string,
+	fn func(path string, err error) error, filter interface{}) {
+}
+
+func walkOnExit(call api.CallContext, err error) {}
+
+func pipeOnEnter(call api.CallContext, in <-chan int, out chan<- string,
+	done chan struct{}) {
+}
+
+func pipeOnExit(call api.CallContext, n int) {}
+
+func sumOnEnter(call api.CallContext, items interface{},
+	pairs []struct{ A, B int }) {
+}
+
+func sumOnExit(call api.CallContext, total int) {}
+
+func discardOnEnter(call api.CallContext, _ int, _ string) {}
+
+func discardOnExit(call api.CallContext, _ bool) {}
+
+func blankOnEnter(call api.CallContext, ctx context.Context, _ int,
+	name string) {
+}
+
+func blankOnExit(call api.CallContext, _ string, err error) {}
+
+func lenOnEnter(call api.CallContext, s interface{}) {}
+
+func lenOnExit(call api.CallContext, n int) {}
+
+func resetOnEnter(call api.CallContext, s interface{}) {}
+
+func resetOnExit(call api.CallContext) {}
+
+func shadowOnEnter(call api.CallContext, n int) {}
+
+func shadowOnExit(call api.CallContext, res int, err error) {}
+
+func groupedOnEnter(call api.CallContext, a, b int, c, d string) {}
+
+func groupedOnExit(call api.CallContext, x, y int) {}
+
+func mixedOnEnter(call api.CallContext, r io.Reader, m map[string][]byte,
+	f func() func() error) {
+}
+
+func mixedOnExit(call api.CallContext, fn func(int) int, err error) {}
+
+func boxOnEnter(call api.CallContext, v interface{}, w any,
+	s ...fmt.Stringer) {
+}
+
+func boxOnExit(call api.CallContext, ret interface{}) {}
+
+func collideOnEnter(call api.CallContext, s string, n int) {}
+
+func collideOnExit(call api.CallContext, n int, err error) {}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shapes
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type Item struct {
+	Name string
+	Size int
+}
+
+type Store struct {
+	items []Item
+}
+
+// Variadic of interfaces
+func Printf(format string, args ...interface{}) (n int, err error) {
+	return fmt.Printf(format, args...)
+}
+
+// Variadic of any, unnamed result
+func Join(sep string, parts ...any) string {
+	return fmt.Sprint(parts...) + sep
+}
+
+// Func-typed parameters
+func Walk(root string, fn func(path string, err error) error,
+	filter func(string) bool) error {
+	if filter(root) {
+		return fn(root, nil)
+	}
+	return nil
+}
+
+// Channel directions
+func Pipe(in <-chan int, out chan<- string, done chan struct{}) int {
+	n := 0
+	for v := range in {
+		out <- fmt.Sprint(v)
+		n++
+	}
+	close(done)
+	return n
+}
+
+// Arrays of structs
+func Sum(items [4]Item, pairs []struct{ A, B int }) (total int) {
+	for _, item := range items {
+		total += item.Size
+	}
+	for _, p := range pairs {
+		total += p.A + p.B
+	}
+	return
+}
+
+// Unnamed parameters and result
+func Discard(int, string) bool {
+	return true
+}
+
+// Blank parameters and result
+func Blank(_ context.Context, _ int, name string) (_ string, err error) {
+	if name == "" {
+		err = fmt.Errorf("empty name")
+	}
+	return
+}
+
+// Unnamed receiver
+func (Store) Len() int {
+	return 0
+}
+
+// Blank receiver
+func (_ *Store) Reset() {
+}
+
+// Named results shadowed in body
+func Shadow(n int) (res int, err error) {
+	if n > 0 {
+		res, err := n*2, error(nil)
+		return res, err
+	}
+	return
+}
+
+// Grouped parameters and results
+func Grouped(a, b int, c, d string) (x, y int) {
+	return a + len(c), b + len(d)
+}
+
+// Interface, map and higher-order function types
+func Mixed(r io.Reader, m map[string][]byte,
+	f func() func() error) (func(int) int, error) {
+	return func(i int) int { return i }, f()()
+}
+
+// Interface parameters and result
+func Box(v interface{}, w any, s ...fmt.Stringer) interface{} {
+	return v
+}
+
+// Generated names collide with identifiers in the body
+func Collide(param0 string, _ int) (int, error) {
+	retVal0 := len(param0)
+	return retVal0, nil
+}
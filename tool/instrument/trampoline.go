@@ -179,7 +179,7 @@ func findHookFile(rule *resource.InstFuncRule) (string, error) {
 }
 
 func findRuleFiles(rule resource.InstRule) ([]string, error) {
-	files, err := util.ListFiles(rule.GetPath())
+	files, err := resource.ListHookFiles(rule.GetPath())
 	if err != nil {
 		return nil, err
 	}
@@ -225,16 +225,20 @@ func getHookParamTraits(t *resource.InstFuncRule, onEnter bool) ([]ParamTrait, e
 		return nil, err
 	}
 	var attrs []ParamTrait
-	// Find which parameter is type of interface{}
-	for i, field := range target.Type.Params.List {
-		attr := ParamTrait{Index: i}
-		if util.IsInterfaceType(field.Type) {
-			attr.IsInterfaceAny = true
-		}
-		if util.IsEllipsis(field.Type) {
-			attr.IsVaradic = true
+	// Find which parameter is type of interface{}, parameters declared in the
+	// syntax of n1,n2 type have their own traits
+	for _, field := range target.Type.Params.List {
+		n := max(len(field.Names), 1)
+		for range n {
+			attr := ParamTrait{Index: len(attrs)}
+			if util.IsInterfaceType(field.Type) || util.IsAnyType(field.Type) {
+				attr.IsInterfaceAny = true
+			}
+			if util.IsEllipsis(field.Type) {
+				attr.IsVaradic = true
+			}
+			attrs = append(attrs, attr)
 		}
-		attrs = append(attrs, attr)
 	}
 	return attrs, nil
 }
@@ -243,16 +247,18 @@ func (rp *RuleProcessor) callOnEnterHook(t *resource.InstFuncRule, traits []Para
 	// The actual parameter list of hook function should be the same as the
 	// target function
 	if rp.exact {
-		util.Assert(len(traits) == (len(rp.onEnterHookFunc.Type.Params.List)+1),
+		util.Assert(len(traits) == len(getNames(rp.onEnterHookFunc.Type.Params))+1,
 			"do you miss api.CallContext parameter?")
 	}
 	// Hook: 	   func onEnterFoo(callContext* CallContext, p*[]int)
 	// Trampoline: func OtelOnEnterTrampoline_foo(p *[]int)
 	args := []dst.Expr{dst.NewIdent(TrampolineCallContextName)}
 	if rp.exact {
-		for idx, field := range rp.onEnterHookFunc.Type.Params.List {
-			trait := traits[idx+1 /*CallContext*/]
+		idx := 1 /*CallContext*/
+		for _, field := range rp.onEnterHookFunc.Type.Params.List {
 			for _, name := range field.Names { // syntax of n1,n2 type
+				trait := traits[idx]
+				idx++
 				if trait.IsVaradic {
 					args = append(args, util.DereferenceOf(util.Ident(name.Name+"...")))
 				} else {
@@ -276,23 +282,21 @@ func (rp *RuleProcessor) callOnExitHook(t *resource.InstFuncRule, traits []Param
 	// The actual parameter list of hook function should be the same as the
 	// target function
 	if rp.exact {
-		util.Assert(len(traits) == len(rp.onExitHookFunc.Type.Params.List),
+		util.Assert(len(traits) == len(getNames(rp.onExitHookFunc.Type.Params)),
 			"do you miss api.CallContext parameter?")
 	}
 	// Hook: 	   func onExitFoo(ctx* CallContext, p*[]int)
 	// Trampoline: func OtelOnExitTrampoline_foo(ctx* CallContext, p *[]int)
-	var args []dst.Expr
-	for idx, field := range rp.onExitHookFunc.Type.Params.List {
-		if idx == 0 {
-			args = append(args, dst.NewIdent(TrampolineCallContextName))
-			if !rp.exact {
-				// Generic hook function, no need to process parameters
-				break
-			}
+	args := []dst.Expr{dst.NewIdent(TrampolineCallContextName)}
+	idx := 1 /*CallContext*/
+	for i, field := range rp.onExitHookFunc.Type.Params.List {
+		if i == 0 || !rp.exact {
+			// Generic hook function, no need to process parameters
 			continue
 		}
-		trait := traits[idx]
 		for _, name := range field.Names { // syntax of n1,n2 type
+			trait := traits[idx]
+			idx++
 			if trait.IsVaradic {
 				arg := util.DereferenceOf(util.Ident(name.Name + "..."))
 				args = append(args, arg)
@@ -313,7 +317,27 @@ func (rp *RuleProcessor) callOnExitHook(t *resource.InstFuncRule, traits []Param
 	return nil
 }
 
+// splitFields splits fields in the syntax of n1,n2 type into one field for each
+// name, so that they can be rectified separately
+func splitFields(paramList *dst.FieldList) {
+	fields := make([]*dst.Field, 0, len(paramList.List))
+	for _, field := range paramList.List {
+		if len(field.Names) <= 1 {
+			fields = append(fields, field)
+			continue
+		}
+		for _, name := range field.Names {
+			fields = append(fields, &dst.Field{
+				Names: []*dst.Ident{name},
+				Type:  dst.Clone(field.Type).(dst.Expr),
+			})
+		}
+	}
+	paramList.List = fields
+}
+
 func rectifyAnyType(paramList *dst.FieldList, traits []ParamTrait) error {
+	splitFields(paramList)
 	if len(paramList.List) != len(traits) {
 		return errc.New(errc.ErrInternal, "do you miss api.CallContext parameter?")
 	}
@@ -341,6 +365,14 @@ func (rp *RuleProcessor) addHookFuncVar(t *resource.InstFuncRule,
 		if err != nil {
 			return err
 		}
+		// Hooks are not generic, values of type parameters are boxed
+		for i, field := range paramTypes.List {
+			if refersTypeParam(field.Type, rp.typeParams) {
+				return errc.New(errc.ErrInstrument,
+					fmt.Sprintf("parameter %d of hook %s must be interface{}",
+						i, makeOnXName(t, onEnter)))
+			}
+		}
 	}
 
 	// Generate var decl and append it to the target file, note that many target
@@ -366,8 +398,21 @@ func (rp *RuleProcessor) addHookFuncVar(t *resource.InstFuncRule,
 			}
 		}
 	}
+	// Pattern rules may match functions across files in the same package,
+	// the hook function should be declared only once in the package
+	if rp.hookDecls[fnName] {
+		exist = true
+	}
 	if !exist {
+		// Hooks of call rules are not pushed to the target package, as it's
+		// unknown which packages call the matched function, pull them instead
+		if path, ok := rp.callHooks[t]; ok {
+			funcDecl.Decs.Before = dst.NewLine
+			funcDecl.Decs.Start.Append(
+				fmt.Sprintf("//go:linkname %s %s.%s", fnName, path, fnName))
+		}
 		rp.addDecl(funcDecl)
+		rp.hookDecls[fnName] = true
 	}
 	return nil
 }
@@ -440,6 +485,10 @@ func (rp *RuleProcessor) rectifyTypes() {
 	onEnterHookFunc, onExitHookFunc := rp.onEnterHookFunc, rp.onExitHookFunc
 	onEnterHookFunc.Type.Params = rp.buildTrampolineType(true)
 	onExitHookFunc.Type.Params = rp.buildTrampolineType(false)
+	if rp.typeParams != nil {
+		onEnterHookFunc.Type.TypeParams = dst.Clone(rp.typeParams).(*dst.FieldList)
+		onExitHookFunc.Type.TypeParams = dst.Clone(rp.typeParams).(*dst.FieldList)
+	}
 	candidate := []*dst.FieldList{
 		onEnterHookFunc.Type.Params,
 		onExitHookFunc.Type.Params,
@@ -474,6 +523,9 @@ func (rp *RuleProcessor) replenishCallContext(onEnter bool) bool {
 						if basicLit, ok := rhsExpr.(*dst.BasicLit); ok {
 							if basicLit.Kind == token.STRING {
 								rawFuncName := rp.rawFunc.Name.Name
+								if c, ok := rp.callees[rawFuncName]; ok {
+									rawFuncName = c.funcName
+								}
 								basicLit.Value = strconv.Quote(rawFuncName)
 							} else {
 								return false // ill-formed AST
@@ -493,6 +545,9 @@ func (rp *RuleProcessor) replenishCallContext(onEnter bool) bool {
 						if basicLit, ok := rhsExpr.(*dst.BasicLit); ok {
 							if basicLit.Kind == token.STRING {
 								pkgName := rp.target.Name.Name
+								if c, ok := rp.callees[rp.rawFunc.Name.Name]; ok {
+									pkgName = c.pkgName
+								}
 								basicLit.Value = strconv.Quote(pkgName)
 							} else {
 								return false // ill-formed AST
@@ -568,11 +623,13 @@ func (rp *RuleProcessor) implementCallContext(t *resource.InstFuncRule) {
 			return true
 		})
 	}
+	rp.genericCallContextImpl(TrampolineCallContextImplType + suffix)
 }
 
 func setValue(field string, idx int, typ dst.Expr) *dst.CaseClause {
 	// *(c.Params[idx].(*int)) = val.(int)
-	// c.Params[idx] = val iff type is interface{}
+	// Params always hold pointers to the parameters, including the ones of
+	// interface type, so that the parameter itself is updated
 	se := util.SelectorExpr(util.Ident(TrampolineCtxIdentifier), field)
 	ie := util.IndexExpr(se, util.IntLit(idx))
 	te := util.TypeAssertExpr(ie, util.DereferenceOf(typ))
@@ -580,9 +637,6 @@ func setValue(field string, idx int, typ dst.Expr) *dst.CaseClause {
 	de := util.DereferenceOf(pe)
 	val := util.Ident(TrampolineValIdentifier)
 	assign := util.AssignStmt(de, util.TypeAssertExpr(val, typ))
-	if util.IsInterfaceType(typ) {
-		assign = util.AssignStmt(ie, val)
-	}
 	caseClause := util.SwitchCase(
 		util.Exprs(util.IntLit(idx)),
 		util.Stmts(assign),
@@ -592,16 +646,12 @@ func setValue(field string, idx int, typ dst.Expr) *dst.CaseClause {
 
 func getValue(field string, idx int, typ dst.Expr) *dst.CaseClause {
 	// return *(c.Params[idx].(*int))
-	// return c.Params[idx] iff type is interface{}
 	se := util.SelectorExpr(util.Ident(TrampolineCtxIdentifier), field)
 	ie := util.IndexExpr(se, util.IntLit(idx))
 	te := util.TypeAssertExpr(ie, util.DereferenceOf(typ))
 	pe := util.ParenExpr(te)
 	de := util.DereferenceOf(pe)
 	ret := util.ReturnStmt(util.Exprs(de))
-	if util.IsInterfaceType(typ) {
-		ret = util.ReturnStmt(util.Exprs(ie))
-	}
 	caseClause := util.SwitchCase(
 		util.Exprs(util.IntLit(idx)),
 		util.Stmts(ret),
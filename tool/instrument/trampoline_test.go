@@ -0,0 +1,224 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Absolute path of testdata, as tests run in a temporary directory
+var testdata string
+
+// normalizeSuffixes replaces random suffixes of rules with stable ones in the
+// order they first appear in the text
+func normalizeSuffixes(text string, rp *RuleProcessor) string {
+	suffixes := make([]string, 0, len(rp.rule2Suffix))
+	for _, suffix := range rp.rule2Suffix {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return strings.Index(text, suffixes[i]) < strings.Index(text, suffixes[j])
+	})
+	for i, suffix := range suffixes {
+		text = strings.ReplaceAll(text, suffix, fmt.Sprintf("_%d_", i))
+	}
+	return text
+}
+
+// checkGolden compares the text with the golden file, or updates the golden
+// file if -update is given
+func checkGolden(t *testing.T, golden, text string) {
+	t.Helper()
+	if *update {
+		err := os.WriteFile(golden, []byte(text), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expect, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expect) != text {
+		t.Errorf("%s mismatch, run go test -update to see the diff:\n%s",
+			golden, text)
+	}
+}
+
+// typeCheckFiles type checks the instrumented package, hooks are declared
+// without body so they are not resolved
+func typeCheckFiles(t *testing.T, files []string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	roots := make([]*ast.File, 0, len(files))
+	for _, file := range files {
+		root, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err := conf.Check(roots[0].Name.Name, fset, roots, nil)
+	if err != nil {
+		t.Fatalf("instrumented code does not compile: %v", err)
+	}
+}
+
+// TestMain runs tests in a temporary directory as the instrument phase does,
+// so that debug files of instrumentation do not pollute the source tree
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(func() int {
+		wd, err := os.Getwd()
+		if err != nil {
+			panic(err)
+		}
+		testdata = filepath.Join(wd, "testdata")
+		tmp, err := os.MkdirTemp("", "otel-instrument")
+		if err != nil {
+			panic(err)
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+		err = os.Chdir(tmp)
+		if err != nil {
+			panic(err)
+		}
+		defer func() { _ = os.Chdir(wd) }()
+		util.SetRunPhase(util.PInstrument)
+		err = config.InitConfig()
+		if err != nil {
+			panic(err)
+		}
+		return m.Run()
+	}())
+}
+
+// shapeDecls extracts the instrumented function and declarations generated for
+// it from the rewritten source, so that every shape has its own golden file
+func shapeDecls(t *testing.T, source, text, function string) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	orig, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Declarations are generated after the original ones
+	if len(root.Decls) <= len(orig.Decls) {
+		t.Fatalf("nothing generated for %s", function)
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var decl string
+	for _, d := range root.Decls[:len(orig.Decls)] {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Name.Name == function {
+			decl = text[offset(fn.Doc.Pos()):offset(fn.End())]
+		}
+	}
+	if decl == "" {
+		t.Fatalf("function %s not found", function)
+	}
+	generated := text[offset(root.Decls[len(orig.Decls)-1].End()):]
+	return decl + generated
+}
+
+func TestTrampolineShapes(t *testing.T) {
+	dir := filepath.Join(testdata, "shapes")
+	source := filepath.Join(dir, "shapes.go")
+
+	rules := []struct {
+		function     string
+		receiverType string
+		hook         string
+	}{
+		{"Printf", "", "printf"},
+		{"Join", "", "join"},
+		{"Walk", "", "walk"},
+		{"Pipe", "", "pipe"},
+		{"Sum", "", "sum"},
+		{"Discard", "", "discard"},
+		{"Blank", "", "blank"},
+		{"Len", "Store", "len"},
+		{"Reset", "*Store", "reset"},
+		{"Shadow", "", "shadow"},
+		{"Grouped", "", "grouped"},
+		{"Mixed", "", "mixed"},
+		{"Box", "", "box"},
+		{"Collide", "", "collide"},
+	}
+	for _, r := range rules {
+		t.Run(r.function, func(t *testing.T) {
+			rule := &resource.InstFuncRule{
+				InstBaseRule: resource.InstBaseRule{
+					Path:       filepath.Join(dir, "hook"),
+					ImportPath: "shapes",
+					RuleFile:   "shapes.json",
+				},
+				Function:     r.function,
+				ReceiverType: r.receiverType,
+				OnEnter:      r.hook + "OnEnter",
+				OnExit:       r.hook + "OnExit",
+			}
+			bundle := resource.NewRuleBundle("shapes")
+			bundle.SetPackageName("shapes")
+			err := bundle.AddFile2FuncRule(source, rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			workDir := t.TempDir()
+			rp := newRuleProcessorAt([]string{source}, "shapes", workDir)
+			err = rp.applyRules(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text, err := os.ReadFile(filepath.Join(workDir, "shapes.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decls := shapeDecls(t, source, string(text), r.function)
+			golden := filepath.Join(dir, r.hook+".golden")
+			checkGolden(t, golden, normalizeSuffixes(decls, rp))
+			text, err = os.ReadFile(filepath.Join(workDir, OtelTrampolineFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden = filepath.Join(dir, OtelTrampolineFile+".golden")
+			checkGolden(t, golden, normalizeSuffixes(string(text), rp))
+			typeCheckFiles(t, rp.compileArgs)
+		})
+	}
+}
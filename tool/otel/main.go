@@ -15,10 +15,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
@@ -28,10 +32,13 @@ import (
 )
 
 const (
-	SubcommandSet     = "set"
-	SubcommandGo      = "go"
-	SubcommandVersion = "version"
-	SubcommandRemix   = "remix"
+	SubcommandSet      = "set"
+	SubcommandGo       = "go"
+	SubcommandVersion  = "version"
+	SubcommandRemix    = "remix"
+	SubcommandRules    = "rules"
+	SubcommandVendor   = "vendor"
+	SubcommandToolexec = "toolexec"
 )
 
 var usage = `Usage: {} <command> [args]
@@ -39,13 +46,22 @@ Example:
 	{} go build
 	{} go install
 	{} go build main.go
+	{} go test -run TestFoo ./...
 	{} version
 	{} set -verbose -rule=custom.json
+	{} rules list
+	{} rules explain ./cmd/app
+	{} rules validate custom.json
+	{} vendor
+	{} toolexec -importpath=fmt -o=out print.go
 
 Command:
 	version    print the version
 	set        set the configuration
-	go         build the Go application
+	go         build or test the Go application
+	rules      list, explain or validate rules
+	vendor     vendor dependencies of instrumentation for offline builds
+	toolexec   instrument a package for build systems that compile it themselves
 `
 
 func printUsage() {
@@ -54,6 +70,13 @@ func printUsage() {
 	fmt.Print(usage)
 }
 
+// staleBuildDir is how long a build directory is kept if the build that owns
+// it was killed before cleaning up
+const staleBuildDir = 24 * time.Hour
+
+// buildDir is the private directory created by this process, if any
+var buildDir string
+
 func initTempDir() error {
 	// All temp directories are prepared before, instrument phase should not
 	// create any new directories.
@@ -61,24 +84,91 @@ func initTempDir() error {
 		return nil
 	}
 
+	// Toolexec is invoked once per package by the build system, possibly many
+	// at the same time and in a shared working directory, it never touches
+	// the temp build directory, but works in a private directory elsewhere
+	toolexec := os.Args[1] == SubcommandToolexec
+
 	// Make temp build directory if not exists
-	if util.PathNotExists(util.TempBuildDir) {
+	if !toolexec && util.PathNotExists(util.TempBuildDir) {
 		err := os.MkdirAll(util.TempBuildDir, 0777)
 		if err != nil {
 			return errc.New(errc.ErrMkdirAll, err.Error())
 		}
 	}
+	if !util.InPreprocess() {
+		return nil
+	}
+	root := util.TempBuildDir
+	if toolexec {
+		root = os.TempDir()
+	} else {
+		sweepBuildDirs()
+	}
+
+	// Every build works in its own directory, so that concurrent builds from
+	// the same directory never overwrite files of each other. The instrument
+	// phase finds it by the environment variable inherited from us
+	dir, err := os.MkdirTemp(root, "build-")
+	if err != nil {
+		return errc.New(errc.ErrMkdirAll, err.Error())
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return errc.New(errc.ErrAbsPath, err.Error())
+	}
+	err = os.Setenv(util.BuildDirEnv, dir)
+	if err != nil {
+		return errc.New(errc.ErrInternal, err.Error())
+	}
+	buildDir = dir
 	for _, subdir := range []string{util.PPreprocess, util.PInstrument} {
-		_ = os.RemoveAll(util.GetTempBuildDirWith(subdir))
-		err := os.MkdirAll(util.GetTempBuildDirWith(subdir), 0777)
+		err = os.MkdirAll(util.GetBuildDirWith(subdir), 0777)
 		if err != nil {
 			return errc.New(errc.ErrMkdirAll, err.Error())
 		}
 	}
-
 	return nil
 }
 
+// sweepBuildDirs removes build directories left by killed builds
+func sweepBuildDirs() {
+	dirs, _ := filepath.Glob(filepath.Join(util.TempBuildDir, "build-*"))
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err == nil && time.Since(info.ModTime()) > staleBuildDir {
+			_ = os.RemoveAll(dir)
+		}
+	}
+}
+
+// publishBuildDir moves files of the build to where they are expected to be
+// inspected, i.e. .otel-build/preprocess, .otel-build/instrument and
+// .otel-build/debug.log, replacing those of the previous build. It's done on a
+// best-effort basis, as another build may be publishing at the same time.
+func publishBuildDir() {
+	if buildDir == "" {
+		return
+	}
+	if os.Args[1] == SubcommandToolexec {
+		// Nothing is published by toolexec, see initTempDir
+		_ = os.RemoveAll(buildDir)
+		buildDir = ""
+		return
+	}
+	for _, name := range []string{util.PPreprocess, util.PInstrument,
+		util.DebugLogFile} {
+		target := util.GetTempBuildDirWith(name)
+		_ = os.RemoveAll(target)
+		err := os.Rename(filepath.Join(buildDir, name), target)
+		if err != nil {
+			util.Log("Failed to publish %s: %v", name, err)
+		}
+	}
+	_ = os.RemoveAll(buildDir)
+	buildDir = ""
+}
+
 func initEnv() error {
 	util.Assert(len(os.Args) >= 2, "no command specified")
 
@@ -90,6 +180,15 @@ func initEnv() error {
 	case os.Args[1] == SubcommandRemix:
 		// otel remix?
 		util.SetRunPhase(util.PInstrument)
+	case os.Args[1] == SubcommandRules:
+		// otel rules? It matches rules just like preprocess does
+		util.SetRunPhase(util.PPreprocess)
+	case os.Args[1] == SubcommandVendor:
+		// otel vendor? It prepares dependencies just like preprocess does
+		util.SetRunPhase(util.PPreprocess)
+	case os.Args[1] == SubcommandToolexec:
+		// otel toolexec? It matches rules just like preprocess does
+		util.SetRunPhase(util.PPreprocess)
 	default:
 		// do nothing
 	}
@@ -111,9 +210,16 @@ func initEnv() error {
 }
 
 func fatal(err error) {
+	logPath := util.GetLoggerPath()
+	// The private directory of toolexec is kept for the error log, as it's
+	// not published anyway
+	if buildDir != "" && os.Args[1] != SubcommandToolexec {
+		publishBuildDir()
+		logPath, _ = filepath.Abs(util.GetTempBuildDirWith(util.DebugLogFile))
+	}
 	message := "===== Environments =====\n"
 	message += fmt.Sprintf("%-11s: %s\n", "Command", strings.Join(os.Args, " "))
-	message += fmt.Sprintf("%-11s: %s\n", "ErrorLog", util.GetLoggerPath())
+	message += fmt.Sprintf("%-11s: %s\n", "ErrorLog", logPath)
 	message += fmt.Sprintf("%-11s: %s\n", "WorkDir", os.Getenv("PWD"))
 	message += fmt.Sprintf("%-11s: %s, %s, %s\n", "Toolchain",
 		runtime.GOOS+"/"+runtime.GOARCH,
@@ -144,10 +250,26 @@ func main() {
 		err = preprocess.Preprocess()
 	case SubcommandRemix:
 		err = instrument.Instrument()
+	case SubcommandRules:
+		err = preprocess.Rules()
+	case SubcommandVendor:
+		err = preprocess.Vendor()
+	case SubcommandToolexec:
+		err = preprocess.Toolexec()
 	default:
 		printUsage()
 	}
+	if err == nil {
+		publishBuildDir()
+	}
 	if err != nil {
+		// The instrumented tests ran but failed, the go command has already
+		// reported why, exit with its status rather than a fatal error
+		var exitErr *exec.ExitError
+		if subcmd == SubcommandGo && errors.As(err, &exitErr) {
+			publishBuildDir()
+			os.Exit(exitErr.ExitCode())
+		}
 		if subcmd != SubcommandRemix {
 			fatal(err)
 		} else {
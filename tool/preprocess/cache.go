@@ -0,0 +1,254 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Build Cache
+//
+// The go build cache is content-addressed, compile outputs are keyed by the
+// package inputs together with the ID of the compiler, which is obtained by
+// running "compile -V=full" through toolexec. The instrument phase appends the
+// tool version to the compiler ID, so that outputs of other tool versions are
+// never reused. Rules applied to a package are not visible to the go command,
+// so the hash of the rules matched with every instrumented package, together
+// with hook code they refer to, is passed to the package as a compiler flag,
+// which is part of the package inputs, and the instrument phase strips it
+// before the compiler sees it. Changing the rules of a package rebuilds only
+// itself, and packages that are no longer instrumented go back to their
+// original inputs. The pkg module is extracted to a stable location for the
+// same reason, see findModCacheDir.
+//
+// Compiler flags are given per package by -gcflags=pattern=flags, where the
+// latest match on the command line wins, so the flag of the rule set repeats
+// the -gcflags in effect for the package. We take over -gcflags of the build,
+// and find which of them is in effect for every package by marking them in the
+// dry build.
+
+const (
+	goFlagGcflags = "gcflags"
+	// Marks the -gcflags in effect for the package in the dry build
+	gcflagsMark = "-otel.gcflags="
+)
+
+// initGcflags takes over -gcflags of the build in the order the go command sees
+// them, i.e. ones from GOFLAGS, the debug mode, and the command line
+func (dp *DepProcessor) initGcflags() {
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		name, value, ok := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if ok && name == goFlagGcflags {
+			dp.gcflags = append(dp.gcflags, value)
+		}
+	}
+	if config.GetConf().Debug {
+		// Disable compiler optimizations for debugging mode
+		dp.gcflags = append(dp.gcflags, "all=-N -l")
+	}
+	var userFlags []string
+	dp.goBuildCmd, userFlags = stripGoFlags(dp.goBuildCmd, goFlagGcflags)
+	dp.gcflags = append(dp.gcflags, userFlags...)
+}
+
+// splitGcflags splits the value of -gcflags into the package pattern and the
+// flags, the pattern is empty if the flags apply to packages named on the
+// command line. It returns false if the value is malformed.
+func splitGcflags(value string) (string, string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "-") {
+		return "", value, true
+	}
+	pattern, flags, ok := strings.Cut(value, "=")
+	return strings.TrimSpace(pattern), flags, ok
+}
+
+// getGcflags returns -gcflags of the build, followed by the flag of the rule
+// set for every instrumented package
+func (dp *DepProcessor) getGcflags() []string {
+	flags := make([]string, 0, len(dp.gcflags)+len(dp.ruleSetFlags))
+	for _, value := range dp.gcflags {
+		flags = append(flags, "-"+goFlagGcflags+"="+value)
+	}
+	return append(flags, dp.ruleSetFlags...)
+}
+
+// getMarkedGcflags returns -gcflags of the build for the dry build, where each
+// of them is marked with its index, so that the one in effect for a package is
+// found from its compile command
+func (dp *DepProcessor) getMarkedGcflags() []string {
+	flags := make([]string, 0, len(dp.gcflags))
+	for i, value := range dp.gcflags {
+		pattern, rest, ok := splitGcflags(value)
+		if ok {
+			value = fmt.Sprintf("%s%d", gcflagsMark, i)
+			if rest != "" {
+				value += " " + rest
+			}
+			if pattern != "" {
+				value = pattern + "=" + value
+			}
+		}
+		flags = append(flags, "-"+goFlagGcflags+"="+value)
+	}
+	return flags
+}
+
+// findGcflagsMark finds the index of -gcflags in effect for the package from
+// its compile command in the dry build, or -1 if there is none
+func findGcflagsMark(cmdArgs []string) int {
+	mark := -1
+	for _, arg := range cmdArgs {
+		if !strings.HasPrefix(arg, gcflagsMark) {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimPrefix(arg, gcflagsMark))
+		if err == nil {
+			mark = i
+		}
+	}
+	return mark
+}
+
+// writeRuleSetFlags computes the hash of rules matched with every instrumented
+// package, and passes it to the package along with the -gcflags in effect
+func (dp *DepProcessor) writeRuleSetFlags(bundles []*resource.RuleBundle) error {
+	flags := make(map[string]string)
+	for _, bundle := range bundles {
+		hash, err := hashRuleBundle(bundle)
+		if err != nil {
+			return err
+		}
+		util.Log("Rule set hash of %s: %s", bundle.ImportPath, hash)
+		for pattern, mark := range dp.gcflagsMarks[bundle.ImportPath] {
+			value := resource.RuleSetFlag + hash
+			if mark >= 0 && mark < len(dp.gcflags) {
+				_, rest, _ := splitGcflags(dp.gcflags[mark])
+				if rest != "" {
+					value = rest + " " + value
+				}
+			}
+			flags[pattern] = "-" + goFlagGcflags + "=" + pattern + "=" + value
+		}
+	}
+	patterns := make([]string, 0, len(flags))
+	for pattern := range flags {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	dp.ruleSetFlags = make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		dp.ruleSetFlags = append(dp.ruleSetFlags, flags[pattern])
+	}
+	return nil
+}
+
+// hashRuleBundle computes the hash of rules matched with the package, together
+// with hook code and files to be added, they are read by the instrument phase
+// rather than compiled as dependencies
+func hashRuleBundle(bundle *resource.RuleBundle) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, config.ToolVersion+"\n")
+	bs, err := json.Marshal(bundle)
+	if err != nil {
+		return "", errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	_, _ = h.Write(bs)
+	if config.GetConf().Debug {
+		_, _ = io.WriteString(h, "debug\n")
+	}
+	paths := make(map[string]bool)
+	for _, rule := range bundle.FileRules {
+		paths[rule.Path] = true
+	}
+	for _, funcRules := range bundle.File2FuncRules {
+		for _, rules := range funcRules {
+			for _, rule := range rules {
+				paths[rule.Path] = true
+			}
+		}
+	}
+	for _, rule := range bundle.CallRules {
+		paths[rule.Path] = true
+	}
+	sortedPaths := make([]string, 0, len(paths))
+	for path := range paths {
+		if path != "" {
+			sortedPaths = append(sortedPaths, path)
+		}
+	}
+	sort.Strings(sortedPaths)
+	for _, path := range sortedPaths {
+		// Hook packages as the go command sees them, generated hooks of span
+		// rules are placed via overlay
+		files, err := resource.ListHookFiles(path)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			err = hashFile(h, file)
+			if err != nil {
+				return "", err
+			}
+			_, _ = io.WriteString(h, "\n")
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errc.New(errc.ErrOpenFile, err.Error())
+	}
+	defer func() { _ = file.Close() }()
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return errc.New(errc.ErrOpenFile, err.Error())
+	}
+	return nil
+}
+
+// getGoCache returns the GOCACHE used by the instrumented build, it's either
+// the persistent build cache or an isolated temporary one if the cache is off
+func getGoCache() (string, error) {
+	conf := config.GetConf()
+	if conf.IsCacheOff() {
+		return getTempGoCache()
+	}
+	goCachePath, err := conf.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(goCachePath, 0755)
+	if err != nil {
+		return "", errc.New(errc.ErrMkdirAll, err.Error())
+	}
+	return goCachePath, nil
+}
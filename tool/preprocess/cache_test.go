@@ -0,0 +1,139 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitGcflags(t *testing.T) {
+	tests := []struct {
+		value   string
+		pattern string
+		flags   string
+		ok      bool
+	}{
+		{"", "", "", true},
+		{"-N -l", "", "-N -l", true},
+		{" -m", "", "-m", true},
+		{"all=-N -l", "all", "-N -l", true},
+		{"std=", "std", "", true},
+		{"./cmd/...=-m=2", "./cmd/...", "-m=2", true},
+		{"all", "all", "", false},
+	}
+	for _, tt := range tests {
+		pattern, flags, ok := splitGcflags(tt.value)
+		if pattern != tt.pattern || flags != tt.flags || ok != tt.ok {
+			t.Errorf("%q: expect (%q, %q, %v), got (%q, %q, %v)", tt.value,
+				tt.pattern, tt.flags, tt.ok, pattern, flags, ok)
+		}
+	}
+}
+
+func TestGetMarkedGcflags(t *testing.T) {
+	dp := &DepProcessor{gcflags: []string{"-m", "all=-N -l", "std=", "all"}}
+	expect := []string{
+		"-gcflags=-otel.gcflags=0 -m",
+		"-gcflags=all=-otel.gcflags=1 -N -l",
+		"-gcflags=std=-otel.gcflags=2",
+		// Malformed values are left to the go command to complain about
+		"-gcflags=all",
+	}
+	if actual := dp.getMarkedGcflags(); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect %q, got %q", expect, actual)
+	}
+}
+
+func TestGetGcflags(t *testing.T) {
+	dp := &DepProcessor{
+		gcflags:      []string{"all=-N -l"},
+		ruleSetFlags: []string{"-gcflags=net/http=-N -l -otel.ruleset=abc"},
+	}
+	expect := []string{
+		"-gcflags=all=-N -l",
+		"-gcflags=net/http=-N -l -otel.ruleset=abc",
+	}
+	if actual := dp.getGcflags(); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect %q, got %q", expect, actual)
+	}
+}
+
+func TestFindGcflagsMark(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdArgs []string
+		mark    int
+	}{
+		{"no mark", []string{"compile", "-o", "a.o", "-p", "main"}, -1},
+		{"mark", []string{"compile", "-otel.gcflags=1", "-N", "-l", "a.go"}, 1},
+		{"latest mark wins", []string{"compile", "-otel.gcflags=0",
+			"-otel.gcflags=2", "a.go"}, 2},
+		{"bad mark", []string{"compile", "-otel.gcflags=x", "a.go"}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if mark := findGcflagsMark(tt.cmdArgs); mark != tt.mark {
+				t.Errorf("expect %d, got %d", tt.mark, mark)
+			}
+		})
+	}
+}
+
+func TestStripGoFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      []string
+		stripped []string
+		values   []string
+	}{
+		{
+			name:     "no flag",
+			cmd:      []string{"go", "build", "-o", "app", "."},
+			stripped: []string{"go", "build", "-o", "app", "."},
+			values:   []string{},
+		},
+		{
+			name: "flags in both forms",
+			cmd: []string{"go", "build", "-gcflags=all=-N -l", "-o", "app",
+				"--gcflags", "-m", "."},
+			stripped: []string{"go", "build", "-o", "app", "."},
+			values:   []string{"all=-N -l", "-m"},
+		},
+		{
+			name:     "other flags with the same prefix",
+			cmd:      []string{"go", "build", "-gcflagsx=1", "."},
+			stripped: []string{"go", "build", "-gcflagsx=1", "."},
+			values:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, values := stripGoFlags(tt.cmd, goFlagGcflags)
+			if !reflect.DeepEqual(stripped, tt.stripped) {
+				t.Errorf("expect %q, got %q", tt.stripped, stripped)
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("expect values %q, got %q", tt.values, values)
+			}
+		})
+	}
+	// The latest value wins for flags that are given once
+	_, value := stripGoFlag([]string{"go", "build", "-modfile=a.mod",
+		"-modfile", "b.mod"}, "modfile")
+	if value != "b.mod" {
+		t.Errorf("expect b.mod, got %s", value)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Rule Conflict
+//
+// Rules from different rule files know nothing about each other. Multiple func
+// rules targeting the same function are chained by their Order, which is fine
+// as long as rule authors are aware of it, but a custom rule that accidentally
+// hooks a function instrumented by a default rule is hard to notice. Worse, two
+// file rules replacing the same file, or two struct rules adding the same field,
+// can never be applied together. Such conflicts are detected once rules are
+// matched, and reported as warnings or errors depending on the configuration.
+
+type RuleConflict struct {
+	ImportPath string
+	// What the conflicting rules target, e.g. "func (*Engine).ServeHTTP"
+	Target string
+	// Conflicting rules, func rules are listed in the order they are applied
+	Rules []resource.InstRule
+	// Why the rules conflict with each other
+	Reason string
+}
+
+func (c *RuleConflict) String() string {
+	files := make([]string, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		file := rule.GetRuleFile()
+		if r, ok := rule.(*resource.InstFuncRule); ok {
+			file += fmt.Sprintf(" (Order %d)", r.Order)
+		}
+		files = append(files, file)
+	}
+	return fmt.Sprintf("%s: %s %s: %s", c.ImportPath, c.Target, c.Reason,
+		strings.Join(files, ", "))
+}
+
+// ruleFilesOf returns distinct rule files where the rules are defined
+func ruleFilesOf[T resource.InstRule](rules []T) []string {
+	files := make([]string, 0)
+	for _, rule := range rules {
+		if !slices.Contains(files, rule.GetRuleFile()) {
+			files = append(files, rule.GetRuleFile())
+		}
+	}
+	return files
+}
+
+func toInstRules[T resource.InstRule](rules []T) []resource.InstRule {
+	res := make([]resource.InstRule, 0, len(rules))
+	for _, rule := range rules {
+		res = append(res, rule)
+	}
+	return res
+}
+
+func findBundleConflicts(bundle *resource.RuleBundle) []*RuleConflict {
+	conflicts := make([]*RuleConflict, 0)
+	// Func rules targeting the same function are applied in the ascending
+	// order of Order, it's a conflict if they come from different rule files
+	for _, funcRules := range bundle.File2FuncRules {
+		for _, rules := range funcRules {
+			if len(ruleFilesOf(rules)) < 2 {
+				continue
+			}
+			ordered := make([]*resource.InstFuncRule, len(rules))
+			copy(ordered, rules)
+			sort.SliceStable(ordered, func(i, j int) bool {
+				return ordered[i].Order < ordered[j].Order
+			})
+			conflicts = append(conflicts, &RuleConflict{
+				ImportPath: bundle.ImportPath,
+				Target:     describeRule(ordered[0]),
+				Rules:      toInstRules(ordered),
+				Reason:     "is hooked by multiple rule files",
+			})
+		}
+	}
+	// Struct rules adding the same field to the same struct
+	for _, structRules := range bundle.File2StructRules {
+		for _, rules := range structRules {
+			fields := make(map[string][]*resource.InstStructRule)
+			for _, rule := range rules {
+				fields[rule.FieldName] = append(fields[rule.FieldName], rule)
+			}
+			for field, rs := range fields {
+				if len(rs) < 2 {
+					continue
+				}
+				target := fmt.Sprintf("struct %s.%s", rs[0].StructType, field)
+				conflicts = append(conflicts, &RuleConflict{
+					ImportPath: bundle.ImportPath,
+					Target:     target,
+					Rules:      toInstRules(rs),
+					Reason:     "is added by multiple rules",
+				})
+			}
+		}
+	}
+	// File rules replacing the same file, files are replaced by their base name
+	replaced := make(map[string][]*resource.InstFileRule)
+	for _, rule := range bundle.FileRules {
+		if rule.Replace {
+			name := filepath.Base(rule.FileName)
+			replaced[name] = append(replaced[name], rule)
+		}
+	}
+	for name, rs := range replaced {
+		if len(rs) < 2 {
+			continue
+		}
+		conflicts = append(conflicts, &RuleConflict{
+			ImportPath: bundle.ImportPath,
+			Target:     "file " + name,
+			Rules:      toInstRules(rs),
+			Reason:     "is replaced by multiple rules",
+		})
+	}
+	return conflicts
+}
+
+// findConflicts finds conflicting rules of all bundles, the result is sorted
+func findConflicts(bundles []*resource.RuleBundle) []*RuleConflict {
+	conflicts := make([]*RuleConflict, 0)
+	for _, bundle := range bundles {
+		conflicts = append(conflicts, findBundleConflicts(bundle)...)
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].ImportPath != conflicts[j].ImportPath {
+			return conflicts[i].ImportPath < conflicts[j].ImportPath
+		}
+		return conflicts[i].Target < conflicts[j].Target
+	})
+	return conflicts
+}
+
+// checkConflicts reports conflicting rules as warnings, or fails if conflicts
+// are configured to be errors
+func checkConflicts(conflicts []*RuleConflict) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		util.Log("Rule conflict: %s", c)
+		lines = append(lines, c.String())
+	}
+	if config.GetConf().GetRuleConflict() == config.RuleConflictError {
+		return errc.New(errc.ErrInvalidRule,
+			fmt.Sprintf("%d rule conflicts are found", len(conflicts))).
+			With("conflicts", strings.Join(lines, "\n"))
+	}
+	for _, line := range lines {
+		fmt.Fprintf(os.Stderr, "warning: rule conflict: %s\n", line)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+)
+
+func TestFindConflicts(t *testing.T) {
+	funcRule := func(function, ruleFile string, order int) *resource.InstFuncRule {
+		return &resource.InstFuncRule{
+			InstBaseRule: resource.InstBaseRule{RuleFile: ruleFile},
+			Function:     function,
+			ReceiverType: "*Engine",
+			OnEnter:      "onEnter",
+			Order:        order,
+		}
+	}
+	structRule := func(field, ruleFile string) *resource.InstStructRule {
+		return &resource.InstStructRule{
+			InstBaseRule: resource.InstBaseRule{RuleFile: ruleFile},
+			StructType:   "Request",
+			FieldName:    field,
+			FieldType:    "interface{}",
+		}
+	}
+	fileRule := func(fileName, ruleFile string, replace bool) *resource.InstFileRule {
+		return &resource.InstFileRule{
+			InstBaseRule: resource.InstBaseRule{RuleFile: ruleFile},
+			FileName:     fileName,
+			Replace:      replace,
+		}
+	}
+
+	gin := resource.NewRuleBundle("github.com/gin-gonic/gin")
+	custom := funcRule("ServeHTTP", "custom.json", 1)
+	for _, rule := range []*resource.InstFuncRule{
+		funcRule("ServeHTTP", "default.json", 2),
+		custom,
+		// Rules of the same rule file are chained on purpose
+		funcRule("Run", "default.json", 1),
+		funcRule("Run", "default.json", 2),
+	} {
+		if err := gin.AddFile2FuncRule("gin.go", rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	http := resource.NewRuleBundle("net/http")
+	for _, rule := range []*resource.InstStructRule{
+		structRule("otelCtx", "default.json"),
+		structRule("otelCtx", "custom.json"),
+		structRule("otelSpan", "default.json"),
+	} {
+		if err := http.AddFile2StructRule("request.go", rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	http.AddFileRule(fileRule("hook/transport.go", "default.json", true))
+	http.AddFileRule(fileRule("other/transport.go", "custom.json", true))
+	http.AddFileRule(fileRule("hook/client.go", "default.json", false))
+	http.AddFileRule(fileRule("other/client.go", "custom.json", false))
+
+	conflicts := findConflicts([]*resource.RuleBundle{http, gin})
+	tests := []struct {
+		importPath string
+		target     string
+		rules      int
+	}{
+		{"github.com/gin-gonic/gin", "func (*Engine).ServeHTTP", 2},
+		{"net/http", "file transport.go", 2},
+		{"net/http", "struct Request.otelCtx", 2},
+	}
+	if len(conflicts) != len(tests) {
+		t.Fatalf("expect %d conflicts, got %v", len(tests), conflicts)
+	}
+	for i, tt := range tests {
+		c := conflicts[i]
+		if c.ImportPath != tt.importPath || c.Target != tt.target ||
+			len(c.Rules) != tt.rules {
+			t.Errorf("expect %s %s of %d rules, got %s", tt.importPath,
+				tt.target, tt.rules, c)
+		}
+	}
+	// Func rules are listed in the order they are applied
+	if conflicts[0].Rules[0] != custom {
+		t.Errorf("expect the rule of lower Order first, got %s", conflicts[0])
+	}
+	expect := "github.com/gin-gonic/gin: func (*Engine).ServeHTTP is hooked " +
+		"by multiple rule files: custom.json (Order 1), default.json (Order 2)"
+	if conflicts[0].String() != expect {
+		t.Errorf("expect %q, got %q", expect, conflicts[0].String())
+	}
+}
+
+func TestFindConflictsNone(t *testing.T) {
+	bundle := resource.NewRuleBundle("net/http")
+	err := bundle.AddFile2FuncRule("server.go", &resource.InstFuncRule{
+		InstBaseRule: resource.InstBaseRule{RuleFile: "default.json"},
+		Function:     "Serve",
+		OnEnter:      "onEnter",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts := findConflicts([]*resource.RuleBundle{bundle})
+	if len(conflicts) != 0 {
+		t.Fatalf("expect no conflict, got %v", conflicts)
+	}
+	if err = checkConflicts(conflicts); err != nil {
+		t.Fatal(err)
+	}
+}
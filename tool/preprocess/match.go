@@ -16,13 +16,16 @@ package preprocess
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/data"
@@ -33,29 +36,257 @@ import (
 	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/tools/go/packages"
 )
 
 type ruleMatcher struct {
 	availableRules map[string][]resource.InstRule
-	moduleVersions []*vendorModule // vendor used only
+	callRules      []*resource.InstCallRule // call rules apply to callers
+	moduleName     string                   // module name of main module
+	moduleVersions []*vendorModule          // vendor used only
+	essentials     map[string]bool          // packages required by base rules
+	reachable      map[string]bool          // nil means all are reachable
+	reachableMains map[string]bool          // directories of main packages
+	decisions      []*MatchDecision
+	decisionsLock  sync.Mutex
+	gcflagsMarks   map[string]map[string]int // see DepProcessor.gcflagsMarks
+	gcflagsLock    sync.Mutex
+}
+
+const (
+	// The rule is matched and will be applied to the package
+	MatchStatusMatched = "matched"
+	// The package version is out of the version range of the rule
+	MatchStatusVersionMismatch = "version-mismatch"
+	// The Go version is out of the Go version range of the rule
+	MatchStatusGoVersionMismatch = "go-version-mismatch"
+	// The target declaration of the rule is not found in the package
+	MatchStatusNotFound = "not-found"
+	// The package is filtered out by package include/exclude filters
+	MatchStatusFiltered = "filtered"
+	// The package is not imported by the main packages of interest
+	MatchStatusUnreachable = "unreachable"
+)
+
+// MatchDecision records why a candidate rule is (not) matched with a package
+type MatchDecision struct {
+	ImportPath string
+	Version    string
+	GoVersion  string
+	Rule       resource.InstRule
+	Status     string
+	// Functions matched by the pattern func rule
+	Functions []string `json:",omitempty"`
+}
+
+func (rm *ruleMatcher) decide(importPath, version, goVersion string,
+	rule resource.InstRule, status string, functions ...string) {
+	rm.decisionsLock.Lock()
+	defer rm.decisionsLock.Unlock()
+	rm.decisions = append(rm.decisions, &MatchDecision{
+		ImportPath: importPath,
+		Version:    version,
+		GoVersion:  goVersion,
+		Rule:       rule,
+		Status:     status,
+		Functions:  functions,
+	})
 }
 
 func newRuleMatcher() *ruleMatcher {
 	rules := make(map[string][]resource.InstRule)
+	callRules := make([]*resource.InstCallRule, 0)
 	for _, rule := range findAvailableRules() {
+		if rl, ok := rule.(*resource.InstCallRule); ok {
+			callRules = append(callRules, rl)
+			continue
+		}
 		rules[rule.GetImportPath()] = append(rules[rule.GetImportPath()], rule)
 	}
 	if config.GetConf().Verbose {
-		util.Log("Available rules: %v", rules)
+		util.Log("Available rules: %v, call rules: %v", rules, callRules)
+	}
+	return &ruleMatcher{
+		availableRules: rules,
+		callRules:      callRules,
+		essentials:     findEssentials(),
+		gcflagsMarks:   make(map[string]map[string]int),
+	}
+}
+
+// findEssentials finds packages targeted by base rules, they are fundamental
+// to the whole instrumentation and never filtered out by package filters.
+func findEssentials() map[string]bool {
+	essentials := make(map[string]bool)
+	raw, err := data.ReadRuleFile(config.BaseRuleFile)
+	if err != nil {
+		util.Log("Failed to read %s: %v", config.BaseRuleFile, err)
+		return essentials
+	}
+	rules, err := loadRuleRaw(string(raw))
+	if err != nil {
+		util.Log("Failed to parse %s: %v", config.BaseRuleFile, err)
+		return essentials
+	}
+	for _, rule := range rules {
+		essentials[rule.GetImportPath()] = true
+	}
+	return essentials
+}
+
+// isPackageAllowed checks if the package is allowed to be instrumented by the
+// package include/exclude filters
+func (rm *ruleMatcher) isPackageAllowed(importPath string) bool {
+	if rm.essentials[importPath] {
+		return true
+	}
+	for _, pattern := range config.GetConf().GetExcludePackages() {
+		if util.MatchPackagePattern(pattern, importPath) {
+			return false
+		}
+	}
+	includes := config.GetConf().GetIncludePackages()
+	if len(includes) == 0 {
+		return true
+	}
+	for _, pattern := range includes {
+		if util.MatchPackagePattern(pattern, importPath) {
+			return true
+		}
 	}
-	return &ruleMatcher{availableRules: rules}
+	return false
 }
 
-type ruleHolder struct {
-	resource.InstBaseRule
-	resource.InstFileRule
-	resource.InstStructRule
-	resource.InstFuncRule
+// findPackageDir finds the directory of the package by its files, which are
+// relative to the working directory in the dry run. Files generated by the go
+// command are located in its work directory, and files placed via overlay are
+// located elsewhere, the directory holding most of the files is taken.
+func findPackageDir(cmdArgs []string) string {
+	overlayDir, _ := filepath.Abs(util.GetPreprocessLogPath(OtelOverlayDir))
+	counts := make(map[string]int)
+	found := ""
+	for _, arg := range cmdArgs {
+		if !util.IsGoFile(arg) || strings.HasPrefix(arg, "$WORK") {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(arg))
+		if err != nil || dir == overlayDir {
+			continue
+		}
+		counts[dir]++
+		if counts[dir] > counts[found] {
+			found = dir
+		}
+	}
+	return found
+}
+
+// addGcflagsMark records the pattern that selects the matched package for
+// -gcflags, along with the index of -gcflags in effect for it
+func (rm *ruleMatcher) addGcflagsMark(importPath string, cmdArgs []string) {
+	pattern := importPath
+	// All main packages are compiled as "main", tell them by directories,
+	// which are relative to the working directory of the go command
+	if importPath == "main" {
+		dir := findPackageDir(cmdArgs)
+		if dir == "" {
+			return
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		rel, err := filepath.Rel(wd, dir)
+		if err != nil {
+			return
+		}
+		sep := string(filepath.Separator)
+		if rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+sep) {
+			rel = "." + sep + rel
+		}
+		pattern = rel
+	}
+	rm.gcflagsLock.Lock()
+	defer rm.gcflagsLock.Unlock()
+	if rm.gcflagsMarks[importPath] == nil {
+		rm.gcflagsMarks[importPath] = make(map[string]int)
+	}
+	rm.gcflagsMarks[importPath][pattern] = findGcflagsMark(cmdArgs)
+}
+
+// isPackageReachable checks if the package is imported by the main packages
+// that instrumentation is restricted to, if any
+func (rm *ruleMatcher) isPackageReachable(importPath string, cmdArgs []string) bool {
+	if rm.reachable == nil || rm.essentials[importPath] {
+		return true
+	}
+	if importPath != "main" {
+		return rm.reachable[importPath]
+	}
+	// All main packages are compiled as "main", tell them by their files,
+	// which are relative to the working directory in the dry run
+	for _, arg := range cmdArgs {
+		if !util.IsGoFile(arg) {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(arg))
+		if err == nil && rm.reachableMains[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// findReachablePackages finds packages that are imported, directly or
+// indirectly, by the main packages matching the patterns, together with
+// directories of the main packages
+func findReachablePackages(patterns []string, goFlags []string) (map[string]bool,
+	map[string]bool, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedDeps,
+		Env:        append(os.Environ(), offlineEnv()...),
+		BuildFlags: goFlags,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, nil, errc.New(errc.ErrPreprocess, err.Error())
+	}
+	mains := make([]*packages.Package, 0)
+	mainDirs := map[string]bool{}
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" && len(pkg.GoFiles) > 0 {
+			mains = append(mains, pkg)
+			mainDirs[filepath.Dir(pkg.GoFiles[0])] = true
+		}
+	}
+	if len(mains) == 0 {
+		return nil, nil, errc.New(errc.ErrPreprocess, "no main package is found").
+			With("reachable", strings.Join(patterns, ","))
+	}
+	reachable := map[string]bool{}
+	packages.Visit(mains, func(pkg *packages.Package) bool {
+		if reachable[pkg.PkgPath] {
+			return false
+		}
+		reachable[pkg.PkgPath] = true
+		return true
+	}, nil)
+	return reachable, mainDirs, nil
+}
+
+// ruleFile is the versioned form of rule files
+type ruleFile struct {
+	SchemaVersion int
+	Rules         []json.RawMessage
+}
+
+// ruleProbe tells the kind of the rule by fields that are unique to the kind
+type ruleProbe struct {
+	Call       bool
+	Function   string
+	StructType string
+	FileName   string
 }
 
 func loadRuleFile(path string) ([]resource.InstRule, error) {
@@ -65,32 +296,96 @@ func loadRuleFile(path string) ([]resource.InstRule, error) {
 		err = errc.Adhere(err, "pwd", currentDir)
 		return nil, err
 	}
-	return loadRuleRaw(content)
+	rules, err := loadRuleRaw(content)
+	if err != nil {
+		return nil, errc.Adhere(err, "ruleFile", path)
+	}
+	for _, rule := range rules {
+		rule.SetRuleFile(path)
+	}
+	return rules, nil
 }
 
-func loadRuleRaw(content string) ([]resource.InstRule, error) {
-	var h []*ruleHolder
-	err := json.Unmarshal([]byte(content), &h)
+// splitRuleFile splits the rule file into raw rules, the schema version of the
+// rule file is checked if it's declared
+func splitRuleFile(content string) ([]json.RawMessage, error) {
+	if !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		var raws []json.RawMessage
+		err := json.Unmarshal([]byte(content), &raws)
+		if err != nil {
+			return nil, errc.New(errc.ErrInvalidJSON, err.Error())
+		}
+		return raws, nil
+	}
+	rf := &ruleFile{}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(rf)
 	if err != nil {
 		return nil, errc.New(errc.ErrInvalidJSON, err.Error())
 	}
+	if rf.SchemaVersion < 1 || rf.SchemaVersion > resource.RuleSchemaVersion {
+		return nil, errc.New(errc.ErrInvalidRule,
+			fmt.Sprintf("unsupported rule schema version %d, expect %d",
+				rf.SchemaVersion, resource.RuleSchemaVersion))
+	}
+	return rf.Rules, nil
+}
+
+// decodeRule decodes and verifies one rule of the rule file, unknown fields
+// are rejected if strict is set, which are most likely typos
+func decodeRule(raw json.RawMessage, strict bool) (resource.InstRule, error) {
+	probe := &ruleProbe{}
+	err := json.Unmarshal(raw, probe)
+	if err != nil {
+		return nil, errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	var rule resource.InstRule
+	switch {
+	case probe.Call:
+		rule = &resource.InstCallRule{}
+	case probe.StructType != "":
+		rule = &resource.InstStructRule{}
+	case probe.Function != "":
+		rule = &resource.InstFuncRule{}
+	case probe.FileName != "":
+		rule = &resource.InstFileRule{}
+	default:
+		return nil, errc.New(errc.ErrInvalidRule,
+			"unknown rule kind, expect Function, StructType or FileName")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	err = decoder.Decode(rule)
+	if err != nil {
+		return nil, errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	if r, ok := rule.(*resource.InstFuncRule); ok && r.Span {
+		// Span rules are verified once they are expanded
+		err = expandSpanRule(r)
+	} else {
+		err = rule.Verify()
+	}
+	if err != nil {
+		return nil, errc.Adhere(err, "rule", string(raw))
+	}
+	return rule, nil
+}
+
+func loadRuleRaw(content string) ([]resource.InstRule, error) {
+	raws, err := splitRuleFile(content)
+	if err != nil {
+		return nil, err
+	}
 	rules := make([]resource.InstRule, 0)
-	for _, rule := range h {
-		if rule.StructType != "" {
-			r := &rule.InstStructRule
-			r.InstBaseRule = rule.InstBaseRule
-			rules = append(rules, r)
-		} else if rule.Function != "" {
-			r := &rule.InstFuncRule
-			r.InstBaseRule = rule.InstBaseRule
-			rules = append(rules, r)
-		} else if rule.FileName != "" {
-			r := &rule.InstFileRule
-			r.InstBaseRule = rule.InstBaseRule
-			rules = append(rules, r)
-		} else {
-			util.ShouldNotReachHereT("invalid rule type")
+	for _, raw := range raws {
+		rule, err := decodeRule(raw, false)
+		if err != nil {
+			return nil, err
 		}
+		rules = append(rules, rule)
 	}
 	return rules, nil
 }
@@ -109,9 +404,9 @@ func loadDefaultRules() []resource.InstRule {
 	filteredFiles := make([]string, 0)
 	disable := config.GetConf().GetDisabledRules()
 	switch disable {
-	case "all":
+	case config.DisableAllRules:
 		// Disable all rules except base.json
-		filteredFiles = append(filteredFiles, "base.json")
+		filteredFiles = append(filteredFiles, config.BaseRuleFile)
 	case "":
 		// Enable all rules
 		filteredFiles = files
@@ -131,7 +426,7 @@ func loadDefaultRules() []resource.InstRule {
 	foundBase := false
 	for i, name := range filteredFiles {
 		i, name := i, name // capture loop variables
-		if name == "base.json" {
+		if name == config.BaseRuleFile {
 			foundBase = true
 		}
 
@@ -148,6 +443,9 @@ func loadDefaultRules() []resource.InstRule {
 				util.Log("Failed to parse rule file %s: %v", name, err)
 				return nil
 			}
+			for _, r := range rule {
+				r.SetRuleFile(name)
+			}
 
 			ruleChunks[i] = rule
 			return nil
@@ -219,70 +517,6 @@ func extractVersion(path string) string {
 	return version[1 : len(version)-1]
 }
 
-// splitVersionRange splits the version range into two parts, start and end.
-func splitVersionRange(vr string) (string, string) {
-	util.Assert(strings.Contains(vr, ","), "invalid version range format")
-	util.Assert(strings.Contains(vr, "["), "invalid version range format")
-	util.Assert(strings.Contains(vr, ")"), "invalid version range format")
-
-	start := vr[1:strings.Index(vr, ",")]
-	end := vr[strings.Index(vr, ",")+1 : len(vr)-1]
-	return "v" + start, "v" + end
-}
-
-// matchVersion checks if the version string matches the version range in the
-// rule. The version range is in format [start, end), where start is inclusive
-// and end is exclusive. If the rule version string is empty, it always matches.
-func matchVersion(version string, ruleVersion string) (bool, error) {
-	// Fast path, always match if the rule version is not specified
-	if ruleVersion == "" {
-		return true, nil
-	}
-	// Check if both rule version and package version are in sane
-	if !strings.Contains(version, "v") {
-		return false, errc.New(errc.ErrMatchRule,
-			fmt.Sprintf("invalid version %v", version))
-	}
-	if !strings.Contains(ruleVersion, "[") ||
-		!strings.Contains(ruleVersion, ")") ||
-		!strings.Contains(ruleVersion, ",") ||
-		strings.Contains(ruleVersion, "v") {
-		return false, errc.New(errc.ErrMatchRule,
-			fmt.Sprintf("invalid rule version %v", ruleVersion))
-	}
-	// Remove extra whitespace from the rule version string
-	ruleVersion = strings.ReplaceAll(ruleVersion, " ", "")
-
-	// Compare the version with the rule version, the rule version is in the
-	// format [start, end), where start is inclusive and end is exclusive
-	// and start or end can be omitted, which means the range is open-ended.
-	ruleVersionStart, ruleVersionEnd := splitVersionRange(ruleVersion)
-	switch {
-	case ruleVersionStart != "v" && ruleVersionEnd != "v":
-		// Full version range
-		if semver.Compare(version, ruleVersionStart) >= 0 &&
-			semver.Compare(version, ruleVersionEnd) < 0 {
-			return true, nil
-		}
-	case ruleVersionStart == "v":
-		// Only end is specified
-		util.Assert(ruleVersionEnd != "v", "sanity check")
-		if semver.Compare(version, ruleVersionEnd) < 0 {
-			return true, nil
-		}
-	case ruleVersionEnd == "v":
-		// Only start is specified
-		util.Assert(ruleVersionStart != "v", "sanity check")
-		if semver.Compare(version, ruleVersionStart) >= 0 {
-			return true, nil
-		}
-	default:
-		return false, errc.New(errc.ErrMatchRule,
-			fmt.Sprintf("invalid rule version range %v", ruleVersion))
-	}
-	return false, nil
-}
-
 // match gives compilation arguments and finds out all interested rules
 // for it.
 func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
@@ -297,16 +531,68 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 	// the instrumentation rule, but first we need to check if the package name
 	// are already registered, to avoid futile effort
 	copy(availables, rm.availableRules[importPath])
-	if len(availables) == 0 {
+	callRules := rm.findCallRules(importPath)
+	if len(availables) == 0 && len(callRules) == 0 {
 		return nil // fast fail
 	}
-	parsedAst := make(map[string]*dst.File)
-	bundle := resource.NewRuleBundle(importPath)
-
 	goVersion := findFlagValue(cmdArgs, util.BuildGoVer)
 	util.Assert(goVersion != "", "sanity check")
 	util.Assert(strings.HasPrefix(goVersion, "go"), "sanity check")
 	goVersion = strings.Replace(goVersion, "go", "v", 1)
+
+	// Record why the candidates are not matched, rules that are never checked
+	// against any file are considered as not found
+	version := ""
+	reasons := make(map[resource.InstRule]string)
+	defer func() {
+		for _, rule := range availables {
+			reason, ok := reasons[rule]
+			if !ok {
+				reason = MatchStatusNotFound
+			}
+			rm.decide(importPath, version, goVersion, rule, reason)
+		}
+	}()
+
+	if !rm.isPackageAllowed(importPath) {
+		util.Log("Skip filtered package %s", importPath)
+		for _, rule := range availables {
+			reasons[rule] = MatchStatusFiltered
+		}
+		return nil
+	}
+	if !rm.isPackageReachable(importPath, cmdArgs) {
+		util.Log("Skip unreachable package %s", importPath)
+		for _, rule := range availables {
+			reasons[rule] = MatchStatusUnreachable
+		}
+		return nil
+	}
+	parsedAst := make(map[string]*dst.File)
+	bundle := resource.NewRuleBundle(importPath)
+	rm.matchCallRules(bundle, callRules, cmdArgs, goVersion)
+	// Functions matched by pattern func rules, grouped by file
+	patternFuncs := make(map[*resource.InstFuncRule]map[string][]string)
+	defer func() {
+		// Pattern rules are considered as matched once all files are checked
+		for i := len(availables) - 1; i >= 0; i-- {
+			rl, ok := availables[i].(*resource.InstFuncRule)
+			if !ok || len(patternFuncs[rl]) == 0 {
+				continue
+			}
+			funcs := make([]string, 0)
+			for _, fs := range patternFuncs[rl] {
+				funcs = append(funcs, fs...)
+			}
+			sort.Strings(funcs)
+			util.Log("Match func rule %s with %d functions: %s", rl,
+				len(funcs), strings.Join(funcs, ", "))
+			rm.decide(importPath, version, goVersion, rl,
+				MatchStatusMatched, funcs...)
+			availables = append(availables[:i], availables[i+1:]...)
+		}
+	}()
+
 	for _, candidate := range cmdArgs {
 		// It's not a go file, ignore silently
 		if !util.IsGoFile(candidate) {
@@ -317,7 +603,7 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 		// If it's a vendor build, we need to extract the version of the module
 		// from vendor/modules.txt, otherwise we find the version from source
 		// code file path
-		version := extractVersion(file)
+		version = extractVersion(file)
 		if rm.moduleVersions != nil {
 			recorded := findVendorModuleVersion(rm.moduleVersions, importPath)
 			if recorded != "" {
@@ -329,27 +615,30 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 			rule := availables[i]
 
 			// Check if the version is supported
-			matched, err := matchVersion(version, rule.GetVersion())
+			matched, err := util.MatchVersion(version, rule.GetVersion())
 			if err != nil {
 				util.Log("Bad match: file %s, rule %s, version %s",
 					file, rule, version)
 				continue
 			}
 			if !matched {
+				reasons[rule] = MatchStatusVersionMismatch
 				continue
 			}
 			// Check if the rule requires a specific Go version(range)
 			if rule.GetGoVersion() != "" {
-				matched, err = matchVersion(goVersion, rule.GetGoVersion())
+				matched, err = util.MatchVersion(goVersion, rule.GetGoVersion())
 				if err != nil {
 					util.Log("Bad match: file %s, rule %s, go version %s",
 						file, rule, goVersion)
 					continue
 				}
 				if !matched {
+					reasons[rule] = MatchStatusGoVersionMismatch
 					continue
 				}
 			}
+			delete(reasons, rule)
 
 			// Check if it matches with file rule early as we try to avoid
 			// parsing the file content, which is time consuming
@@ -362,6 +651,8 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 				util.Log("Match file rule %s", rule)
 				bundle.AddFileRule(rule.(*resource.InstFileRule))
 				bundle.SetPackageName(ast.Name.Name)
+				rm.decide(importPath, version, goVersion, rule,
+					MatchStatusMatched)
 				availables = append(availables[:i], availables[i+1:]...)
 				continue
 			}
@@ -408,7 +699,27 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 					}
 				} else if funcDecl, ok := decl.(*dst.FuncDecl); ok {
 					if rl, ok := rule.(*resource.InstFuncRule); ok {
-						if util.MatchFuncDecl(funcDecl, rl.Function, rl.ReceiverType) {
+						if rl.IsPattern() {
+							// Pattern rules may match many functions across
+							// files, keep looking until all files are checked
+							if !rl.MatchFuncDecl(funcDecl) {
+								continue
+							}
+							if len(patternFuncs[rl][file]) == 0 {
+								err = bundle.AddFile2FuncRule(file, rl)
+								if err != nil {
+									util.Log("Failed to add func rule: %v", err)
+									continue
+								}
+							}
+							if patternFuncs[rl] == nil {
+								patternFuncs[rl] = make(map[string][]string)
+							}
+							patternFuncs[rl][file] = append(patternFuncs[rl][file],
+								util.FuncDeclName(funcDecl))
+							continue
+						}
+						if rl.MatchFuncDecl(funcDecl) {
 							util.Log("Match func rule %s with %v", rule, cmdArgs)
 							err = bundle.AddFile2FuncRule(file, rl)
 							if err != nil {
@@ -423,6 +734,8 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 			}
 			if valid {
 				// Remove the rule from the available rules
+				rm.decide(importPath, version, goVersion, rule,
+					MatchStatusMatched)
 				availables = append(availables[:i], availables[i+1:]...)
 			}
 		}
@@ -430,6 +743,174 @@ func (rm *ruleMatcher) match(cmdArgs []string) *resource.RuleBundle {
 	return bundle
 }
 
+// findCallRules finds call rules whose call sites may reside in the package
+func (rm *ruleMatcher) findCallRules(importPath string) []*resource.InstCallRule {
+	rules := make([]*resource.InstCallRule, 0)
+	for _, rule := range rm.callRules {
+		callers := rule.Callers
+		if len(callers) == 0 {
+			// Call sites in the main module are instrumented by default
+			if importPath == "main" {
+				rules = append(rules, rule)
+				continue
+			}
+			if rm.moduleName == "" {
+				continue
+			}
+			callers = []string{rm.moduleName + "/..."}
+		}
+		for _, caller := range callers {
+			if util.MatchPackagePattern(caller, importPath) {
+				rules = append(rules, rule)
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// callNames records names that are called in a package, which are collected
+// syntactically since type information is not available yet
+type callNames struct {
+	// Plain function calls, e.g. Getenv for Getenv(...)
+	funcs map[string]bool
+	// Qualified calls by the qualifier, e.g. os for os.Getenv(...)
+	qualified map[string]map[string]bool
+	// Selector calls of any kind, e.g. WriteString for b.WriteString(...)
+	selectors map[string]bool
+}
+
+func (cn *callNames) collect(tree *dst.File) {
+	dst.Inspect(tree, func(node dst.Node) bool {
+		call, ok := node.(*dst.CallExpr)
+		if !ok {
+			return true
+		}
+		fun := call.Fun
+		// Unwrap explicit instantiations of generic functions, e.g. F[int]()
+		switch f := fun.(type) {
+		case *dst.IndexExpr:
+			fun = f.X
+		case *dst.IndexListExpr:
+			fun = f.X
+		}
+		switch f := fun.(type) {
+		case *dst.Ident:
+			cn.funcs[f.Name] = true
+		case *dst.SelectorExpr:
+			cn.selectors[f.Sel.Name] = true
+			if x, ok := f.X.(*dst.Ident); ok {
+				if cn.qualified[x.Name] == nil {
+					cn.qualified[x.Name] = make(map[string]bool)
+				}
+				cn.qualified[x.Name][f.Sel.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+// calls checks if any of the names matches the rule
+func calls(rule *resource.InstCallRule, names map[string]bool) bool {
+	for name := range names {
+		if rule.MatchCalleeName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCallRules matches call rules with the calling package, the rule is
+// matched if any file of the package calls the function, call sites are
+// located precisely during instrumentation as it requires type information
+func (rm *ruleMatcher) matchCallRules(bundle *resource.RuleBundle,
+	rules []*resource.InstCallRule, cmdArgs []string, goVersion string) {
+	if len(rules) == 0 {
+		return
+	}
+	importPath := bundle.ImportPath
+	// Names that the imported package is referred to, "" stands for the
+	// package name which is unknown without loading the package
+	imports := make(map[string]map[string]bool)
+	names := &callNames{
+		funcs:     make(map[string]bool),
+		qualified: make(map[string]map[string]bool),
+		selectors: make(map[string]bool),
+	}
+	for _, file := range cmdArgs {
+		if !util.IsGoFile(file) {
+			continue
+		}
+		tree, err := util.ParseAstFromFileFast(file)
+		if tree == nil || err != nil {
+			util.Log("Failed to parse %s: %v", file, err)
+			continue
+		}
+		bundle.SetPackageName(tree.Name.Name)
+		for _, spec := range tree.Imports {
+			path := strings.Trim(spec.Path.Value, `"`)
+			if imports[path] == nil {
+				imports[path] = make(map[string]bool)
+			}
+			alias := ""
+			if spec.Name != nil {
+				alias = spec.Name.Name
+			}
+			imports[path][alias] = true
+		}
+		names.collect(tree)
+	}
+	for _, rule := range rules {
+		if rule.GetGoVersion() != "" {
+			matched, err := util.MatchVersion(goVersion, rule.GetGoVersion())
+			if err != nil || !matched {
+				continue
+			}
+		}
+		if !callsRule(rule, importPath, imports, names) {
+			continue
+		}
+		util.Log("Match call rule %s with %v", rule, importPath)
+		bundle.AddCallRule(rule)
+		rm.decide(importPath, "", goVersion, rule, MatchStatusMatched)
+	}
+}
+
+// callsRule checks if the package calls the function of the call rule
+func callsRule(rule *resource.InstCallRule,
+	importPath string, imports map[string]map[string]bool,
+	names *callNames) bool {
+	// Methods can be called without importing the package where the receiver
+	// type is declared, any selector call of the method name is interesting
+	if rule.ReceiverType != "" {
+		return calls(rule, names.selectors)
+	}
+	if importPath == rule.ImportPath {
+		return calls(rule, names.funcs)
+	}
+	for alias := range imports[rule.ImportPath] {
+		switch alias {
+		case "_":
+		case ".":
+			if calls(rule, names.funcs) {
+				return true
+			}
+		case "":
+			// The package name is unknown, any qualifier is possible
+			for _, qualified := range names.qualified {
+				if calls(rule, qualified) {
+					return true
+				}
+			}
+		default:
+			if calls(rule, names.qualified[alias]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func findFlagValue(cmd []string, flag string) string {
 	for i, v := range cmd {
 		if v == flag {
@@ -571,7 +1052,11 @@ func parseVendorModules(projDir string) ([]*vendorModule, error) {
 }
 
 func runMatch(matcher *ruleMatcher, cmd string, ch chan *resource.RuleBundle) {
-	bundle := matcher.match(util.SplitCmds(cmd))
+	cmdArgs := util.SplitCmds(cmd)
+	bundle := matcher.match(cmdArgs)
+	if bundle.IsValid() {
+		matcher.addGcflagsMark(bundle.ImportPath, cmdArgs)
+	}
 	ch <- bundle
 }
 
@@ -581,7 +1066,8 @@ func (dp *DepProcessor) matchRules() ([]*resource.RuleBundle, error) {
 	// Match the dependencies with available rules and prepare them
 	// for the actual instrumentation
 	// Run dry build to the build blueprint
-	compileCmds, err := runDryBuild(dp.goBuildCmd)
+	goFlags := append(dp.getGoFlags(), dp.getMarkedGcflags()...)
+	compileCmds, err := runDryBuild(dp.goBuildCmd, goFlags)
 	if err != nil {
 		// Tell us more about what happened in the dry run
 		errLog, _ := util.ReadFile(util.GetLogPath(DryRunLog))
@@ -590,6 +1076,18 @@ func (dp *DepProcessor) matchRules() ([]*resource.RuleBundle, error) {
 	}
 
 	matcher := newRuleMatcher()
+	matcher.moduleName = dp.moduleName
+	// Dependencies of main packages change as hooks are imported, find them
+	// in every round
+	if patterns := config.GetConf().GetReachableFrom(); len(patterns) > 0 {
+		matcher.reachable, matcher.reachableMains, err =
+			findReachablePackages(patterns, dp.getGoFlags())
+		if err != nil {
+			return nil, err
+		}
+		util.Log("Found %d packages reachable from %v", len(matcher.reachable),
+			patterns)
+	}
 
 	// If we are in vendor mode, we need to parse the vendor/modules.txt file
 	// to get the version of each module for future matching
@@ -613,10 +1111,28 @@ func (dp *DepProcessor) matchRules() ([]*resource.RuleBundle, error) {
 	bundles := make([]*resource.RuleBundle, 0)
 	for cnt < len(compileCmds) {
 		bundle := <-ch
-		if bundle.IsValid() {
+		cnt++
+		if !bundle.IsValid() {
+			continue
+		}
+		// Tests may compile the same package twice, i.e. with and without its
+		// test files, while the instrument phase expects one bundle per package
+		merged := false
+		for _, b := range bundles {
+			if b.ImportPath == bundle.ImportPath {
+				b.Merge(bundle)
+				merged = true
+				break
+			}
+		}
+		if !merged {
 			bundles = append(bundles, bundle)
 		}
-		cnt++
 	}
+	// Keep match decisions and rule conflicts of the latest round for
+	// diagnostics
+	dp.decisions = matcher.decisions
+	dp.gcflagsMarks = matcher.gcflagsMarks
+	dp.conflicts = findConflicts(bundles)
 	return bundles, nil
 }
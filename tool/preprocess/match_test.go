@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPackageReachable(t *testing.T) {
+	mainDir, err := filepath.Abs("testdata/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted := &ruleMatcher{
+		essentials:     map[string]bool{"runtime": true},
+		reachable:      map[string]bool{"net/http": true},
+		reachableMains: map[string]bool{mainDir: true},
+	}
+	tests := []struct {
+		name       string
+		matcher    *ruleMatcher
+		importPath string
+		cmdArgs    []string
+		expect     bool
+	}{
+		{
+			name:       "unrestricted",
+			matcher:    &ruleMatcher{},
+			importPath: "database/sql",
+			expect:     true,
+		},
+		{
+			name:       "essential",
+			matcher:    restricted,
+			importPath: "runtime",
+			expect:     true,
+		},
+		{
+			name:       "reachable",
+			matcher:    restricted,
+			importPath: "net/http",
+			expect:     true,
+		},
+		{
+			name:       "unreachable",
+			matcher:    restricted,
+			importPath: "database/sql",
+			expect:     false,
+		},
+		{
+			name:       "reachable main",
+			matcher:    restricted,
+			importPath: "main",
+			cmdArgs:    []string{"-p", "main", "testdata/app/main.go"},
+			expect:     true,
+		},
+		{
+			name:       "unreachable main",
+			matcher:    restricted,
+			importPath: "main",
+			cmdArgs:    []string{"-p", "main", "testdata/tool/main.go"},
+			expect:     false,
+		},
+		{
+			name:       "main without go files",
+			matcher:    restricted,
+			importPath: "main",
+			cmdArgs:    []string{"-p", "main"},
+			expect:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.matcher.isPackageReachable(tt.importPath, tt.cmdArgs)
+			if got != tt.expect {
+				t.Errorf("expect %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
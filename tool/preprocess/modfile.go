@@ -0,0 +1,389 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"golang.org/x/mod/modfile"
+)
+
+// -----------------------------------------------------------------------------
+// Private Module Files
+//
+// The instrumented build never modifies the working tree of the user. Replace
+// directives are added to a private copy of go.mod and go.sum, which is passed
+// to the go command via -modfile, and generated sources such as the importer
+// are added to the build via -overlay. Both live in the temporary build
+// directory, so nothing is left behind even if the build is killed, and the
+// module files are not shared between concurrent builds. In workspace mode,
+// where -modfile is not allowed, a private go.work is passed via GOWORK
+// instead. It uses everything the go.work of the user does, plus a module of
+// our own that requires and replaces dependencies of the instrumentation.
+
+const (
+	OtelOverlay    = resource.OverlayJsonFile
+	OtelOverlayDir = "overlay"
+	goFlagModfile  = "modfile"
+	goFlagOverlay  = "overlay"
+	OtelWorkDir    = "workspace"
+	OtelWorkDeps   = "otel_deps.go"
+	// The module of our own in workspace mode
+	otelWorkModule = "otel_workspace_deps"
+)
+
+// stripGoFlag removes the flag with the given name from the go build command,
+// and returns its value if any
+func stripGoFlag(goBuildCmd []string, name string) ([]string, string) {
+	stripped, values := stripGoFlags(goBuildCmd, name)
+	if len(values) == 0 {
+		return stripped, ""
+	}
+	return stripped, values[len(values)-1]
+}
+
+// stripGoFlags is like stripGoFlag, but returns all values of the flag in the
+// order they are given, for flags that can be given many times
+func stripGoFlags(goBuildCmd []string, name string) ([]string, []string) {
+	stripped := make([]string, 0, len(goBuildCmd))
+	values := make([]string, 0)
+	for i := 0; i < len(goBuildCmd); i++ {
+		arg := goBuildCmd[i]
+		if i < 2 || !strings.HasPrefix(arg, "-") {
+			stripped = append(stripped, arg)
+			continue
+		}
+		n, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if n != name {
+			stripped = append(stripped, arg)
+			continue
+		}
+		if !hasValue && i+1 < len(goBuildCmd) {
+			i++ // The value is the next argument
+			v = goBuildCmd[i]
+		}
+		values = append(values, v)
+	}
+	return stripped, values
+}
+
+// findGoWork returns the go.work file in effect, if any
+func findGoWork(dir string) string {
+	out, err := runCmdCombinedOutput(dir, nil, "go", "env", "GOWORK")
+	if err != nil {
+		util.Log("Failed to run go env GOWORK: %v", err)
+		return ""
+	}
+	gowork := strings.TrimSpace(out)
+	if gowork == "off" {
+		return ""
+	}
+	return gowork
+}
+
+// initModfile takes over the -modfile and -overlay flags of the user, they
+// are merged into our own ones as the go command accepts only one of each
+func (dp *DepProcessor) initModfile() error {
+	dp.goBuildCmd, dp.userModfile = stripGoFlag(dp.goBuildCmd, goFlagModfile)
+	var userOverlay string
+	dp.goBuildCmd, userOverlay = stripGoFlag(dp.goBuildCmd, goFlagOverlay)
+	if userOverlay != "" {
+		data, err := util.ReadFile(userOverlay)
+		if err != nil {
+			return err
+		}
+		ov := &resource.OverlayJSON{}
+		err = json.Unmarshal([]byte(data), ov)
+		if err != nil {
+			return errc.New(errc.ErrInvalidJSON, err.Error()).
+				With("overlay", userOverlay)
+		}
+		// Relative paths are resolved against the working directory of the
+		// go command, while we run some of them in the module directory
+		for from, to := range ov.Replace {
+			from, err = filepath.Abs(from)
+			if err != nil {
+				return errc.New(errc.ErrAbsPath, err.Error())
+			}
+			if to != "" {
+				to, err = filepath.Abs(to)
+				if err != nil {
+					return errc.New(errc.ErrAbsPath, err.Error())
+				}
+			}
+			dp.overlays[from] = to
+		}
+	}
+	overlay, err := filepath.Abs(util.GetPreprocessLogPath(OtelOverlay))
+	if err != nil {
+		return errc.New(errc.ErrAbsPath, err.Error())
+	}
+	dp.overlay = overlay
+	return nil
+}
+
+// copyModFiles makes the private copy of go.mod and go.sum, or the private
+// workspace in workspace mode
+func (dp *DepProcessor) copyModFiles() error {
+	if gowork := findGoWork(dp.getGoModDir()); gowork != "" {
+		if dp.userModfile != "" {
+			return errc.New(errc.ErrPreprocess,
+				"-modfile cannot be used in workspace mode")
+		}
+		return dp.initWorkspace(gowork)
+	}
+	src := dp.getGoModPath()
+	if dp.userModfile != "" {
+		src = dp.userModfile
+	}
+	modfile, err := filepath.Abs(util.GetPreprocessLogPath(util.GoModFile))
+	if err != nil {
+		return errc.New(errc.ErrAbsPath, err.Error())
+	}
+	err = util.CopyFile(src, modfile)
+	if err != nil {
+		return err
+	}
+	// The go command looks for go.sum right next to the -modfile
+	sum := strings.TrimSuffix(src, ".mod") + ".sum"
+	if util.PathExists(sum) {
+		err = util.CopyFile(sum, strings.TrimSuffix(modfile, ".mod")+".sum")
+		if err != nil {
+			return err
+		}
+	}
+	dp.modfile = modfile
+	util.Log("Use private %s copied from %s", modfile, src)
+	return nil
+}
+
+// absReplace makes the local path of the replace directive absolute, as it's
+// relative to the directory of the file where the directive was
+func absReplace(r *modfile.Replace, dir string) (string, string) {
+	if r.New.Version != "" || filepath.IsAbs(r.New.Path) {
+		return r.New.Path, r.New.Version
+	}
+	return filepath.Join(dir, r.New.Path), ""
+}
+
+// initWorkspace writes the private go.work and the module of our own, and
+// makes the go command use them via GOWORK
+func (dp *DepProcessor) initWorkspace(gowork string) error {
+	data, err := util.ReadFile(gowork)
+	if err != nil {
+		return err
+	}
+	wf, err := modfile.ParseWork(gowork, []byte(data), nil)
+	if err != nil {
+		return errc.New(errc.ErrPreprocess, err.Error()).With("file", gowork)
+	}
+	workDir, err := filepath.Abs(util.GetPreprocessLogPath(OtelWorkDir))
+	if err != nil {
+		return errc.New(errc.ErrAbsPath, err.Error())
+	}
+	modDir := filepath.Join(workDir, otelWorkModule)
+	err = os.MkdirAll(modDir, 0777)
+	if err != nil {
+		return errc.New(errc.ErrMkdirAll, err.Error())
+	}
+
+	// Our module knows replace directives of the workspace, so that they are
+	// never added again, and modules of the workspace, so that it can be
+	// tidied by itself
+	mf := &modfile.File{Syntax: &modfile.FileSyntax{}}
+	pwf := &modfile.WorkFile{Syntax: &modfile.FileSyntax{}}
+	err = mf.AddModuleStmt(otelWorkModule)
+	if err != nil {
+		return errc.New(errc.ErrPreprocess, err.Error())
+	}
+	if wf.Go != nil {
+		_ = mf.AddGoStmt(wf.Go.Version)
+		_ = pwf.AddGoStmt(wf.Go.Version)
+	}
+	if wf.Toolchain != nil {
+		_ = pwf.AddToolchainStmt(wf.Toolchain.Name)
+	}
+	workRoot := filepath.Dir(gowork)
+	for _, use := range wf.Use {
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workRoot, dir)
+		}
+		_ = pwf.AddUse(dir, use.ModulePath)
+		gomod := filepath.Join(dir, util.GoModFile)
+		if util.PathNotExists(gomod) {
+			continue
+		}
+		umf, err := parseGoMod(gomod)
+		if err != nil {
+			return err
+		}
+		path := umf.Module.Mod.Path
+		_ = mf.AddRequire(path, "v0.0.0-00010101000000-000000000000")
+		_ = mf.AddReplace(path, "", dir, "")
+		for _, r := range umf.Replace {
+			newPath, newVersion := absReplace(r, dir)
+			_ = mf.AddReplace(r.Old.Path, r.Old.Version, newPath, newVersion)
+		}
+	}
+	for _, r := range wf.Replace {
+		newPath, newVersion := absReplace(r, workRoot)
+		_ = mf.AddReplace(r.Old.Path, r.Old.Version, newPath, newVersion)
+		_ = pwf.AddReplace(r.Old.Path, r.Old.Version, newPath, newVersion)
+	}
+	_ = pwf.AddUse(modDir, otelWorkModule)
+	mf.Cleanup()
+	pwf.Cleanup()
+
+	bs, err := mf.Format()
+	if err != nil {
+		return errc.New(errc.ErrPreprocess, err.Error())
+	}
+	gomod := filepath.Join(modDir, util.GoModFile)
+	_, err = util.WriteFile(gomod, string(bs))
+	if err != nil {
+		return err
+	}
+	privateWork := filepath.Join(workDir, util.GoWorkFile)
+	_, err = util.WriteFile(privateWork, string(modfile.Format(pwf.Syntax)))
+	if err != nil {
+		return err
+	}
+	// Checksums known to the workspace are known to us as well, new ones are
+	// recorded in the private go.work.sum
+	worksum := gowork + ".sum"
+	if util.PathExists(worksum) {
+		err = util.CopyFile(worksum, filepath.Join(workDir, util.GoWorkSumFile))
+		if err != nil {
+			return err
+		}
+	}
+	err = os.Setenv("GOWORK", privateWork)
+	if err != nil {
+		return errc.New(errc.ErrInternal, err.Error())
+	}
+	dp.workfile = privateWork
+	dp.workModfile = gomod
+	// The go command ignores vendor directories of modules in workspace mode
+	dp.vendorMode = false
+	util.Log("Workspace mode, use private %s copied from %s", privateWork,
+		gowork)
+	return nil
+}
+
+// writeWorkDeps makes our module in workspace mode import what the importers
+// import, so that go mod tidy finds the dependencies of instrumentation
+func (dp *DepProcessor) writeWorkDeps() error {
+	importers := make([]string, 0)
+	if dp.otelImporter != "" {
+		importers = append(importers, dp.overlays[dp.otelImporter])
+	}
+	for _, ti := range dp.testImporters {
+		importers = append(importers, dp.overlays[ti.path])
+	}
+	paths := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, importer := range importers {
+		if importer == "" {
+			continue
+		}
+		f, err := parser.ParseFile(fset, importer, nil, parser.ImportsOnly)
+		if err != nil {
+			return errc.New(errc.ErrParseCode, err.Error())
+		}
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err == nil && path != "unsafe" {
+				paths[path] = true
+			}
+		}
+	}
+	content := "// This file is generated by alibaba-otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package otel_deps\n\n"
+	for _, path := range sortedKeys(paths) {
+		content += fmt.Sprintf("import _ %q\n", path)
+	}
+	deps := filepath.Join(filepath.Dir(dp.workModfile), OtelWorkDeps)
+	_, err := util.WriteFile(deps, content)
+	return err
+}
+
+// getModfile returns the go.mod file that we are allowed to modify
+func (dp *DepProcessor) getModfile() string {
+	if dp.workModfile != "" {
+		return dp.workModfile
+	}
+	if dp.modfile != "" {
+		return dp.modfile
+	}
+	return dp.getGoModPath()
+}
+
+// getGoFlags returns the flags that point the go command to our private
+// module files and generated sources
+func (dp *DepProcessor) getGoFlags() []string {
+	flags := []string{}
+	if dp.modfile != "" {
+		flags = append(flags, "-"+goFlagModfile+"="+dp.modfile)
+	}
+	if len(dp.overlays) > 0 {
+		flags = append(flags, "-"+goFlagOverlay+"="+dp.overlay)
+	}
+	return flags
+}
+
+// writeOverlayFile adds the generated file to the build as if it was placed
+// at the given path, without touching the path itself
+func (dp *DepProcessor) writeOverlayFile(path, content string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return errc.New(errc.ErrAbsPath, err.Error())
+	}
+	dir := filepath.Dir(dp.overlay)
+	target, exist := dp.overlays[path]
+	if !exist || !strings.HasPrefix(target, dir) {
+		// Keep the base name, as the suffix such as _test.go matters
+		name := fmt.Sprintf("%d_%s", len(dp.overlays), filepath.Base(path))
+		target = filepath.Join(dir, OtelOverlayDir, name)
+		err = os.MkdirAll(filepath.Dir(target), 0777)
+		if err != nil {
+			return errc.New(errc.ErrMkdirAll, err.Error())
+		}
+	}
+	_, err = util.WriteFile(target, content)
+	if err != nil {
+		return err
+	}
+	dp.overlays[path] = target
+	bs, err := json.MarshalIndent(&resource.OverlayJSON{Replace: dp.overlays}, "", "  ")
+	if err != nil {
+		return errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	_, err = util.WriteFile(dp.overlay, string(bs))
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// -----------------------------------------------------------------------------
+// Offline Mode
+//
+// Instrumentation rules bring in dependencies that the project itself does not
+// have, i.e. the pkg module, rule modules and their requirements, which are
+// usually fetched by go mod tidy on the fly. This is not an option for builds
+// without network access. In offline mode, the go command is never allowed to
+// download anything, and the required modules are checked in advance, so that
+// the build fails fast with the full list of missing modules rather than the
+// first one the go command happens to complain about. Modules are taken either
+// from the module cache, or from the module cache of the project prepared by
+// "otel vendor" on a connected machine. The latter is kept apart from the vendor
+// directory, which must agree with go.mod of the project, while go.mod is never
+// modified for instrumentation.
+
+const (
+	VendoredModCache = ".otel-vendor"
+	goProxyOff       = "GOPROXY=off"
+)
+
+// vendoredModCache is the module cache prepared by "otel vendor", all go
+// commands use it instead of the default one in offline mode if it exists
+var vendoredModCache string
+
+// offlineEnv returns the environment that keeps the go command off the network
+// in offline mode
+func offlineEnv() []string {
+	if !config.GetConf().Offline {
+		return nil
+	}
+	env := []string{goProxyOff}
+	if vendoredModCache != "" {
+		// The vendor directory, if any, knows nothing about modules of
+		// instrumentation, they are loaded from the module cache instead
+		goflags := strings.TrimSpace(os.Getenv("GOFLAGS") + " -mod=readonly")
+		env = append(env, "GOMODCACHE="+vendoredModCache, "GOFLAGS="+goflags)
+	}
+	return env
+}
+
+// projectModCache returns where "otel vendor" places the module cache, i.e.
+// next to go.mod of the main module. The main module is always the one of the
+// working directory, no matter which packages are built.
+func projectModCache() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errc.New(errc.ErrGetwd, err.Error())
+	}
+	gomod, err := findGoMod(wd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(gomod), VendoredModCache), nil
+}
+
+// initOfflineModCache switches to the module cache prepared by "otel vendor" in
+// offline mode if there is one
+func (dp *DepProcessor) initOfflineModCache() error {
+	if !config.GetConf().Offline || dp.vendorOnly {
+		return nil
+	}
+	modCache, err := projectModCache()
+	if err != nil {
+		return err
+	}
+	if util.PathNotExists(modCache) {
+		return nil
+	}
+	vendoredModCache = modCache
+	// -mod=vendor of the build command would take precedence over GOFLAGS
+	dp.goBuildCmd, _ = stripGoFlag(dp.goBuildCmd, "mod")
+	util.Log("Use vendored module cache %s", modCache)
+	return nil
+}
+
+// addVendoredSums adds checksums recorded by "otel vendor" to the private
+// go.sum, modules that the project does not require are missing otherwise
+func (dp *DepProcessor) addVendoredSums() error {
+	vendored, err := util.ReadFile(filepath.Join(vendoredModCache,
+		util.GoSumFile))
+	if err != nil {
+		return err
+	}
+	gosum := strings.TrimSuffix(dp.getModfile(), ".mod") + ".sum"
+	content := ""
+	if util.PathExists(gosum) {
+		content, err = util.ReadFile(gosum)
+		if err != nil {
+			return err
+		}
+	}
+	known := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		known[line] = true
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	for _, line := range strings.Split(vendored, "\n") {
+		if line == "" || known[line] {
+			continue
+		}
+		known[line] = true
+		content += line + "\n"
+	}
+	_, err = util.WriteFile(gosum, content)
+	if err != nil {
+		return err
+	}
+	util.Log("Add vendored checksums to %s", gosum)
+	return nil
+}
+
+// checkOfflineDeps makes sure that all required modules are available in the
+// module cache
+func (dp *DepProcessor) checkOfflineDeps() error {
+	mf, err := parseGoMod(dp.getModfile())
+	if err != nil {
+		return err
+	}
+	missing, err := dp.findMissingCached(mf)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	util.Log("Missing modules in offline mode: %v", missing)
+	name, _ := util.GetToolName()
+	return errc.New(errc.ErrPreprocess,
+		fmt.Sprintf("%d modules are missing in offline mode, run \"%s vendor\""+
+			" or \"go mod download\" on a connected machine to get them",
+			len(missing), name)).
+		With("modules", strings.Join(missing, " "))
+}
+
+// listedModule is what "go list -m -json" tells about a module
+type listedModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Dir     string
+	Replace *listedModule
+	Error   *struct{ Err string }
+}
+
+// cached returns the module version that is looked for in the module cache,
+// it's false if the module is always available
+func (m *listedModule) cached() (module.Version, bool) {
+	if m.Main || strings.HasPrefix(m.Path, pkgPrefix) {
+		return module.Version{}, false
+	}
+	if m.Replace != nil {
+		// Local replacements are always available, while versioned
+		// replacements redirect to other modules
+		if m.Replace.Version == "" {
+			return module.Version{}, false
+		}
+		return module.Version{Path: m.Replace.Path, Version: m.Replace.Version},
+			true
+	}
+	return module.Version{Path: m.Path, Version: m.Version}, true
+}
+
+// listBuildList returns all modules in the build list of the given modfile,
+// modules that cannot be loaded are reported with an error rather than failing
+// the command
+func (dp *DepProcessor) listBuildList(gomod string) ([]*listedModule, error) {
+	args := []string{"list", "-m", "-e", "-json", "-mod=mod",
+		"-" + goFlagModfile + "=" + gomod}
+	if len(dp.overlays) > 0 {
+		args = append(args, "-"+goFlagOverlay+"="+dp.overlay)
+	}
+	args = append(args, "all")
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dp.getGoModDir()
+	cmd.Env = append(os.Environ(), offlineEnv()...)
+	if dp.workModfile != "" {
+		// The modfile is a copy of our own module in workspace mode
+		cmd.Dir = filepath.Dir(dp.workModfile)
+		cmd.Env = append(cmd.Env, "GOWORK=off")
+	}
+	// Only the standard output is JSON
+	out, err := cmd.Output()
+	if err != nil {
+		msg := err.Error()
+		if ee, ok := err.(*exec.ExitError); ok {
+			msg = string(ee.Stderr)
+		}
+		return nil, errc.New(errc.ErrRunCmd, msg).
+			With("command", fmt.Sprintf("%v", args))
+	}
+	mods := []*listedModule{}
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	for {
+		mod := &listedModule{}
+		err = dec.Decode(mod)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errc.New(errc.ErrInvalidJSON, err.Error())
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// otelModules returns paths of the local otel modules in the private go.mod,
+// namely the pkg module and rule modules, and requirements of them
+func (dp *DepProcessor) otelModules(mf *modfile.File) ([]string,
+	[]*modfile.Require, error) {
+	paths := []string{}
+	reqs := []*modfile.Require{}
+	for _, r := range mf.Replace {
+		if r.New.Version != "" || !strings.HasPrefix(r.Old.Path, pkgPrefix) {
+			continue
+		}
+		dir := r.New.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(dp.getGoModDir(), dir)
+		}
+		gomod := filepath.Join(dir, util.GoModFile)
+		if util.PathNotExists(gomod) {
+			continue
+		}
+		otelMod, err := parseGoMod(gomod)
+		if err != nil {
+			return nil, nil, err
+		}
+		paths = append(paths, r.Old.Path)
+		reqs = append(reqs, otelMod.Require...)
+	}
+	return paths, reqs, nil
+}
+
+// writeOfflineModfile writes a copy of the private go.mod that also requires
+// the otel modules, so that the build list is the one that the build will end
+// up with. The private go.mod itself requires them only after go mod tidy,
+// which is exactly what fails on missing modules.
+func (dp *DepProcessor) writeOfflineModfile(otelPaths []string) (string,
+	error) {
+	mf, err := parseGoMod(dp.getModfile())
+	if err != nil {
+		return "", err
+	}
+	for _, path := range otelPaths {
+		// Replaced modules without a version are required by the zero
+		// pseudo-version, as go mod tidy does
+		err = mf.AddRequire(path, "v0.0.0-00010101000000-000000000000")
+		if err != nil {
+			return "", errc.New(errc.ErrPreprocess, err.Error())
+		}
+	}
+	bs, err := mf.Format()
+	if err != nil {
+		return "", errc.New(errc.ErrPreprocess, err.Error())
+	}
+	gomod := filepath.Join(filepath.Dir(dp.getModfile()), "offline.mod")
+	_, err = util.WriteFile(gomod, string(bs))
+	if err != nil {
+		return "", err
+	}
+	gosum := strings.TrimSuffix(dp.getModfile(), ".mod") + ".sum"
+	if util.PathExists(gosum) {
+		err = util.CopyFile(gosum, strings.TrimSuffix(gomod, ".mod")+".sum")
+		if err != nil {
+			return "", err
+		}
+	}
+	return gomod, nil
+}
+
+// loadBuildList returns the build list that the build will end up with, as
+// well as requirements of the otel modules
+func (dp *DepProcessor) loadBuildList(mf *modfile.File) ([]*listedModule,
+	[]*modfile.Require, error) {
+	otelPaths, otelReqs, err := dp.otelModules(mf)
+	if err != nil {
+		return nil, nil, err
+	}
+	gomod, err := dp.writeOfflineModfile(otelPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	mods, err := dp.listBuildList(gomod)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mods, otelReqs, nil
+}
+
+// findMissingCached returns modules in the build list that are not found in
+// the module cache. The go.mod of every module is needed to load the module
+// graph, while the source is needed only for modules that packages are built
+// from, i.e. modules required by the main module and by the otel modules.
+func (dp *DepProcessor) findMissingCached(mf *modfile.File) ([]string, error) {
+	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil,
+		"go", "env", "GOMODCACHE")
+	if err != nil {
+		return nil, err
+	}
+	modCache := strings.TrimSpace(out)
+
+	mods, otelReqs, err := dp.loadBuildList(mf)
+	if err != nil {
+		return nil, err
+	}
+	required := map[string]bool{}
+	for _, r := range append(mf.Require, otelReqs...) {
+		required[r.Mod.Path] = true
+	}
+	missing := []string{}
+	for _, m := range mods {
+		mod, ok := m.cached()
+		if !ok {
+			continue
+		}
+		if m.Error != nil {
+			util.Log("Failed to load module %s: %s", mod, m.Error.Err)
+			missing = append(missing, mod.String())
+			continue
+		}
+		// The source is either extracted or still zipped in the cache
+		if !required[m.Path] || m.Dir != "" {
+			continue
+		}
+		escPath, err := module.EscapePath(mod.Path)
+		if err != nil {
+			return nil, errc.New(errc.ErrPreprocess, err.Error())
+		}
+		escVersion, err := module.EscapeVersion(mod.Version)
+		if err != nil {
+			return nil, errc.New(errc.ErrPreprocess, err.Error())
+		}
+		zip := filepath.Join(modCache, "cache", "download", escPath, "@v",
+			escVersion+".zip")
+		if util.PathNotExists(zip) {
+			missing = append(missing, mod.String())
+		}
+	}
+	return missing, nil
+}
+
+// writePkgFile places the generated file into the pkg module via overlay, rel
+// is the path relative to the module root. The extracted pkg module is shared
+// by builds and never written to, while vendored builds compile the pkg module
+// from the vendor directory instead.
+func (dp *DepProcessor) writePkgFile(rel, content string) error {
+	path := filepath.Join(dp.pkgLocalCache, rel)
+	if dp.vendorMode {
+		path = filepath.Join(dp.getGoModDir(), VendorDir,
+			filepath.FromSlash(pkgPrefix), rel)
+	}
+	return dp.writeOverlayFile(path, content)
+}
+
+// fetchVendoredDeps downloads everything that checkOfflineDeps looks for into
+// the module cache. The go.mod of every module in the build list is fetched by
+// loading it, while the source is fetched for required modules only.
+func (dp *DepProcessor) fetchVendoredDeps() error {
+	mf, err := parseGoMod(dp.getModfile())
+	if err != nil {
+		return err
+	}
+	mods, otelReqs, err := dp.loadBuildList(mf)
+	if err != nil {
+		return err
+	}
+	required := map[string]bool{}
+	for _, r := range append(mf.Require, otelReqs...) {
+		required[r.Mod.Path] = true
+	}
+	args := append([]string{"go", "mod", "download"}, dp.getGoFlags()...)
+	for _, m := range mods {
+		mod, ok := m.cached()
+		if ok && required[m.Path] {
+			args = append(args, mod.String())
+		}
+	}
+	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil, args...)
+	util.Log("Run go mod download: %v", out)
+	return err
+}
+
+// Vendor materializes all dependencies that instrumentation introduces into the
+// module cache of the project, so that it can be built in offline mode. It
+// prepares dependencies exactly like the build does, but nothing is built, and
+// all go commands use the module cache of the project, so that it ends up
+// with whatever offline builds need. The vendor directory and go.mod of the project are
+// left untouched, so that plain builds work as before.
+func Vendor() error {
+	modCache, err := projectModCache()
+	if err != nil {
+		return err
+	}
+	err = os.RemoveAll(modCache)
+	if err != nil {
+		return errc.New(errc.ErrRemoveAll, err.Error())
+	}
+	// The vendor directory of the project, if any, knows nothing about modules
+	// of instrumentation, they are downloaded from the network instead. Files
+	// are left writable so that the module cache can be removed as usual.
+	goflags := strings.TrimSpace(os.Getenv("GOFLAGS") + " -mod=mod -modcacherw")
+	for _, env := range [][2]string{
+		{"GOMODCACHE", modCache},
+		{"GOFLAGS", goflags},
+	} {
+		err = os.Setenv(env[0], env[1])
+		if err != nil {
+			return errc.New(errc.ErrInternal, err.Error())
+		}
+	}
+	dp := newDepProcessor()
+	dp.vendorOnly = true
+	cmd, _ := stripGoFlag(append([]string{"go", "build"}, os.Args[2:]...), "mod")
+	err = dp.init(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { dp.postProcess() }()
+	bundles, err := dp.prepareDeps()
+	if err != nil {
+		return err
+	}
+	// Checksums of modules that the project does not require are recorded in
+	// the private go.sum only
+	gosum := strings.TrimSuffix(dp.getModfile(), ".mod") + ".sum"
+	err = util.CopyFile(gosum, filepath.Join(modCache, util.GoSumFile))
+	if err != nil {
+		return err
+	}
+	util.Log("Vendored dependencies of %d instrumented packages into %s",
+		len(bundles), modCache)
+	fmt.Printf("Vendored dependencies of %d instrumented packages into %s\n",
+		len(bundles), modCache)
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestListedModuleCached(t *testing.T) {
+	tests := []struct {
+		name   string
+		mod    *listedModule
+		expect module.Version
+		cached bool
+	}{
+		{
+			name: "main module",
+			mod:  &listedModule{Path: "example.com/app", Main: true},
+		},
+		{
+			name: "pkg module",
+			mod:  &listedModule{Path: pkgPrefix + "/rules/http"},
+		},
+		{
+			name: "local replacement",
+			mod: &listedModule{Path: "example.com/lib", Version: "v1.0.0",
+				Replace: &listedModule{Path: "../lib"}},
+		},
+		{
+			name: "versioned replacement",
+			mod: &listedModule{Path: "example.com/lib", Version: "v1.0.0",
+				Replace: &listedModule{Path: "example.com/fork",
+					Version: "v1.0.1"}},
+			expect: module.Version{Path: "example.com/fork", Version: "v1.0.1"},
+			cached: true,
+		},
+		{
+			name:   "required module",
+			mod:    &listedModule{Path: "example.com/lib", Version: "v1.0.0"},
+			expect: module.Version{Path: "example.com/lib", Version: "v1.0.0"},
+			cached: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, cached := tt.mod.cached()
+			if mod != tt.expect || cached != tt.cached {
+				t.Errorf("expect (%v, %v), got (%v, %v)", tt.expect, tt.cached,
+					mod, cached)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOfflineModfile(t *testing.T) {
+	dir := t.TempDir()
+	gomod := filepath.Join(dir, "app", "go.mod")
+	writeTestFile(t, gomod, `module example.com/app
+
+go 1.23
+
+require example.com/lib v1.0.0
+
+replace `+pkgPrefix+` => ../pkg
+
+replace `+pkgPrefix+`/rules/http => `+filepath.Join(dir, "http")+`
+
+// Not extracted yet
+replace `+pkgPrefix+`/rules/grpc => ../grpc
+
+replace `+pkgPrefix+`/rules/fork => example.com/fork v1.0.0
+
+replace example.com/lib => ../lib
+`)
+	writeTestFile(t, filepath.Join(dir, "app", "go.sum"), "example.com/lib v1.0.0 h1:x=\n")
+	writeTestFile(t, filepath.Join(dir, "pkg", "go.mod"),
+		"module "+pkgPrefix+"\n\ngo 1.23\n\nrequire go.opentelemetry.io/otel v1.35.0\n")
+	writeTestFile(t, filepath.Join(dir, "http", "go.mod"),
+		"module "+pkgPrefix+"/rules/http\n\ngo 1.23\n\nrequire example.com/mux v1.2.0\n")
+	writeTestFile(t, filepath.Join(dir, "lib", "go.mod"),
+		"module example.com/lib\n\ngo 1.23\n\nrequire example.com/other v1.0.0\n")
+
+	dp := &DepProcessor{modulePath: gomod}
+	mf, err := parseGoMod(gomod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only local otel modules are taken, along with their requirements
+	paths, reqs, err := dp.otelModules(mf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectPaths := []string{pkgPrefix, pkgPrefix + "/rules/http"}
+	if !reflect.DeepEqual(paths, expectPaths) {
+		t.Errorf("expect otel modules %v, got %v", expectPaths, paths)
+	}
+	reqPaths := make([]string, 0, len(reqs))
+	for _, r := range reqs {
+		reqPaths = append(reqPaths, r.Mod.String())
+	}
+	expectReqs := []string{"go.opentelemetry.io/otel@v1.35.0",
+		"example.com/mux@v1.2.0"}
+	if !reflect.DeepEqual(reqPaths, expectReqs) {
+		t.Errorf("expect requirements %v, got %v", expectReqs, reqPaths)
+	}
+
+	// The copy requires them as go mod tidy would, and keeps go.sum
+	offline, err := dp.writeOfflineModfile(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offline != filepath.Join(dir, "app", "offline.mod") {
+		t.Errorf("unexpected offline modfile %s", offline)
+	}
+	omf, err := parseGoMod(offline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	required := map[string]string{}
+	for _, r := range omf.Require {
+		required[r.Mod.Path] = r.Mod.Version
+	}
+	const zero = "v0.0.0-00010101000000-000000000000"
+	if required[pkgPrefix] != zero || required[pkgPrefix+"/rules/http"] != zero ||
+		required["example.com/lib"] != "v1.0.0" {
+		t.Errorf("unexpected requirements %v", required)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "app", "offline.sum")); err != nil {
+		t.Errorf("expect go.sum to be copied: %v", err)
+	}
+}
+
+func TestAddVendoredSums(t *testing.T) {
+	dir := t.TempDir()
+	old := vendoredModCache
+	vendoredModCache = filepath.Join(dir, VendoredModCache)
+	defer func() { vendoredModCache = old }()
+	writeTestFile(t, filepath.Join(vendoredModCache, "go.sum"),
+		"example.com/lib v1.0.0 h1:x=\nexample.com/mux v1.2.0 h1:y=\n")
+	// The private go.sum may lack the trailing newline
+	writeTestFile(t, filepath.Join(dir, "otel.sum"),
+		"example.com/lib v1.0.0 h1:x=")
+
+	dp := &DepProcessor{modfile: filepath.Join(dir, "otel.mod")}
+	err := dp.addVendoredSums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "otel.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "example.com/lib v1.0.0 h1:x=\nexample.com/mux v1.2.0 h1:y=\n"
+	if string(content) != expect {
+		t.Errorf("expect %q, got %q", expect, content)
+	}
+}
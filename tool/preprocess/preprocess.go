@@ -22,6 +22,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 
@@ -45,39 +46,79 @@ import (
 const (
 	OtelPkgDir       = "otel_pkg"
 	OtelImporter     = "otel_importer.go"
+	OtelTestImporter = "otel_importer_test.go"
+	OtelEnvDefaults  = "otel_env_defaults.go"
+	OtelSampling     = "otel_sampling_rules.go"
+	OtelBuildAttrs   = "otel_build_attributes.go"
+	OtelManifest     = "otel_manifest.go"
 	OtelRuleCache    = "rule_cache"
-	OtelBackups      = "backups"
-	OtelBackupSuffix = ".bk"
 	DryRunLog        = "dry_run.log"
 	CompileRemix     = "remix"
 	VendorDir        = "vendor"
 	GoCacheDir       = "gocache"
+	// Same as testaccess.IS_IN_TEST, which makes the otel setup export spans
+	// and metrics in memory
+	testaccessEnv = "IN_OTEL_TEST"
 )
 
 type DepProcessor struct {
-	backups       map[string]string
 	moduleName    string // Module name from go.mod
 	modulePath    string // Where go.mod is located
 	goBuildCmd    []string
 	vendorMode    bool
-	pkgLocalCache string // Local module cache path of alibaba-otel pkg module
-	otelImporter  string // Path to the otel_importer.go file
+	vendorOnly    bool              // Vendor dependencies without building, i.e. "otel vendor"
+	pkgLocalCache string            // Local module cache path of alibaba-otel pkg module
+	otelImporter  string            // Path to the otel_importer.go file
+	testImporters []*testImporter   // Importers of packages under test
+	decisions     []*MatchDecision  // Match decisions of the latest match
+	conflicts     []*RuleConflict   // Rule conflicts of the latest match
+	modfile       string            // Private copy of go.mod, empty in workspace mode
+	workfile      string            // Private go.work in workspace mode
+	workModfile   string            // go.mod of our own module in workspace mode
+	userModfile   string            // The -modfile specified by the user
+	overlay       string            // Path to the overlay file
+	overlays      map[string]string // Generated files keyed by where they are placed
+	gcflags       []string          // Values of -gcflags of the build in order
+	// Patterns of instrumented packages for -gcflags, keyed by import paths,
+	// along with the index of -gcflags in effect for them
+	gcflagsMarks map[string]map[string]int
+	ruleSetFlags []string // -gcflags carrying the rule set of every package
+}
+
+// testImporter is the otel_importer_test.go file of the package under test.
+// Test binaries have no main package of their own, the importer is compiled
+// into the package under test instead, and only into its test binary as it's
+// a test file.
+type testImporter struct {
+	path       string // Path to the otel_importer_test.go file
+	pkgName    string // Name of the package under test
+	importPath string // Import path of the package under test
 }
 
 func newDepProcessor() *DepProcessor {
 	dp := &DepProcessor{
-		backups:       map[string]string{},
 		vendorMode:    false,
 		pkgLocalCache: "",
 		otelImporter:  "",
+		overlays:      map[string]string{},
 	}
 	return dp
 }
 
 func (dp *DepProcessor) String() string {
-	return fmt.Sprintf("moduleName: %s, modulePath: %s, goBuildCmd: %v, vendorMode: %v, pkgLocalCache: %s, otelImporter: %s",
+	return fmt.Sprintf("moduleName: %s, modulePath: %s, goBuildCmd: %v, vendorMode: %v, pkgLocalCache: %s, otelImporter: %s, testImporters: %d, userModfile: %s",
 		dp.moduleName, dp.modulePath, dp.goBuildCmd, dp.vendorMode,
-		dp.pkgLocalCache, dp.otelImporter)
+		dp.pkgLocalCache, dp.otelImporter, len(dp.testImporters),
+		dp.userModfile)
+}
+
+// isTest tells if we are building test binaries, i.e. "otel go test"
+func (dp *DepProcessor) isTest() bool {
+	return isGoTest(dp.goBuildCmd)
+}
+
+func isGoTest(goBuildCmd []string) bool {
+	return len(goBuildCmd) > 1 && goBuildCmd[1] == "test"
 }
 
 func (dp *DepProcessor) getGoModPath() string {
@@ -102,6 +143,7 @@ func runCmdCombinedOutput(dir string, env []string, args ...string) (string, err
 	cmd := exec.Command(path, args...)
 	cmd.Dir = dir
 	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = append(cmd.Env, offlineEnv()...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", errc.New(errc.ErrRunCmd, string(out)).
@@ -138,11 +180,9 @@ func parseGoMod(gomod string) (*modfile.File, error) {
 	return modFile, nil
 }
 
-func (dp *DepProcessor) initCmd() {
-	// There is a tricky, all arguments after the otel tool itself are saved for
-	// later use, which means the subcommand "go build" itself are also included
-	dp.goBuildCmd = make([]string, len(os.Args)-1)
-	copy(dp.goBuildCmd, os.Args[1:])
+func (dp *DepProcessor) initCmd(goBuildCmd []string) {
+	dp.goBuildCmd = make([]string, len(goBuildCmd))
+	copy(dp.goBuildCmd, goBuildCmd)
 	util.AssertGoBuild(dp.goBuildCmd)
 }
 
@@ -175,13 +215,20 @@ func findMainDir(pkgs []*packages.Package) (string, error) {
 
 func (dp *DepProcessor) initMod() (err error) {
 	// Find compiling module and package information from the build command
-	pkgs, err := findModule(dp.goBuildCmd)
+	pkgs, err := findModule(dp.goBuildCmd, dp.getGoFlags())
 	if err != nil {
 		return err
 	}
 	util.Log("Find Go packages %v", util.Jsonify(pkgs))
 	for _, pkg := range pkgs {
 		util.Log("Find Go package %v", util.Jsonify(pkg))
+		if dp.isTest() && pkg.Module != nil {
+			// Test the module, each package under test gets its own importer
+			dp.moduleName = pkg.Module.Path
+			dp.modulePath = pkg.Module.GoMod
+			dp.addTestImporter(pkg)
+			continue
+		}
 		if pkg.GoFiles == nil {
 			continue
 		}
@@ -220,9 +267,9 @@ func (dp *DepProcessor) initMod() (err error) {
 				}
 				dp.moduleName = modfile.Module.Mod.Path
 				// We generate additional source file(otel_importer.go) in the
-				// same directory as the go.mod file, we should append this file
-				// into build commands to make sure it is compiled together with
-				// the original source files.
+				// same directory as the source files via overlay, we should
+				// append this file into build commands to make sure it is
+				// compiled together with the original source files.
 				found := false
 				for _, cmd := range dp.goBuildCmd {
 					if strings.Contains(cmd, OtelImporter) {
@@ -232,8 +279,15 @@ func (dp *DepProcessor) initMod() (err error) {
 				}
 				if !found {
 					last := dp.goBuildCmd[len(dp.goBuildCmd)-1]
-					dp.otelImporter = filepath.Join(filepath.Dir(last), OtelImporter)
-					dp.goBuildCmd = append(dp.goBuildCmd, dp.otelImporter)
+					importer := filepath.Join(filepath.Dir(last), OtelImporter)
+					// Named files must be in the same directory from the view
+					// of the go command, i.e. the importer is named just like
+					// the source files, while the overlay needs its full path
+					dp.otelImporter, err = filepath.Abs(importer)
+					if err != nil {
+						return errc.New(errc.ErrAbsPath, err.Error())
+					}
+					dp.goBuildCmd = append(dp.goBuildCmd, importer)
 				}
 			}
 		}
@@ -241,7 +295,10 @@ func (dp *DepProcessor) initMod() (err error) {
 	if dp.moduleName == "" || dp.modulePath == "" {
 		return errc.New(errc.ErrPreprocess, "cannot find compiled module")
 	}
-	if dp.otelImporter == "" {
+	if dp.otelImporter == "" && len(dp.testImporters) == 0 {
+		if dp.isTest() {
+			return errc.New(errc.ErrPreprocess, "no test files")
+		}
 		return errc.New(errc.ErrPreprocess, "cannot place otel_importer.go file")
 	}
 
@@ -261,20 +318,56 @@ func (dp *DepProcessor) initMod() (err error) {
 	return nil
 }
 
-func (dp *DepProcessor) initBuildMode() {
-	// Check if the build mode
-	ignoreVendor := false
-	for _, arg := range dp.goBuildCmd {
-		// -mod=mod and -mod=readonly tells the go command to ignore the vendor
-		// directory. We should not use the vendor directory in this case.
-		if strings.HasPrefix(arg, "-mod=mod") ||
-			strings.HasPrefix(arg, "-mod=readonly") {
-			dp.vendorMode = false
-			ignoreVendor = true
-			break
+// addTestImporter places an importer into the package under test, packages
+// without test files are not tested, so they are skipped
+func (dp *DepProcessor) addTestImporter(pkg *packages.Package) {
+	dir := pkg.Dir
+	if dir == "" && len(pkg.GoFiles) > 0 {
+		dir = filepath.Dir(pkg.GoFiles[0])
+	}
+	if dir == "" {
+		return
+	}
+	tests, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if len(tests) == 0 {
+		return
+	}
+	for _, ti := range dp.testImporters {
+		if ti.importPath == pkg.PkgPath {
+			return
 		}
 	}
-	if !ignoreVendor {
+	dp.testImporters = append(dp.testImporters, &testImporter{
+		path:       filepath.Join(dir, OtelTestImporter),
+		pkgName:    pkg.Name,
+		importPath: pkg.PkgPath,
+	})
+}
+
+func (dp *DepProcessor) initBuildMode() error {
+	// Build modes other than exe, e.g. pie, c-shared and plugin, work as they
+	// are, except that plugins named by files are identified by the hash of
+	// their content, which the go command reads from disk regardless of the
+	// overlay, where our importer lives
+	_, buildMode := stripGoFlag(dp.goBuildCmd, "buildmode")
+	if buildMode != "" {
+		util.Log("Build mode %s", buildMode)
+	}
+	last := dp.goBuildCmd[len(dp.goBuildCmd)-1]
+	if buildMode == "plugin" && filepath.Base(last) == OtelImporter {
+		return errc.New(errc.ErrPreprocess,
+			"-buildmode=plugin requires a package rather than named files")
+	}
+	// Dependencies of instrumentation are vendored into a module cache of their
+	// own, the vendor directory belongs to the project and is never touched
+	if dp.vendorOnly || vendoredModCache != "" {
+		dp.vendorMode = false
+		return nil
+	}
+	// Check if the build mode
+	if ignoresVendor(dp.goBuildCmd) {
+		dp.vendorMode = false
+	} else {
 		// FIXME: vendor directory name can be anything, but we assume it's "vendor"
 		// for now
 		vendor := filepath.Join(dp.getGoModDir(), VendorDir)
@@ -284,6 +377,19 @@ func (dp *DepProcessor) initBuildMode() {
 	// additional dependencies online, which means all dependencies should be
 	// available in the vendor directory. This requires users to add these
 	// dependencies proactively
+	return nil
+}
+
+func ignoresVendor(goBuildCmd []string) bool {
+	for _, arg := range goBuildCmd {
+		// -mod=mod and -mod=readonly tells the go command to ignore the vendor
+		// directory. We should not use the vendor directory in this case.
+		if strings.HasPrefix(arg, "-mod=mod") ||
+			strings.HasPrefix(arg, "-mod=readonly") {
+			return true
+		}
+	}
+	return false
 }
 
 func (dp *DepProcessor) initSignalHandler() {
@@ -301,14 +407,29 @@ func (dp *DepProcessor) initSignalHandler() {
 	}()
 }
 
-func (dp *DepProcessor) init() error {
-	dp.initCmd()
-	err := dp.initMod()
+func (dp *DepProcessor) init(goBuildCmd []string) error {
+	dp.initCmd(goBuildCmd)
+	err := dp.initModfile()
+	if err != nil {
+		return err
+	}
+	dp.initGcflags()
+	err = dp.initOfflineModCache()
+	if err != nil {
+		return err
+	}
+	err = dp.initMod()
+	if err != nil {
+		return err
+	}
+	err = dp.initBuildMode()
 	if err != nil {
 		return err
 	}
-	dp.initBuildMode()
 	dp.initSignalHandler()
+	if config.GetConf().Offline {
+		util.Log("Offline mode, run go commands with %v", offlineEnv())
+	}
 	// Once all the initialization is done, let's log the configuration
 	util.Log("ToolVersion: %s", config.ToolVersion)
 	util.Log("%s", dp.String())
@@ -323,45 +444,7 @@ func (dp *DepProcessor) postProcess() {
 		return
 	}
 
-	_ = os.RemoveAll(dp.otelImporter)
-
 	_ = os.RemoveAll(dp.generatedOf(OtelPkgDir))
-
-	// Restore everything we have modified during instrumentation
-	_ = dp.restoreBackupFiles()
-}
-
-func (dp *DepProcessor) backupFile(origin string) error {
-	util.GuaranteeInPreprocess()
-	backup := filepath.Base(origin) + OtelBackupSuffix
-	backup = util.GetLogPath(filepath.Join(OtelBackups, backup))
-	err := os.MkdirAll(filepath.Dir(backup), 0777)
-	if err != nil {
-		return errc.New(errc.ErrMkdirAll, err.Error())
-	}
-	if _, exist := dp.backups[origin]; !exist {
-		err = util.CopyFile(origin, backup)
-		if err != nil {
-			return err
-		}
-		dp.backups[origin] = backup
-		util.Log("Backup %v", origin)
-	} else if config.GetConf().Verbose {
-		util.Log("Backup %v already exists", origin)
-	}
-	return nil
-}
-
-func (dp *DepProcessor) restoreBackupFiles() error {
-	util.GuaranteeInPreprocess()
-	for origin, backup := range dp.backups {
-		err := util.CopyFile(backup, origin)
-		if err != nil {
-			return err
-		}
-		util.Log("Restore %v", origin)
-	}
-	return nil
 }
 
 func getCompileCommands() ([]string, error) {
@@ -478,10 +561,12 @@ func getCompileCommands() ([]string, error) {
 // Directory and file names that begin with "." or "_" are ignored
 // by the go tool, as are directories named "testdata".
 
-func tryLoadPackage(path string) ([]*packages.Package, error) {
+func tryLoadPackage(path string, goFlags []string) ([]*packages.Package, error) {
 	cfg := &packages.Config{
 		// Change it unless you know what you are doing
-		Mode: packages.NeedModule | packages.NeedFiles | packages.NeedName,
+		Mode:       packages.NeedModule | packages.NeedFiles | packages.NeedName,
+		Env:        append(os.Environ(), offlineEnv()...),
+		BuildFlags: goFlags,
 	}
 
 	pkgs, err := packages.Load(cfg, path)
@@ -491,7 +576,7 @@ func tryLoadPackage(path string) ([]*packages.Package, error) {
 	return pkgs, nil
 }
 
-func findModule(buildCmd []string) ([]*packages.Package, error) {
+func findModule(buildCmd []string, goFlags []string) ([]*packages.Package, error) {
 	candidates := make([]*packages.Package, 0)
 	found := false
 
@@ -502,7 +587,8 @@ func findModule(buildCmd []string) ([]*packages.Package, error) {
 		// Stop canary when we see a build flag or a "build" command
 		if strings.HasPrefix("-", buildArg) ||
 			buildArg == "build" ||
-			buildArg == "install" {
+			buildArg == "install" ||
+			buildArg == "test" {
 			break
 		}
 
@@ -524,7 +610,7 @@ func findModule(buildCmd []string) ([]*packages.Package, error) {
 		// because we dont know what the build argument is. One exception is
 		// when we already found packages, in this case, we expect subsequent
 		// build arguments are packages, so we should not tolerate any error.
-		pkgs, err := tryLoadPackage(buildArg)
+		pkgs, err := tryLoadPackage(buildArg, goFlags)
 		if err != nil {
 			if found {
 				// If packages are already found, we expect subsequent build
@@ -546,7 +632,7 @@ func findModule(buildCmd []string) ([]*packages.Package, error) {
 	// If no import paths are given, the action applies to the package in the
 	// current directory.
 	if !found {
-		pkgs, err := tryLoadPackage(".")
+		pkgs, err := tryLoadPackage(".", goFlags)
 		if err != nil {
 			return nil, err
 		}
@@ -565,16 +651,20 @@ func findModule(buildCmd []string) ([]*packages.Package, error) {
 }
 
 // runDryBuild runs a dry build to get all dependencies needed for the project.
-func runDryBuild(goBuildCmd []string) ([]string, error) {
+func runDryBuild(goBuildCmd []string, goFlags []string) ([]string, error) {
 	dryRunLog, err := os.Create(util.GetLogPath(DryRunLog))
 	if err != nil {
 		return nil, errc.New(errc.ErrCreateFile, err.Error())
 	}
-	// The full build command is: "go build/install -a -x -n  {...}"
+	// The full build command is: "go build/install/test -a -x -n  {...}"
 	args := []string{}
-	args = append(args, goBuildCmd[:2]...)             // go build/install
+	args = append(args, goBuildCmd[:2]...)             // go build/install/test
 	args = append(args, []string{"-a", "-x", "-n"}...) // -a -x -n
+	args = append(args, goFlags...)                    // -modfile -overlay
 	args = append(args, goBuildCmd[2:]...)             // {...} remaining
+	if isGoTest(goBuildCmd) {
+		args = stripCoverFlags(args)
+	}
 	util.AssertGoBuild(goBuildCmd)
 	util.AssertGoBuild(args)
 
@@ -584,6 +674,7 @@ func runDryBuild(goBuildCmd []string) ([]string, error) {
 	// This is a little anti-intuitive as the error message is not printed to
 	// the stderr, instead it is printed to the stdout, only the build tool
 	// knows the reason why.
+	cmd.Env = append(os.Environ(), offlineEnv()...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = dryRunLog
 	// @@Note that dir should not be set, as the dry build should be run in the
@@ -603,21 +694,76 @@ func runDryBuild(goBuildCmd []string) ([]string, error) {
 	return compileCmds, nil
 }
 
+// stripCoverFlags removes coverage flags from the go test command. Coverage
+// rewrites source files of covered packages into the temporary work directory,
+// which does not exist in the dry build, we match rules against the original
+// files instead, the instrument phase then finds them by line directives of
+// the rewritten files.
+func stripCoverFlags(args []string) []string {
+	stripped := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			stripped = append(stripped, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "cover":
+			continue
+		case "covermode", "coverpkg", "coverprofile":
+			if !hasValue {
+				i++ // Skip the value as well
+			}
+			continue
+		}
+		stripped = append(stripped, arg)
+	}
+	return stripped
+}
+
 func (dp *DepProcessor) runModTidy() error {
-	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil,
-		"go", "mod", "tidy")
+	// Our own module is tidied in workspace mode, which is done by itself as
+	// go mod tidy always ignores the workspace
+	if dp.workModfile != "" {
+		err := dp.writeWorkDeps()
+		if err != nil {
+			return err
+		}
+		out, err := runCmdCombinedOutput(filepath.Dir(dp.workModfile),
+			[]string{"GOWORK=off"}, "go", "mod", "tidy")
+		util.Log("Run go mod tidy: %v", out)
+		return err
+	}
+	args := append([]string{"go", "mod", "tidy"}, dp.getGoFlags()...)
+	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil, args...)
 	util.Log("Run go mod tidy: %v", out)
 	return err
 }
 
 func (dp *DepProcessor) runModVendor() error {
-	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil,
-		"go", "mod", "vendor")
+	args := append([]string{"go", "mod", "vendor"}, dp.getGoFlags()...)
+	out, err := runCmdCombinedOutput(dp.getGoModDir(), nil, args...)
 	util.Log("Run go mod vendor: %v", out)
 	return err
 }
 
 func (dp *DepProcessor) refreshDeps() error {
+	// Tell what is missing before the go command fails on the first one
+	if config.GetConf().Offline {
+		err := dp.checkOfflineDeps()
+		if err != nil {
+			return err
+		}
+	}
+	// Fetch what offline builds look for at this point
+	if dp.vendorOnly {
+		err := dp.fetchVendoredDeps()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Run go mod tidy to remove unused dependencies
 	err := dp.runModTidy()
 	if err != nil {
@@ -636,7 +782,7 @@ func (dp *DepProcessor) refreshDeps() error {
 }
 
 func getTempGoCache() (string, error) {
-	goCachePath, err := filepath.Abs(filepath.Join(util.TempBuildDir, GoCacheDir))
+	goCachePath, err := filepath.Abs(util.GetBuildDirWith(GoCacheDir))
 	if err != nil {
 		return "", err
 	}
@@ -654,40 +800,54 @@ func buildGoCacheEnv(value string) []string {
 	return []string{"GOCACHE=" + value}
 }
 
-func runBuildWithToolexec(goBuildCmd []string) error {
+func runBuildWithToolexec(goBuildCmd []string, goFlags []string) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return errc.New(errc.ErrGetExecutable, err.Error())
 	}
-	// go build/install
+	// go build/install/test
 	args := []string{}
 	args = append(args, goBuildCmd[:2]...)
 	// Remix toolexec
 	args = append(args, "-toolexec="+exe+" "+CompileRemix)
 
-	// Leave the temporary compilation directory
-	args = append(args, util.BuildWork)
+	// Leave the temporary compilation directory, go test prints it among the
+	// test output, so we leave it only for debugging
+	if !isGoTest(goBuildCmd) || config.GetConf().Debug {
+		args = append(args, util.BuildWork)
+	}
 
-	// Force rebuilding
-	args = append(args, "-a")
+	// Force rebuilding if the build cache is off, otherwise the go command
+	// decides what to rebuild, see writeRuleSetFlags for details
+	if config.GetConf().IsCacheOff() {
+		args = append(args, "-a")
+	}
 
-	if config.GetConf().Debug {
-		// Disable compiler optimizations for debugging mode
-		args = append(args, "-gcflags=all=-N -l")
+	// The go test runs vet against what it compiles, i.e. the instrumented
+	// code, which is never what users want to check. It can be turned on again
+	// by passing -vet explicitly
+	if isGoTest(goBuildCmd) {
+		args = append(args, "-vet=off")
 	}
 
+	// Build with the private module files and generated sources
+	args = append(args, goFlags...)
+
 	// Append additional build arguments provided by the user
 	args = append(args, goBuildCmd[2:]...)
 
 	util.Log("Run toolexec build: %v", args)
 	util.AssertGoBuild(args)
 
-	// get the temporary build cache path
-	goCachePath, err := getTempGoCache()
+	goCachePath, err := getGoCache()
 	if err != nil {
 		return err
 	}
-	util.Log("Using isolated GOCACHE: %s", goCachePath)
+	util.Log("Using GOCACHE: %s", goCachePath)
+
+	if isGoTest(goBuildCmd) {
+		return runTestWithToolexec(args, buildGoCacheEnv(goCachePath))
+	}
 
 	// @@ Note that we should not set the working directory here, as the build
 	// with toolexec should be run in the same directory as the original build
@@ -697,6 +857,24 @@ func runBuildWithToolexec(goBuildCmd []string) error {
 	return err
 }
 
+// runTestWithToolexec runs the instrumented tests. Unlike builds, the output is
+// for users rather than for the debug log, and a failure of tests is reported
+// as it is, i.e. an *exec.ExitError, since the go command has already told why.
+func runTestWithToolexec(args []string, env []string) error {
+	// Spans and metrics are kept in memory for tests to inspect, unless users
+	// decide otherwise
+	if os.Getenv(testaccessEnv) == "" {
+		env = append(env, testaccessEnv+"=true")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = append(cmd.Env, offlineEnv()...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 func precheck() error {
 	// Check if the project is modularized
 	go11module := os.Getenv("GO111MODULE")
@@ -713,7 +891,7 @@ func precheck() error {
 		config.PrintVersion()
 		os.Exit(0)
 	}
-	if os.Args[2] != "build" && os.Args[2] != "install" {
+	if os.Args[2] != "build" && os.Args[2] != "install" && os.Args[2] != "test" {
 		// exec original go command
 		err := util.RunCmd(os.Args[1:]...)
 		if err != nil {
@@ -724,11 +902,38 @@ func precheck() error {
 	return nil
 }
 
+// isTrimPath tells if file system paths are removed from the resulting binary,
+// either by the build command or by GOFLAGS
+func isTrimPath(goBuildCmd []string) bool {
+	args := append(strings.Fields(os.Getenv("GOFLAGS")), goBuildCmd...)
+	for _, arg := range args {
+		switch strings.TrimLeft(arg, "-") {
+		case "trimpath", "trimpath=true":
+			return true
+		}
+	}
+	return false
+}
+
+// replaceRelativeTo returns the directory that local replacements are relative
+// to, or empty if they are absolute. The build info records them as they are,
+// so they are relative to the module directory with -trimpath, which is then
+// reproducible across machines. They are always absolute in workspace mode, as
+// go.mod is modified in place there
+func (dp *DepProcessor) replaceRelativeTo() string {
+	if dp.modfile == "" || !isTrimPath(dp.goBuildCmd) {
+		return ""
+	}
+	return dp.getGoModDir()
+}
+
 // addModReplace adds replace directives to the go.mod file. The fisrt parameter
 // is the path to the go.mod file, the second parameter is a map of replace
 // directives, where the key is the old import path and the value is a tuple
-// of the new import path and the version.
-func addModReplace(gomod string, replaceMap map[string][2]string) error {
+// of the new import path and the version. Local paths are made relative to
+// relativeTo if it's not empty.
+func addModReplace(gomod string, replaceMap map[string][2]string,
+	relativeTo string) error {
 	modfile, err := parseGoMod(gomod)
 	if err != nil {
 		return err
@@ -751,6 +956,16 @@ func addModReplace(gomod string, replaceMap map[string][2]string) error {
 				if err != nil {
 					return errc.New(errc.ErrAbsPath, err.Error())
 				}
+				if relativeTo != "" {
+					rel, err := filepath.Rel(relativeTo, b0)
+					if err == nil {
+						// Local paths must start with ./ or ../
+						if !strings.HasPrefix(rel, "..") {
+							rel = "." + string(filepath.Separator) + rel
+						}
+						b0 = rel
+					}
+				}
 			} else {
 				// replace mod => mod version
 			}
@@ -780,12 +995,155 @@ func addModReplace(gomod string, replaceMap map[string][2]string) error {
 func (dp *DepProcessor) saveDebugFiles() {
 	dir := filepath.Join(util.GetTempBuildDir(), "changed")
 	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return
+	}
+	if dp.modfile != "" {
+		_ = util.CopyFile(dp.modfile, filepath.Join(dir, util.GoModFile))
+	}
+	if dp.workfile != "" {
+		_ = util.CopyFile(dp.workfile, filepath.Join(dir, util.GoWorkFile))
+		_ = util.CopyFile(dp.workModfile, filepath.Join(dir, util.GoModFile))
+	}
+	if dp.otelImporter != "" {
+		_ = util.CopyFile(dp.overlays[dp.otelImporter],
+			filepath.Join(dir, OtelImporter))
+	}
+	for _, ti := range dp.testImporters {
+		name := strings.ReplaceAll(ti.importPath, "/", "_") + "_" + OtelTestImporter
+		_ = util.CopyFile(dp.overlays[ti.path], filepath.Join(dir, name))
+	}
+}
+
+// writeEnvDefaults regenerates the runtime environment defaults declared in the
+// project config into the extracted otel setup package, they are applied when
+// the instrumented binary starts.
+func (dp *DepProcessor) writeEnvDefaults() error {
+	defaults := config.GetConf().EnvDefaults
+	if len(defaults) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(defaults))
+	for k := range defaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package pkg\n\n"
+	content += "var envDefaults = map[string]string{\n"
+	for _, k := range keys {
+		content += fmt.Sprintf("\t%q: %q,\n", k, defaults[k])
+	}
+	content += "}\n"
+	err := dp.writePkgFile(OtelEnvDefaults, content)
+	if err != nil {
+		return err
+	}
+	util.Log("Apply runtime env defaults %v", keys)
+	return nil
+}
+
+// writeSamplingRules regenerates the sampling rules declared in the project
+// config into the extracted otel setup package, they are installed ahead of
+// the sampler configured by OTEL_TRACES_SAMPLER when the binary starts.
+func (dp *DepProcessor) writeSamplingRules() error {
+	rules := config.GetConf().SamplingRules
+	if len(rules) == 0 {
+		return nil
+	}
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package pkg\n\n"
+	content += "import \"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/core/sampler\"\n\n"
+	content += "var samplingRules = []sampler.Rule{\n"
+	for _, rule := range rules {
+		content += fmt.Sprintf("\t{Name: %q, Route: %q, Scope: %q, Ratio: %v, Rate: %v},\n",
+			rule.Name, rule.Route, rule.Scope, rule.Ratio, rule.Rate)
+	}
+	content += "}\n"
+	err := dp.writePkgFile(OtelSampling, content)
+	if err != nil {
+		return err
+	}
+	util.Log("Apply %d sampling rules", len(rules))
+	return nil
+}
+
+// detectBuildAttributes detects resource attributes of the main module from
+// its git repository, nothing is detected if the module is not versioned by
+// git or git is not installed.
+func (dp *DepProcessor) detectBuildAttributes() map[string]string {
+	attrs := map[string]string{}
+	dir := dp.getGoModDir()
+	out, err := runCmdCombinedOutput(dir, nil, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return attrs
+	}
+	attrs["vcs.ref.head.revision"] = strings.TrimSpace(out)
+	// The version is only known if the module is tagged
+	out, err = runCmdCombinedOutput(dir, nil, "git", "describe", "--tags",
+		"--dirty")
 	if err == nil {
-		for origin := range dp.backups {
-			util.CopyFile(origin, filepath.Join(dir, filepath.Base(origin)))
+		attrs["service.version"] = strings.TrimSpace(out)
+	}
+	return attrs
+}
+
+// writeBuildAttributes embeds resource attributes detected at build time into
+// the extracted otel setup package, they are overwritten by the ones set by
+// OTEL_RESOURCE_ATTRIBUTES at runtime.
+func (dp *DepProcessor) writeBuildAttributes() error {
+	attrs := dp.detectBuildAttributes()
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package pkg\n\n"
+	content += "var buildAttributes = map[string]string{\n"
+	for _, k := range keys {
+		content += fmt.Sprintf("\t%q: %q,\n", k, attrs[k])
+	}
+	content += "}\n"
+	err := dp.writePkgFile(OtelBuildAttrs, content)
+	if err != nil {
+		return err
+	}
+	util.Log("Embed build attributes %v", attrs)
+	return nil
+}
+
+// writeManifest generates the instrumentation manifest into the extracted otel
+// setup package, so that it's embedded into the binary and can be inspected at
+// runtime.
+func (dp *DepProcessor) writeManifest(report *BuildReport) error {
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package pkg\n\n"
+	content += "import \"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/manifest\"\n\n"
+	content += "var otelManifest = &manifest.Manifest{\n"
+	content += fmt.Sprintf("\tToolVersion: %q,\n", report.ToolVersion)
+	content += fmt.Sprintf("\tGoVersion: %q,\n", report.GoVersion)
+	content += "\tPackages: []manifest.Package{\n"
+	for _, pkg := range report.Instrumented {
+		content += fmt.Sprintf("\t\t{ImportPath: %q, Version: %q, Rules: []manifest.Rule{\n",
+			pkg.ImportPath, pkg.Version)
+		for _, rule := range pkg.Rules {
+			content += fmt.Sprintf("\t\t\t{Kind: %q, Target: %q, Version: %q, RuleFile: %q},\n",
+				rule.Kind, rule.Target, rule.Version, rule.RuleFile)
 		}
+		content += "\t\t}},\n"
+	}
+	content += "\t},\n}\n"
+	err := dp.writePkgFile(OtelManifest, content)
+	if err != nil {
+		return err
 	}
-	_ = util.CopyFile(dp.otelImporter, filepath.Join(dir, OtelImporter))
+	util.Log("Embed manifest of %d instrumented packages",
+		len(report.Instrumented))
+	return nil
 }
 
 //go:embed template.go
@@ -795,17 +1153,6 @@ func (dp *DepProcessor) newRuleImporterWith(bundles []*resource.RuleBundle) erro
 	importerTemplate = strings.ReplaceAll(importerTemplate,
 		util.GoBuildIgnoreComment, "")
 
-	// No rule bundles? We still need to generate the otel_importer.go file whose
-	// purpose is to import the fundamental dependencies
-	if len(bundles) == 0 {
-		_, err := util.WriteFile(dp.otelImporter, importerTemplate)
-		if err != nil {
-			return err
-		}
-		return nil
-	}
-
-	// Generate the otel_importer.go file with the rule bundles
 	paths := map[string]bool{}
 	for _, bundle := range bundles {
 		for _, funcRules := range bundle.File2FuncRules {
@@ -817,29 +1164,63 @@ func (dp *DepProcessor) newRuleImporterWith(bundles []*resource.RuleBundle) erro
 				}
 			}
 		}
+		for _, rule := range bundle.CallRules {
+			paths[rule.GetPath()] = true
+		}
 	}
-	content := importerTemplate
+	imports := ""
 	replaceMap := map[string][2]string{}
 	for path := range paths {
-		content += fmt.Sprintf("import _ %q\n", path)
+		imports += fmt.Sprintf("import _ %q\n", path)
 		t := strings.TrimPrefix(path, pkgPrefix)
 		replaceMap[path] = [2]string{filepath.Join(dp.pkgLocalCache, t), ""}
 	}
+	if dp.otelImporter != "" {
+		content := dp.renderImporter(bundles, imports, "main", "main")
+		err := dp.writeOverlayFile(dp.otelImporter, content)
+		if err != nil {
+			return err
+		}
+	}
+	for _, ti := range dp.testImporters {
+		content := dp.renderImporter(bundles, imports, ti.pkgName, ti.importPath)
+		err := dp.writeOverlayFile(ti.path, content)
+		if err != nil {
+			return err
+		}
+	}
+	// Add replace directives for all matched rules
+	err := addModReplace(dp.getModfile(), replaceMap, dp.replaceRelativeTo())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// renderImporter generates the importer that is compiled into the package with
+// the given name and import path, i.e. the main package, or the package under
+// test for test binaries. Even if there are no rule bundles, the importer is
+// still needed to import the fundamental dependencies
+func (dp *DepProcessor) renderImporter(bundles []*resource.RuleBundle,
+	imports, pkgName, importPath string) string {
+	content := strings.Replace(importerTemplate, "package main",
+		"package "+pkgName, 1)
+	content += imports
 	cnt := 0
 	for _, bundle := range bundles {
 		tag := ""
-		// If we occasionally instrument the main package, we don't need to add
-		// the linkname directive, as the target variables are already defined
-		// in the main package, adding new linkname for generated code will cause
-		// the symbol redefinition error.
-		if bundle.ImportPath != "main" {
+		// If we occasionally instrument the main package(or the package under
+		// test), we don't need to add the linkname directive, as the target
+		// variables are already defined in the package itself, adding new
+		// linkname for generated code will cause the symbol redefinition error.
+		if bundle.ImportPath != "main" && bundle.ImportPath != importPath {
 			tag = fmt.Sprintf("//go:linkname getstatck%d %s.OtelGetStackImpl\n",
 				cnt, bundle.ImportPath)
 		}
 		content += tag
 		s := fmt.Sprintf("var getstatck%d = debug.Stack\n", cnt)
 		content += s
-		if bundle.ImportPath != "main" {
+		if bundle.ImportPath != "main" && bundle.ImportPath != importPath {
 			tag = fmt.Sprintf("//go:linkname printstack%d %s.OtelPrintStackImpl\n",
 				cnt, bundle.ImportPath)
 		}
@@ -848,13 +1229,70 @@ func (dp *DepProcessor) newRuleImporterWith(bundles []*resource.RuleBundle) erro
 		content += s
 		cnt++
 	}
-	util.WriteFile(dp.otelImporter, content)
-	// Add replace directives for all matched rules
-	err := addModReplace(dp.getGoModPath(), replaceMap)
+	return content
+}
+
+// prepareDeps makes the dependencies introduced by instrumentation rules, i.e.
+// the pkg module and rule modules, available to the build, and returns the rules
+// matched against the final dependency graph
+func (dp *DepProcessor) prepareDeps() ([]*resource.RuleBundle, error) {
+	// Copy go.mod and add additional repalce directives for the pkg module
+	err := dp.rectifyMod()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	// Bake runtime env defaults from project config into the pkg module
+	err = dp.writeEnvDefaults()
+	if err != nil {
+		return nil, err
+	}
+	err = dp.writeSamplingRules()
+	if err != nil {
+		return nil, err
+	}
+	err = dp.writeBuildAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Two round of rule matching
+	//    {prepare->refresh}
+	//        1st match
+	//    {prepare->refresh}
+	//        2nd match
+	//    {prepare->refresh}
+	// Let's break it down a little bit. We first prepare the rule import,
+	// which is used to import foundational dependencies (e.g., otel, as we
+	// will instrument the otel SDK itself). Then, we perform a refresh to
+	// ensure dependencies are ready and proceed to the 1st match. During
+	// this phase, some rules matching specific criteria are identified. We
+	// then update the rule import again to include these newly matched rules.
+	// Since these rules may (and likely will) break the original dependency
+	// graph, a 2nd match is required to resolve the final set of rules.
+	// These final rules are used to perform a final update of the rule import.
+	// At this point, all preparations are complete, and the process can
+	// advance to the second stage: instrumentation.
+	bundles := make([]*resource.RuleBundle, 0)
+	for i := 0; i < 3; i++ {
+		err = dp.newRuleImporterWith(bundles)
+		if err != nil {
+			return nil, err
+		}
+
+		err = dp.refreshDeps()
+		if err != nil {
+			return nil, err
+		}
+		if i == 2 {
+			continue
+		}
+		bundles, err = dp.matchRules()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bundles, nil
 }
 
 func Preprocess() error {
@@ -866,55 +1304,44 @@ func Preprocess() error {
 
 	dp := newDepProcessor()
 
-	err = dp.init()
+	// There is a tricky, all arguments after the otel tool itself are saved for
+	// later use, which means the subcommand "go build" itself are also included
+	err = dp.init(os.Args[1:])
 	if err != nil {
 		return err
 	}
 	defer func() { dp.postProcess() }()
+	var report *BuildReport
 	{
 		defer util.PhaseTimer("Preprocess")()
 
-		// Backup go.mod and add additional repalce directives for the pkg module
-		err = dp.rectifyMod()
+		// Make dependencies introduced by rules available to the build
+		var bundles []*resource.RuleBundle
+		bundles, err = dp.prepareDeps()
 		if err != nil {
 			return err
 		}
 
-		// Two round of rule matching
-		//    {prepare->refresh}
-		//        1st match
-		//    {prepare->refresh}
-		//        2nd match
-		//    {prepare->refresh}
-		// Let's break it down a little bit. We first prepare the rule import,
-		// which is used to import foundational dependencies (e.g., otel, as we
-		// will instrument the otel SDK itself). Then, we perform a refresh to
-		// ensure dependencies are ready and proceed to the 1st match. During
-		// this phase, some rules matching specific criteria are identified. We
-		// then update the rule import again to include these newly matched rules.
-		// Since these rules may (and likely will) break the original dependency
-		// graph, a 2nd match is required to resolve the final set of rules.
-		// These final rules are used to perform a final update of the rule import.
-		// At this point, all preparations are complete, and the process can
-		// advance to the second stage: instrumentation.
-		bundles := make([]*resource.RuleBundle, 0)
-		for i := 0; i < 3; i++ {
-			err = dp.newRuleImporterWith(bundles)
-			if err != nil {
-				return err
-			}
+		// Tell if matched rules conflict with each other
+		err = checkConflicts(dp.conflicts)
+		if err != nil {
+			return err
+		}
 
-			err = dp.refreshDeps()
-			if err != nil {
-				return err
-			}
-			if i == 2 {
-				continue
-			}
-			bundles, err = dp.matchRules()
-			if err != nil {
-				return err
-			}
+		// Generate the build report before rules are rectified, it's written
+		// only if the build succeeds
+		report = newBuildReport(dp.decisions)
+
+		// Embed what was instrumented into the binary
+		err = dp.writeManifest(report)
+		if err != nil {
+			return err
+		}
+
+		// Generate hooks for span rules, they are linked to target packages
+		err = dp.writeSpanHooks(bundles)
+		if err != nil {
+			return err
 		}
 
 		// Rectify file rules to make sure we can find them locally
@@ -929,6 +1356,13 @@ func Preprocess() error {
 			return err
 		}
 
+		// Identify rules of every package so that instrumented outputs can
+		// be cached
+		err = dp.writeRuleSetFlags(bundles)
+		if err != nil {
+			return err
+		}
+
 		// Retain otel rules and modified user files for debugging
 		dp.saveDebugFiles()
 	}
@@ -937,11 +1371,18 @@ func Preprocess() error {
 		defer util.PhaseTimer("Instrument")()
 
 		// Run go build with toolexec to start instrumentation
-		err = runBuildWithToolexec(dp.goBuildCmd)
+		goFlags := append(dp.getGoFlags(), dp.getGcflags()...)
+		err = runBuildWithToolexec(dp.goBuildCmd, goFlags)
 		if err != nil {
 			return err
 		}
 	}
 	util.Log("Build completed successfully")
+
+	// Tell what was instrumented in the build
+	err = dp.writeBuildReport(report)
+	if err != nil {
+		return err
+	}
 	return nil
 }
@@ -17,12 +17,16 @@ package preprocess
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/data"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
@@ -50,6 +54,14 @@ var otelDeps = map[string]string{
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric":            "v1.35.0",
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace":             "v1.35.0",
 	"go.opentelemetry.io/otel/exporters/zipkin":                         "v1.35.0",
+	"go.opentelemetry.io/otel/log":                                      "v0.11.0",
+	"go.opentelemetry.io/otel/sdk/log":                                  "v0.11.0",
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc":       "v0.11.0",
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp":       "v0.11.0",
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog":               "v0.11.0",
+	"go.opentelemetry.io/contrib/propagators/b3":                        "v1.35.0",
+	"go.opentelemetry.io/contrib/propagators/jaeger":                    "v1.35.0",
+	"go.opentelemetry.io/contrib/propagators/aws":                       "v1.35.0",
 }
 
 func extractGZip(data []byte, targetDir string) error {
@@ -130,30 +142,75 @@ func extractGZip(data []byte, targetDir string) error {
 	return nil
 }
 
-// Fetch the zipped pkg module from the embedded data section and extract it to
-// a temporary directory, then return the path to the pkg directory.
+// How long the lock of the shared pkg directory is held at most, it's taken
+// over afterwards, as the extracting process must have been killed
+const staleSharedPkgLock = 5 * time.Minute
+
+// findModCacheDir fetches the zipped pkg module from the embedded data section
+// and returns the path to the pkg directory. The pkg module is extracted only
+// once and shared by all builds, it must never be written to, files specific
+// to the build are placed via overlay instead. The directory is named after the
+// checksum of the embedded pkg, and extracted under the persistent build cache,
+// or the system temp directory if the cache is off. Its path never changes for
+// the same tool, so that packages of the pkg module, and hooks they provide,
+// are compiled only once with the build cache.
 func findModCacheDir() (string, error) {
 	bs, err := data.UseEmbededPkg()
 	if err != nil {
 		return "", errc.New(errc.ErrPreprocess,
 			fmt.Sprintf("error reading embedded pkg: %v", err))
 	}
-	tempPkg := util.GetTempBuildDirWith("alibaba-pkg")
-	if util.PathExists(tempPkg) {
-		_ = os.RemoveAll(tempPkg)
+	root := os.TempDir()
+	if conf := config.GetConf(); !conf.IsCacheOff() {
+		root, err = conf.GetCacheDir()
+		if err != nil {
+			return "", err
+		}
+	}
+	err = os.MkdirAll(root, 0755)
+	if err != nil {
+		return "", errc.New(errc.ErrMkdirAll, err.Error())
+	}
+	sum := sha256.Sum256(bs)
+	dir := filepath.Join(root, "alibaba-pkg-"+hex.EncodeToString(sum[:8]))
+	pkgDir := filepath.Join(dir, "pkg")
+	if util.PathExists(dir) {
+		return pkgDir, nil
 	}
-	err = extractGZip(bs, tempPkg)
+
+	unlock, err := util.LockFile(dir+".lock", staleSharedPkgLock)
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(tempPkg, "pkg"), nil
+	defer unlock()
+	// Extracted by another process while we were waiting for the lock
+	if util.PathExists(dir) {
+		return pkgDir, nil
+	}
+	// Extract into a temporary directory and rename it afterwards, so that the
+	// directory is either complete or absent
+	tempDir, err := os.MkdirTemp(root, ".alibaba-pkg-")
+	if err != nil {
+		return "", errc.New(errc.ErrMkdirAll, err.Error())
+	}
+	err = extractGZip(bs, tempDir)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", err
+	}
+	err = os.Rename(tempDir, dir)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", errc.New(errc.ErrInternal, err.Error())
+	}
+	return pkgDir, nil
 }
 
 // rectifyRule rectifies the file rules path to the local module cache path.
 func (dp *DepProcessor) rectifyRule(bundles []*resource.RuleBundle) error {
 	util.GuaranteeInPreprocess()
 	defer util.PhaseTimer("Fetch")()
-	modfile, err := parseGoMod(dp.getGoModPath())
+	modfile, err := parseGoMod(dp.getModfile())
 	if err != nil {
 		return err
 	}
@@ -161,7 +218,32 @@ func (dp *DepProcessor) rectifyRule(bundles []*resource.RuleBundle) error {
 	// rectify the custom rules.
 	replaceMap := map[string]string{}
 	for _, replace := range modfile.Replace {
-		replaceMap[replace.Old.Path] = replace.New.Path
+		path := replace.New.Path
+		// Local paths are relative to the module rather than where we are
+		if replace.New.Version == "" && !filepath.IsAbs(path) {
+			path = filepath.Join(dp.getGoModDir(), path)
+		}
+		replaceMap[replace.Old.Path] = path
+	}
+	return rectifyRulePaths(bundles, dp.pkgLocalCache, replaceMap)
+}
+
+// rectifyRulePaths rectifies paths of rules from import paths to where the hook
+// code is located locally, hooks of the pkg module are located in pkgDir, while
+// others are found by replaceMap, which maps import paths to local paths.
+func rectifyRulePaths(bundles []*resource.RuleBundle, pkgDir string,
+	replaceMap map[string]string) error {
+	resolve := func(path string) (string, error) {
+		if strings.HasPrefix(path, pkgPrefix) {
+			p := strings.TrimPrefix(path, pkgPrefix)
+			return filepath.Join(pkgDir, p), nil
+		}
+		p, exist := replaceMap[path]
+		if !exist {
+			return "", errc.New(errc.ErrPreprocess,
+				fmt.Sprintf("rule path %s is not found in go.mod file", path))
+		}
+		return p, nil
 	}
 	rectified := map[string]bool{}
 	for _, bundle := range bundles {
@@ -174,61 +256,56 @@ func (dp *DepProcessor) rectifyRule(bundles []*resource.RuleBundle) error {
 					if rectified[rule.GetPath()] {
 						continue
 					}
-					if strings.HasPrefix(rule.Path, pkgPrefix) {
-						p := strings.TrimPrefix(rule.Path, pkgPrefix)
-						p = filepath.Join(dp.pkgLocalCache, p)
-						rule.SetPath(p)
-						rectified[p] = true
-					} else {
-						p, exist := replaceMap[rule.Path]
-						if !exist {
-							return errc.New(errc.ErrPreprocess,
-								fmt.Sprintf("rule path %s is not found in go.mod file", rule.Path))
-						}
-						rule.SetPath(p)
-						rectified[p] = true
+					p, err := resolve(rule.Path)
+					if err != nil {
+						return err
 					}
+					rule.SetPath(p)
+					rectified[p] = true
 				}
 			}
 		}
+		for _, rule := range bundle.CallRules {
+			if rectified[rule.GetPath()] {
+				continue
+			}
+			// Call sites refer to hooks by import path rather than local path
+			rule.HookImportPath = rule.Path
+			p, err := resolve(rule.Path)
+			if err != nil {
+				return err
+			}
+			rule.SetPath(p)
+			rectified[p] = true
+		}
 		for _, fileRule := range bundle.FileRules {
 			if rectified[fileRule.GetPath()] {
 				continue
 			}
-			if strings.HasPrefix(fileRule.Path, pkgPrefix) {
-				p := strings.TrimPrefix(fileRule.Path, pkgPrefix)
-				p = filepath.Join(dp.pkgLocalCache, p)
-				fileRule.SetPath(p)
-				fileRule.FileName = filepath.Join(p, fileRule.FileName)
-				rectified[p] = true
-			} else {
-				p, exist := replaceMap[fileRule.Path]
-				if !exist {
-					return errc.New(errc.ErrPreprocess,
-						fmt.Sprintf("rule path %s is not found in go.mod file", fileRule.Path))
-				}
-				fileRule.SetPath(p)
-				fileRule.FileName = filepath.Join(p, fileRule.FileName)
-				rectified[p] = true
+			p, err := resolve(fileRule.Path)
+			if err != nil {
+				return err
 			}
+			fileRule.SetPath(p)
+			fileRule.FileName = filepath.Join(p, fileRule.FileName)
+			rectified[p] = true
 		}
 	}
 	return nil
 }
 
 func (dp *DepProcessor) rectifyMod() error {
-	// Backup go.mod and go.sum files
-	gomodDir := dp.getGoModDir()
-	files := []string{}
-	files = append(files, filepath.Join(gomodDir, util.GoModFile))
-	files = append(files, filepath.Join(gomodDir, util.GoSumFile))
-	files = append(files, filepath.Join(gomodDir, util.GoWorkSumFile))
-	for _, file := range files {
-		if util.PathExists(file) {
-			err := dp.backupFile(file)
-			if err != nil {
-				return err
-			}
+	// Copy go.mod and go.sum files, we never modify the original ones
+	err := dp.copyModFiles()
+	if err != nil {
+		return err
+	}
+	// Modules of instrumentation were verified when they were vendored, there
+	// is nowhere to verify them against in offline mode
+	if vendoredModCache != "" {
+		err = dp.addVendoredSums()
+		if err != nil {
+			return err
 		}
 	}
 	// Since we haven't published the alibaba-otel pkg module, we need to add
@@ -245,7 +322,7 @@ func (dp *DepProcessor) rectifyMod() error {
 	for path, version := range otelDeps {
 		replaceMap[path] = [2]string{path, version}
 	}
-	err := addModReplace(dp.getGoModPath(), replaceMap)
+	err = addModReplace(dp.getModfile(), replaceMap, dp.replaceRelativeTo())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,331 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Build Report
+//
+// The build report is a machine-readable summary of what was instrumented. It
+// is written under .otel-build, or to the path given by -report, once the build
+// succeeds, so that the release pipeline can diff it between builds to catch
+// instrumentation that is silently dropped after a dependency bump. Everything in the report is sorted
+// and free of build-specific paths to make it diff-friendly.
+
+const (
+	BuildReportFile    = "otel_report.json"
+	BuildReportSarif   = "otel_report.sarif"
+	BuildReportVersion = 1
+)
+
+type ReportRule struct {
+	Kind         string
+	Target       string
+	Function     string `json:",omitempty"`
+	ReceiverType string `json:",omitempty"`
+	StructType   string `json:",omitempty"`
+	FileName     string `json:",omitempty"`
+	Hook         string `json:",omitempty"`
+	OnEnter      string `json:",omitempty"`
+	OnExit       string `json:",omitempty"`
+	UseRaw       bool   `json:",omitempty"`
+	// Functions matched by the pattern func rule
+	Functions []string `json:",omitempty"`
+	Version   string   `json:",omitempty"`
+	GoVersion string   `json:",omitempty"`
+	RuleFile  string
+}
+
+type ReportPackage struct {
+	ImportPath string
+	Version    string `json:",omitempty"`
+	Rules      []*ReportRule
+}
+
+type ReportSkipped struct {
+	ImportPath string
+	Version    string `json:",omitempty"`
+	Rule       *ReportRule
+	Reason     string
+}
+
+type BuildReport struct {
+	ReportVersion int
+	ToolVersion   string
+	GoVersion     string
+	Instrumented  []*ReportPackage
+	Skipped       []*ReportSkipped
+}
+
+func newReportRule(rule resource.InstRule) *ReportRule {
+	rr := &ReportRule{
+		Kind:      ruleKind(rule),
+		Target:    describeRule(rule),
+		Version:   rule.GetVersion(),
+		GoVersion: rule.GetGoVersion(),
+		// Custom rule files are recorded by their base name, the location of
+		// the rule file is irrelevant to what was instrumented
+		RuleFile: filepath.Base(rule.GetRuleFile()),
+		Hook:     rule.GetPath(),
+	}
+	switch r := rule.(type) {
+	case *resource.InstCallRule:
+		rr.Function = r.Function
+		rr.ReceiverType = r.ReceiverType
+		rr.OnEnter = r.OnEnter
+		rr.OnExit = r.OnExit
+	case *resource.InstFuncRule:
+		rr.Function = r.Function
+		rr.ReceiverType = r.ReceiverType
+		rr.OnEnter = r.OnEnter
+		rr.OnExit = r.OnExit
+		rr.UseRaw = r.UseRaw
+	case *resource.InstStructRule:
+		rr.StructType = r.StructType
+	case *resource.InstFileRule:
+		rr.FileName = r.FileName
+	}
+	return rr
+}
+
+// newBuildReport generates the build report from match decisions, it must be
+// called before rules are rectified, as rectification rewrites the hook path
+// to the local path.
+func newBuildReport(decisions []*MatchDecision) *BuildReport {
+	report := &BuildReport{
+		ReportVersion: BuildReportVersion,
+		ToolVersion:   config.ToolVersion,
+		Instrumented:  make([]*ReportPackage, 0),
+		Skipped:       make([]*ReportSkipped, 0),
+	}
+	pkgs := make(map[string]*ReportPackage)
+	// Tests may compile the same package twice, report the same decision once
+	seen := make(map[string]bool)
+	for _, d := range decisions {
+		if report.GoVersion == "" && d.GoVersion != "" {
+			report.GoVersion = strings.Replace(d.GoVersion, "v", "go", 1)
+		}
+		rr := newReportRule(d.Rule)
+		rr.Functions = d.Functions
+		key := d.ImportPath + d.Status + util.Jsonify(rr)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if d.Status != MatchStatusMatched {
+			report.Skipped = append(report.Skipped, &ReportSkipped{
+				ImportPath: d.ImportPath,
+				Version:    d.Version,
+				Rule:       rr,
+				Reason:     d.Status,
+			})
+			continue
+		}
+		pkg, exist := pkgs[d.ImportPath]
+		if !exist {
+			pkg = &ReportPackage{
+				ImportPath: d.ImportPath,
+				Version:    d.Version,
+				Rules:      make([]*ReportRule, 0),
+			}
+			pkgs[d.ImportPath] = pkg
+			report.Instrumented = append(report.Instrumented, pkg)
+		}
+		pkg.Rules = append(pkg.Rules, rr)
+	}
+	ruleLess := func(a, b *ReportRule) bool {
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if a.RuleFile != b.RuleFile {
+			return a.RuleFile < b.RuleFile
+		}
+		return a.Hook+a.OnEnter+a.OnExit < b.Hook+b.OnEnter+b.OnExit
+	}
+	sort.Slice(report.Instrumented, func(i, j int) bool {
+		return report.Instrumented[i].ImportPath <
+			report.Instrumented[j].ImportPath
+	})
+	for _, pkg := range report.Instrumented {
+		sort.Slice(pkg.Rules, func(i, j int) bool {
+			return ruleLess(pkg.Rules[i], pkg.Rules[j])
+		})
+	}
+	sort.Slice(report.Skipped, func(i, j int) bool {
+		a, b := report.Skipped[i], report.Skipped[j]
+		if a.ImportPath != b.ImportPath {
+			return a.ImportPath < b.ImportPath
+		}
+		if a.Reason != b.Reason {
+			return a.Reason < b.Reason
+		}
+		return ruleLess(a.Rule, b.Rule)
+	})
+	return report
+}
+
+// The SARIF-like report follows the SARIF 2.1.0 layout, where instrumented
+// rules are reported as notes and skipped rules are reported as warnings
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []*sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifResult struct {
+	RuleId    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   *sarifMessage    `json:"message"`
+	Locations []*sarifLocation `json:"locations"`
+}
+
+type sarifDriver struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool    *sarifTool     `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+func newSarifResult(importPath, version string, rule *ReportRule,
+	level, message string) *sarifResult {
+	name := importPath
+	if version != "" {
+		name += "@" + version
+	}
+	return &sarifResult{
+		RuleId:  rule.RuleFile + ":" + rule.Target,
+		Level:   level,
+		Message: &sarifMessage{Text: message},
+		Locations: []*sarifLocation{{
+			LogicalLocations: []*sarifLogicalLocation{{
+				FullyQualifiedName: name,
+				Kind:               "module",
+			}},
+		}},
+	}
+}
+
+func (r *BuildReport) toSarif() *sarifLog {
+	results := make([]*sarifResult, 0)
+	for _, pkg := range r.Instrumented {
+		for _, rule := range pkg.Rules {
+			msg := fmt.Sprintf("%s is instrumented", rule.Target)
+			results = append(results,
+				newSarifResult(pkg.ImportPath, pkg.Version, rule, "note", msg))
+		}
+	}
+	for _, s := range r.Skipped {
+		msg := fmt.Sprintf("%s is skipped due to %s", s.Rule.Target, s.Reason)
+		results = append(results,
+			newSarifResult(s.ImportPath, s.Version, s.Rule, "warning", msg))
+	}
+	return &sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []*sarifRun{{
+			Tool: &sarifTool{Driver: &sarifDriver{
+				Name:    "otel",
+				Version: r.ToolVersion,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+func writeJson(path string, v interface{}) error {
+	bs, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	_, err = util.WriteFile(path, string(bs)+"\n")
+	return err
+}
+
+// writeBuildReport writes the build report under .otel-build, or to the path
+// that is explicitly given
+func (dp *DepProcessor) writeBuildReport(report *BuildReport) error {
+	conf := config.GetConf()
+	format := conf.GetReportFormat()
+	if report == nil || format == config.ReportFormatNone {
+		return nil
+	}
+	// Keep a copy for debugging
+	_ = writeJson(util.GetLogPath(BuildReportFile), report)
+
+	if path := conf.GetReportPath(); path != "" {
+		err := os.MkdirAll(filepath.Dir(path), 0777)
+		if err != nil {
+			return errc.New(errc.ErrMkdirAll, err.Error())
+		}
+		if format == config.ReportFormatSarif {
+			err = writeJson(path, report.toSarif())
+		} else {
+			err = writeJson(path, report)
+		}
+		if err != nil {
+			return err
+		}
+		util.Log("Write build report to %s", path)
+		return nil
+	}
+	path := util.GetTempBuildDirWith(BuildReportFile)
+	err := writeJson(path, report)
+	if err != nil {
+		return err
+	}
+	util.Log("Write build report to %s", path)
+	if format == config.ReportFormatSarif {
+		path = util.GetTempBuildDirWith(BuildReportSarif)
+		err = writeJson(path, report.toSarif())
+		if err != nil {
+			return err
+		}
+		util.Log("Write SARIF build report to %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+)
+
+func newTestFuncRule(importPath, function, ruleFile string) *resource.InstFuncRule {
+	return &resource.InstFuncRule{
+		InstBaseRule: resource.InstBaseRule{
+			ImportPath: importPath,
+			Path:       "github.com/example/hook",
+			RuleFile:   ruleFile,
+		},
+		Function: function,
+		OnEnter:  "onEnter",
+	}
+}
+
+func TestNewBuildReport(t *testing.T) {
+	serve := newTestFuncRule("net/http", "Serve", "/abs/path/rules.json")
+	get := newTestFuncRule("net/http", "Get", "rules.json")
+	query := newTestFuncRule("database/sql", "Query", "rules.json")
+	dial := newTestFuncRule("github.com/redis/go-redis/v9", "Dial",
+		"redis.json")
+	decisions := []*MatchDecision{
+		{ImportPath: "net/http", GoVersion: "v1.23.0", Rule: serve,
+			Status: MatchStatusMatched},
+		{ImportPath: "net/http", Rule: get, Status: MatchStatusMatched},
+		// Compiled twice by tests
+		{ImportPath: "net/http", Rule: get, Status: MatchStatusMatched},
+		{ImportPath: "database/sql", Rule: query, Status: MatchStatusMatched},
+		{ImportPath: "github.com/redis/go-redis/v9", Version: "v9.0.0",
+			Rule: dial, Status: MatchStatusVersionMismatch},
+	}
+	report := newBuildReport(decisions)
+
+	if report.ReportVersion != BuildReportVersion {
+		t.Errorf("expect report version %d, got %d", BuildReportVersion,
+			report.ReportVersion)
+	}
+	if report.GoVersion != "go1.23.0" {
+		t.Errorf("expect go version go1.23.0, got %s", report.GoVersion)
+	}
+	if len(report.Instrumented) != 2 {
+		t.Fatalf("expect 2 instrumented packages, got %d",
+			len(report.Instrumented))
+	}
+	// Packages and rules are sorted
+	sql, http := report.Instrumented[0], report.Instrumented[1]
+	if sql.ImportPath != "database/sql" || http.ImportPath != "net/http" {
+		t.Fatalf("unexpected order of packages %s, %s", sql.ImportPath,
+			http.ImportPath)
+	}
+	if len(http.Rules) != 2 {
+		t.Fatalf("expect duplicated decisions to be reported once, got %d",
+			len(http.Rules))
+	}
+	if http.Rules[0].Target != "func Get" || http.Rules[1].Target != "func Serve" {
+		t.Errorf("unexpected order of rules %s, %s", http.Rules[0].Target,
+			http.Rules[1].Target)
+	}
+	// Rule files are recorded by base name
+	if http.Rules[1].RuleFile != "rules.json" {
+		t.Errorf("expect base name of the rule file, got %s",
+			http.Rules[1].RuleFile)
+	}
+	if http.Rules[1].Kind != RuleKindFunc || http.Rules[1].OnEnter != "onEnter" {
+		t.Errorf("unexpected rule %v", http.Rules[1])
+	}
+	if len(report.Skipped) != 1 {
+		t.Fatalf("expect 1 skipped rule, got %d", len(report.Skipped))
+	}
+	skipped := report.Skipped[0]
+	if skipped.Reason != MatchStatusVersionMismatch ||
+		skipped.Version != "v9.0.0" || skipped.Rule.Target != "func Dial" {
+		t.Errorf("unexpected skipped rule %v", skipped)
+	}
+}
+
+func TestBuildReportToSarif(t *testing.T) {
+	report := newBuildReport([]*MatchDecision{
+		{ImportPath: "net/http", Rule: newTestFuncRule("net/http", "Serve",
+			"rules.json"), Status: MatchStatusMatched},
+		{ImportPath: "github.com/redis/go-redis/v9", Version: "v9.0.0",
+			Rule: newTestFuncRule("github.com/redis/go-redis/v9", "Dial",
+				"redis.json"), Status: MatchStatusVersionMismatch},
+	})
+	sarif := report.toSarif()
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 {
+		t.Fatalf("unexpected sarif log %v", sarif)
+	}
+	results := sarif.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expect 2 results, got %d", len(results))
+	}
+	tests := []struct {
+		ruleId string
+		level  string
+		name   string
+	}{
+		{"rules.json:func Serve", "note", "net/http"},
+		{"redis.json:func Dial", "warning", "github.com/redis/go-redis/v9@v9.0.0"},
+	}
+	for i, tt := range tests {
+		r := results[i]
+		if r.RuleId != tt.ruleId || r.Level != tt.level {
+			t.Errorf("expect %s %s, got %s %s", tt.ruleId, tt.level, r.RuleId,
+				r.Level)
+		}
+		name := r.Locations[0].LogicalLocations[0].FullyQualifiedName
+		if name != tt.name {
+			t.Errorf("expect location %s, got %s", tt.name, name)
+		}
+	}
+}
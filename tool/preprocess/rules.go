@@ -0,0 +1,273 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Rule Catalog
+//
+// The "otel rules" command inspects the rule catalog without building anything.
+// "otel rules list" prints all available rules under current configuration,
+// while "otel rules explain" runs the rule matcher against the current module
+// and tells which rules are matched and why others are not. "otel rules
+// validate" checks rule files, see validate.go.
+
+const (
+	RulesList    = "list"
+	RulesExplain = "explain"
+)
+
+const (
+	RuleKindFunc   = "func"
+	RuleKindStruct = "struct"
+	RuleKindFile   = "file"
+	RuleKindCall   = "call"
+)
+
+func ruleKind(rule resource.InstRule) string {
+	switch rule.(type) {
+	case *resource.InstCallRule:
+		return RuleKindCall
+	case *resource.InstFuncRule:
+		return RuleKindFunc
+	case *resource.InstStructRule:
+		return RuleKindStruct
+	case *resource.InstFileRule:
+		return RuleKindFile
+	}
+	util.ShouldNotReachHereT("insane rule type")
+	return ""
+}
+
+// describeRule returns a human readable description of the rule target
+func describeRule(rule resource.InstRule) string {
+	switch r := rule.(type) {
+	case *resource.InstCallRule:
+		if r.ReceiverType != "" {
+			return fmt.Sprintf("call (%s).%s", r.ReceiverType, r.Function)
+		}
+		return "call " + r.Function
+	case *resource.InstFuncRule:
+		if r.ReceiverType != "" {
+			return fmt.Sprintf("func (%s).%s", r.ReceiverType, r.Function)
+		}
+		return "func " + r.Function
+	case *resource.InstStructRule:
+		return fmt.Sprintf("struct %s.%s %s", r.StructType, r.FieldName,
+			r.FieldType)
+	case *resource.InstFileRule:
+		if r.Replace {
+			return "file " + r.FileName + " (replace)"
+		}
+		return "file " + r.FileName
+	}
+	util.ShouldNotReachHereT("insane rule type")
+	return ""
+}
+
+// describeHook returns where the hook code of the rule comes from
+func describeHook(rule resource.InstRule) string {
+	if r, ok := rule.(*resource.InstCallRule); ok {
+		rule = &r.InstFuncRule
+	}
+	if r, ok := rule.(*resource.InstFuncRule); ok {
+		if r.UseRaw {
+			return "<raw>"
+		}
+		hooks := make([]string, 0)
+		for _, h := range []string{r.OnEnter, r.OnExit} {
+			if h != "" {
+				hooks = append(hooks, h)
+			}
+		}
+		return r.Path + " " + strings.Join(hooks, ",")
+	}
+	return rule.GetPath()
+}
+
+func orAny(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
+
+func sortRules(rules []resource.InstRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].GetImportPath() != rules[j].GetImportPath() {
+			return rules[i].GetImportPath() < rules[j].GetImportPath()
+		}
+		return describeRule(rules[i]) < describeRule(rules[j])
+	})
+}
+
+type ruleEntry struct {
+	Kind string
+	Rule resource.InstRule
+}
+
+func listRules(w io.Writer, asJson bool) error {
+	rules := findAvailableRules()
+	sortRules(rules)
+	if asJson {
+		entries := make([]*ruleEntry, 0, len(rules))
+		for _, rule := range rules {
+			entries = append(entries, &ruleEntry{ruleKind(rule), rule})
+		}
+		bs, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return errc.New(errc.ErrInvalidJSON, err.Error())
+		}
+		_, err = fmt.Fprintln(w, string(bs))
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IMPORT PATH\tTARGET\tVERSION\tGO VERSION\tHOOK\tRULE FILE")
+	for _, rule := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			rule.GetImportPath(), describeRule(rule),
+			orAny(rule.GetVersion()), orAny(rule.GetGoVersion()),
+			describeHook(rule), rule.GetRuleFile())
+	}
+	return tw.Flush()
+}
+
+func explainRules(w io.Writer, asJson bool, buildArgs []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return errc.New(errc.ErrGetwd, err.Error())
+	}
+	gomod, err := findGoMod(wd)
+	if err != nil {
+		return err
+	}
+	// Explain rules against the plain build of current module, nothing will
+	// be changed or compiled
+	dp := newDepProcessor()
+	dp.goBuildCmd = append([]string{"go", "build"}, buildArgs...)
+	dp.modulePath = gomod
+	modfile, err := parseGoMod(gomod)
+	if err != nil {
+		return err
+	}
+	dp.moduleName = modfile.Module.Mod.Path
+	dp.initBuildMode()
+	_, err = dp.matchRules()
+	if err != nil {
+		return err
+	}
+	decisions := dp.decisions
+	sort.SliceStable(decisions, func(i, j int) bool {
+		if decisions[i].ImportPath != decisions[j].ImportPath {
+			return decisions[i].ImportPath < decisions[j].ImportPath
+		}
+		if decisions[i].Status != decisions[j].Status {
+			return decisions[i].Status < decisions[j].Status
+		}
+		return describeRule(decisions[i].Rule) < describeRule(decisions[j].Rule)
+	})
+	if asJson {
+		bs, err := json.MarshalIndent(decisions, "", "  ")
+		if err != nil {
+			return errc.New(errc.ErrInvalidJSON, err.Error())
+		}
+		_, err = fmt.Fprintln(w, string(bs))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	stat := make(map[string]int)
+	lastPkg := ""
+	for _, d := range decisions {
+		if d.ImportPath != lastPkg {
+			version := d.Version
+			if version == "" {
+				version = strings.Replace(d.GoVersion, "v", "go", 1)
+			}
+			fmt.Fprintf(tw, "%s (%s)\n", d.ImportPath, version)
+			lastPkg = d.ImportPath
+		}
+		target := describeRule(d.Rule)
+		if len(d.Functions) > 0 {
+			target += fmt.Sprintf(" (%d functions)", len(d.Functions))
+		}
+		fmt.Fprintf(tw, "  [%s]\t%s\t%s\t%s\n", d.Status, target,
+			orAny(d.Rule.GetVersion()), filepath.Base(d.Rule.GetRuleFile()))
+		stat[d.Status]++
+	}
+	fmt.Fprintf(tw, "\n%d matched, %d version-mismatch, %d go-version-mismatch, "+
+		"%d not-found, %d filtered, %d unreachable\n",
+		stat[MatchStatusMatched], stat[MatchStatusVersionMismatch],
+		stat[MatchStatusGoVersionMismatch], stat[MatchStatusNotFound],
+		stat[MatchStatusFiltered], stat[MatchStatusUnreachable])
+	if len(dp.conflicts) > 0 {
+		fmt.Fprintf(tw, "\n%d conflicts\n", len(dp.conflicts))
+		for _, c := range dp.conflicts {
+			fmt.Fprintf(tw, "  %s\n", c)
+		}
+	}
+	return tw.Flush()
+}
+
+// Rules implements the "otel rules" command
+func Rules() error {
+	usage := func() {
+		name, _ := util.GetToolName()
+		fmt.Printf("Usage: %s rules list [-json]\n", name)
+		fmt.Printf("       %s rules explain [-json] [build flags] [packages]\n",
+			name)
+		fmt.Printf("       %s rules validate [-json] [-default] [rule files]\n",
+			name)
+	}
+	if len(os.Args) < 3 {
+		usage()
+		return nil
+	}
+	// Pick up our own flags, all the others are passed to the build as is
+	asJson := false
+	args := make([]string, 0)
+	for _, arg := range os.Args[3:] {
+		if arg == "-json" || arg == "--json" {
+			asJson = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	switch os.Args[2] {
+	case RulesList:
+		return listRules(os.Stdout, asJson)
+	case RulesExplain:
+		return explainRules(os.Stdout, asJson, args)
+	case RulesValidate:
+		return validateRules(os.Stdout, asJson, args)
+	default:
+		usage()
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"sort"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Span Rule
+//
+// The "Span" shorthand of func rules traces user functions without writing any
+// hook code. Such rules are backed by the built-in generic hooks from the span
+// rule package, and the tool generates a pair of hooks for each rule, which
+// are linked to the target package and forward to the generic ones together
+// with the rule settings, e.g. the parameters to be captured.
+
+const (
+	SpanRulePath   = pkgPrefix + "/rules/span"
+	OtelSpanHooks  = "otel_span_hooks.go"
+	spanOnEnterFmt = "otelSpanOnEnter_%08x"
+	spanOnExitFmt  = "otelSpanOnExit_%08x"
+)
+
+// expandSpanRule rewrites the span rule to use the generated hooks, hook names
+// are derived from the rule itself so that they are stable across builds
+func expandSpanRule(rule *resource.InstFuncRule) error {
+	if rule.UseRaw || rule.OnEnter != "" || rule.OnExit != "" ||
+		rule.Path != "" {
+		return errc.New(errc.ErrInvalidRule,
+			"span rule can not specify hooks "+rule.String())
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(rule.String()))
+	sum := h.Sum32()
+	rule.Path = SpanRulePath
+	rule.OnEnter = fmt.Sprintf(spanOnEnterFmt, sum)
+	rule.OnExit = fmt.Sprintf(spanOnExitFmt, sum)
+	return rule.Verify()
+}
+
+// writeSpanHooks generates hooks of all matched span rules into the
+// span rule package
+func (dp *DepProcessor) writeSpanHooks(bundles []*resource.RuleBundle) error {
+	rules := make(map[string]*resource.InstFuncRule)
+	for _, bundle := range bundles {
+		for _, funcRules := range bundle.File2FuncRules {
+			for _, rs := range funcRules {
+				for _, rule := range rs {
+					if rule.Span {
+						rules[rule.OnEnter] = rule
+					}
+				}
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	content := "// This file is generated by otel tool, DO NOT EDIT MANUALLY\n"
+	content += "package span\n\n"
+	content += "import (\n"
+	content += "\t_ \"unsafe\"\n\n"
+	content += "\t\"github.com/alibaba/opentelemetry-go-auto-instrumentation/pkg/api\"\n"
+	content += ")\n"
+	for _, name := range names {
+		rule := rules[name]
+		params := make([]string, 0, len(rule.SpanParams))
+		for key := range rule.SpanParams {
+			params = append(params, key)
+		}
+		sort.Strings(params)
+		varName := "rule_" + rule.OnEnter
+		content += fmt.Sprintf("\nvar %s = &spanRule{method: %v, params: map[int]string{",
+			varName, rule.ReceiverType != "")
+		for i, key := range params {
+			if i > 0 {
+				content += ", "
+			}
+			content += fmt.Sprintf("%d: %q", rule.SpanParams[key], key)
+		}
+		content += "}}\n\n"
+		content += fmt.Sprintf("//go:linkname %s %s.%s\n",
+			rule.OnEnter, rule.ImportPath, rule.OnEnter)
+		content += fmt.Sprintf("func %s(call api.CallContext) { spanOnEnter(call, %s) }\n\n",
+			rule.OnEnter, varName)
+		content += fmt.Sprintf("//go:linkname %s %s.%s\n",
+			rule.OnExit, rule.ImportPath, rule.OnExit)
+		content += fmt.Sprintf("func %s(call api.CallContext) { spanOnExit(call) }\n",
+			rule.OnExit)
+	}
+	err := dp.writePkgFile(filepath.Join("rules", "span", OtelSpanHooks),
+		content)
+	if err != nil {
+		return err
+	}
+	util.Log("Generate hooks for %d span rules", len(names))
+	return nil
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/instrument"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+// -----------------------------------------------------------------------------
+// Toolexec
+//
+// "otel go build" drives the whole build, it finds packages to instrument by a
+// dry run of the go command, and instruments them while the go command invokes
+// the compiler via -toolexec. Build systems that invoke the compiler themselves,
+// e.g. Bazel rules_go, can not work this way. "otel toolexec" instruments one
+// package at a time instead, given its import path and source files, it matches
+// rules against them in the same way, writes the instrumented source set into
+// the output directory and tells what else the package needs. The build system
+// then compiles the package itself. Finally, "otel toolexec -importer" generates
+// the importer of the main package from results of all packages, which links
+// hook packages and the otel setup into the binary.
+
+// ToolexecResult tells the build system how to compile the package, it's
+// printed as JSON by "otel toolexec"
+type ToolexecResult struct {
+	ImportPath  string
+	PackageName string `json:",omitempty"`
+	// Whether any rule is applied to the package
+	Instrumented bool
+	// Source files to be compiled, in place of the given ones
+	Files []string
+	// Given source files that are replaced by instrumented ones
+	Replaced map[string]string `json:",omitempty"`
+	// Generated source files that are added to the package
+	Added []string `json:",omitempty"`
+	// Packages that are newly imported by the source files, they must be
+	// available to the compiler, i.e. listed in -importcfg
+	Imports []string `json:",omitempty"`
+	// Hook packages that must be linked into the binary, they are imported by
+	// the importer of the main package
+	Hooks []string `json:",omitempty"`
+}
+
+// Packages imported by the importer besides hook packages, see template.go
+var importerImports = []string{
+	"log",
+	"runtime/debug",
+	"go.opentelemetry.io/otel",
+	"go.opentelemetry.io/otel/sdk/trace",
+	"go.opentelemetry.io/otel/baggage",
+	pkgPrefix,
+}
+
+// parseReplaces parses the -replace flag, i.e. comma-separated import paths of
+// hook packages and where they are located, e.g. "example.com/hook=./hook"
+func parseReplaces(value string) (map[string]string, error) {
+	replaceMap := map[string]string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		path, dir, ok := strings.Cut(item, "=")
+		if !ok || path == "" || dir == "" {
+			return nil, errc.New(errc.ErrInvalidConfig,
+				fmt.Sprintf("bad replace %s, expect importpath=dir", item))
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, errc.New(errc.ErrAbsPath, err.Error())
+		}
+		replaceMap[path] = dir
+	}
+	return replaceMap, nil
+}
+
+// findHooks returns import paths of hook packages referred by the bundle, and
+// those imported by call sites of the package, it must be called before rules
+// are rectified
+func findHooks(bundle *resource.RuleBundle) ([]string, []string, error) {
+	hooks := map[string]bool{}
+	for _, funcRules := range bundle.File2FuncRules {
+		for _, rules := range funcRules {
+			for _, rule := range rules {
+				if rule.Span {
+					// Span hooks are generated for the build as a whole
+					return nil, nil, errc.New(errc.ErrPreprocess,
+						"span rules are not supported by toolexec").
+						With("rule", rule.String())
+				}
+				if !rule.UseRaw && rule.Path != "" {
+					hooks[rule.Path] = true
+				}
+			}
+		}
+	}
+	imports := map[string]bool{}
+	for _, rule := range bundle.CallRules {
+		hooks[rule.Path] = true
+		imports[rule.Path] = true
+	}
+	return sortedKeys(hooks), sortedKeys(imports), nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toolexecPackage matches rules against the package and instruments it
+func toolexecPackage(importPath, version, goVersion, importCfg, outDir string,
+	replaceMap map[string]string, files []string) (*ToolexecResult, error) {
+	args := []string{"compile", util.BuildPattern, importPath,
+		util.BuildGoVer, goVersion}
+	if importCfg != "" {
+		args = append(args, "-importcfg", importCfg)
+	}
+	sources := make([]string, 0, len(files))
+	for _, file := range files {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return nil, errc.New(errc.ErrAbsPath, err.Error())
+		}
+		if util.PathNotExists(file) {
+			return nil, errc.New(errc.ErrNotExist, file)
+		}
+		sources = append(sources, file)
+	}
+	args = append(args, sources...)
+	result := &ToolexecResult{
+		ImportPath: importPath,
+		Files:      sources,
+	}
+
+	matcher := newRuleMatcher()
+	if version != "" {
+		// Source files are not necessarily located in the module cache, where
+		// the version is part of the path
+		matcher.moduleVersions = []*vendorModule{
+			{path: importPath, version: version},
+		}
+	}
+	bundle := matcher.match(args)
+	for _, decision := range matcher.decisions {
+		util.Log("Rule %s is %s for %s", decision.Rule, decision.Status,
+			importPath)
+	}
+	if !bundle.IsValid() {
+		return result, nil
+	}
+	err := checkConflicts(findBundleConflicts(bundle))
+	if err != nil {
+		return nil, err
+	}
+	result.PackageName = bundle.PackageName
+	result.Instrumented = true
+	hooks, imports, err := findHooks(bundle)
+	if err != nil {
+		return nil, err
+	}
+	result.Hooks, result.Imports = hooks, imports
+
+	// Hooks are read from local paths during instrumentation, they are shared
+	// by all packages
+	pkgDir, err := findModCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	err = rectifyRulePaths([]*resource.RuleBundle{bundle}, pkgDir, replaceMap)
+	if err != nil {
+		return nil, err
+	}
+	util.Log("Apply bundle %v", bundle)
+	instrumented, err := instrument.InstrumentFiles(bundle, args, outDir)
+	if err != nil {
+		return nil, err
+	}
+	// Source files are replaced in place, generated files are appended
+	result.Files = make([]string, 0)
+	result.Replaced = map[string]string{}
+	for i, arg := range instrumented {
+		if !util.IsGoFile(arg) {
+			continue
+		}
+		result.Files = append(result.Files, arg)
+		if i >= len(args) {
+			result.Added = append(result.Added, arg)
+		} else if arg != args[i] {
+			result.Replaced[args[i]] = arg
+		}
+	}
+	return result, nil
+}
+
+// toolexecImporter generates the importer of the main package from results of
+// all packages of the binary
+func toolexecImporter(pkgName, importPath, outDir string,
+	resultFiles []string) (*ToolexecResult, error) {
+	hooks := map[string]bool{}
+	bundles := make([]*resource.RuleBundle, 0)
+	for _, file := range resultFiles {
+		data, err := util.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		r := &ToolexecResult{}
+		err = json.Unmarshal([]byte(data), r)
+		if err != nil {
+			return nil, errc.New(errc.ErrInvalidJSON, err.Error()).
+				With("result", file)
+		}
+		if !r.Instrumented {
+			continue
+		}
+		for _, hook := range r.Hooks {
+			hooks[hook] = true
+		}
+		bundles = append(bundles, resource.NewRuleBundle(r.ImportPath))
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].ImportPath < bundles[j].ImportPath
+	})
+	imports := ""
+	for _, hook := range sortedKeys(hooks) {
+		imports += fmt.Sprintf("import _ %q\n", hook)
+	}
+	importerTemplate = strings.ReplaceAll(importerTemplate,
+		util.GoBuildIgnoreComment, "")
+	content := newDepProcessor().renderImporter(bundles, imports, pkgName,
+		importPath)
+	importer, err := filepath.Abs(filepath.Join(outDir, OtelImporter))
+	if err != nil {
+		return nil, errc.New(errc.ErrAbsPath, err.Error())
+	}
+	_, err = util.WriteFile(importer, content)
+	if err != nil {
+		return nil, err
+	}
+	return &ToolexecResult{
+		ImportPath:   importPath,
+		PackageName:  pkgName,
+		Instrumented: true,
+		Files:        []string{importer},
+		Added:        []string{importer},
+		Imports:      append(sortedKeys(hooks), importerImports...),
+	}, nil
+}
+
+func printToolexecResult(w io.Writer, result *ToolexecResult) error {
+	bs, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errc.New(errc.ErrInvalidJSON, err.Error())
+	}
+	_, err = fmt.Fprintln(w, string(bs))
+	return err
+}
+
+// Toolexec instruments a single package for build systems that compile it
+// themselves, the result is printed to the standard output
+func Toolexec() error {
+	name, _ := util.GetToolName()
+	fs := flag.NewFlagSet("toolexec", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s toolexec [flags] -importpath=path -o=dir files...\n",
+			name)
+		fmt.Printf("       %s toolexec -importer [-importpath=path] -o=dir results...\n",
+			name)
+		fs.PrintDefaults()
+	}
+	importPath := fs.String("importpath", "",
+		"Import path of the package, \"main\" for the main package")
+	outDir := fs.String("o", "",
+		"Directory where instrumented and generated files are written")
+	version := fs.String("version", "",
+		"Module version of the package, e.g. v1.2.3, rules with version ranges never match without it")
+	goVersion := fs.String("goversion", runtime.Version(),
+		"Go version of the toolchain, e.g. go1.22.3")
+	importCfg := fs.String("importcfg", "",
+		"Import config of the package, it's required by call rules")
+	rules := fs.String("rule", "",
+		"Comma-separated rule files, they replace the configured ones")
+	replaces := fs.String("replace", "",
+		"Comma-separated locations of hook packages that are not part of otel, e.g. example.com/hook=./hook")
+	importer := fs.Bool("importer", false,
+		"Generate the importer of the main package from results of all packages")
+	err := fs.Parse(os.Args[2:])
+	if err != nil {
+		return errc.New(errc.ErrInvalidConfig, err.Error())
+	}
+	if *outDir == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errc.New(errc.ErrInvalidConfig, "missing output directory or files")
+	}
+	err = os.MkdirAll(*outDir, 0755)
+	if err != nil {
+		return errc.New(errc.ErrMkdirAll, err.Error())
+	}
+
+	var result *ToolexecResult
+	if *importer {
+		path := *importPath
+		if path == "" {
+			path = "main"
+		}
+		result, err = toolexecImporter("main", path, *outDir, fs.Args())
+	} else {
+		if *importPath == "" {
+			fs.Usage()
+			return errc.New(errc.ErrInvalidConfig, "missing import path")
+		}
+		if *rules != "" {
+			config.GetConf().RuleJsonFiles = *rules
+		}
+		var replaceMap map[string]string
+		replaceMap, err = parseReplaces(*replaces)
+		if err != nil {
+			return err
+		}
+		result, err = toolexecPackage(*importPath, *version, *goVersion,
+			*importCfg, *outDir, replaceMap, fs.Args())
+	}
+	if err != nil {
+		return err
+	}
+	return printToolexecResult(os.Stdout, result)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+)
+
+func TestParseReplaces(t *testing.T) {
+	abs := func(dir string) string {
+		t.Helper()
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	tests := []struct {
+		name    string
+		value   string
+		expect  map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			value:  "",
+			expect: map[string]string{},
+		},
+		{
+			name:  "relative and absolute dirs",
+			value: "example.com/hook=./hook, example.com/other=/opt/other,",
+			expect: map[string]string{
+				"example.com/hook":  abs("hook"),
+				"example.com/other": "/opt/other",
+			},
+		},
+		{
+			name:    "missing dir",
+			value:   "example.com/hook=",
+			wantErr: true,
+		},
+		{
+			name:    "missing separator",
+			value:   "example.com/hook",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replaces, err := parseReplaces(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expect error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(replaces, tt.expect) {
+				t.Errorf("expect %v, got %v", tt.expect, replaces)
+			}
+		})
+	}
+}
+
+func TestFindHooks(t *testing.T) {
+	funcRule := func(function, path string, useRaw bool) *resource.InstFuncRule {
+		return &resource.InstFuncRule{
+			InstBaseRule: resource.InstBaseRule{Path: path},
+			Function:     function,
+			OnEnter:      "onEnter",
+			UseRaw:       useRaw,
+		}
+	}
+	bundle := resource.NewRuleBundle("net/http")
+	for _, rule := range []*resource.InstFuncRule{
+		funcRule("Serve", "example.com/hook/http", false),
+		funcRule("Get", "example.com/hook/http", false),
+		funcRule("Do", "example.com/hook/client", false),
+		// Raw code is inlined, there is no hook package to link
+		funcRule("Close", "example.com/hook/raw", true),
+	} {
+		if err := bundle.AddFile2FuncRule("server.go", rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundle.AddCallRule(&resource.InstCallRule{
+		InstFuncRule: *funcRule("Dial", "example.com/hook/dial", false),
+		Call:         true,
+	})
+	hooks, imports, err := findHooks(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHooks := []string{"example.com/hook/client", "example.com/hook/dial",
+		"example.com/hook/http"}
+	if !reflect.DeepEqual(hooks, expectHooks) {
+		t.Errorf("expect hooks %v, got %v", expectHooks, hooks)
+	}
+	// Only call sites import hook packages directly
+	if !reflect.DeepEqual(imports, []string{"example.com/hook/dial"}) {
+		t.Errorf("expect imports of call rules, got %v", imports)
+	}
+
+	span := funcRule("Handle", "example.com/hook/span", false)
+	span.Span = true
+	if err = bundle.AddFile2FuncRule("server.go", span); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = findHooks(bundle)
+	if err == nil || !strings.Contains(err.Error(), "span rules") {
+		t.Fatalf("expect span rules to be rejected, got %v", err)
+	}
+}
+
+func TestPrintToolexecResult(t *testing.T) {
+	var buf bytes.Buffer
+	err := printToolexecResult(&buf, &ToolexecResult{
+		ImportPath: "example.com/app/util",
+		Files:      []string{"util.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]interface{}{}
+	if err = json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("bad result %s: %v", buf.String(), err)
+	}
+	// Packages that are not instrumented are compiled as they are
+	if result["Instrumented"] != false || len(result) != 3 {
+		t.Errorf("unexpected result %s", buf.String())
+	}
+}
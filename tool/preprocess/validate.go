@@ -0,0 +1,761 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/config"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/data"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/instrument"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/resource"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"golang.org/x/tools/go/packages"
+)
+
+// -----------------------------------------------------------------------------
+// Rule Validation
+//
+// "otel rules validate" lints rule files without building anything, so that
+// custom rules can be checked in CI before they break a build. Besides the
+// rule definition itself, e.g. unknown fields and malformed version ranges, it
+// checks that hooks named by the rules exist in the hook package and are in
+// the shape the instrumentation expects, i.e. they accept api.CallContext as
+// the first parameter and return nothing. If the target package can be loaded
+// by the current module, e.g. it's a standard library package or a dependency,
+// hooks are type checked against the target function as well, i.e. OnEnter
+// hooks accept the receiver and parameters of the target function and OnExit
+// hooks accept its results. Otherwise, it's left to instrumentation time.
+
+const (
+	RulesValidate = "validate"
+	apiImportPath = pkgPrefix + "/api"
+)
+
+// RuleProblem is a problem found in the rule file, Index is the index of the
+// rule in the rule file, or -1 if the problem is about the rule file itself
+type RuleProblem struct {
+	RuleFile string
+	Index    int
+	Target   string `json:",omitempty"`
+	Problem  string
+}
+
+func (p *RuleProblem) String() string {
+	if p.Index < 0 {
+		return p.Problem
+	}
+	if p.Target == "" {
+		return fmt.Sprintf("#%d: %s", p.Index, p.Problem)
+	}
+	return fmt.Sprintf("#%d %s: %s", p.Index, p.Target, p.Problem)
+}
+
+// reasonOf returns the reason of the error without decorations
+func reasonOf(err error) string {
+	if pe, ok := err.(*errc.PlentifulError); ok {
+		return pe.Reason
+	}
+	return err.Error()
+}
+
+// hookResolver finds where hook packages are located locally, hooks of the pkg
+// module are found in the embedded pkg module, while others are found by the
+// replace directives of current module, just like the build does
+type hookResolver struct {
+	replaceMap map[string]string
+	pkgDir     string
+	// Type checked target packages keyed by import paths of rules
+	targets map[string]*targetPackage
+	// Type checked hook packages and their dependencies keyed by import paths
+	hookDeps map[string]*types.Package
+	// Hook packages that can not be loaded keyed by import paths
+	hookErrs map[string]error
+}
+
+// targetPackage is the type checked target package, together with all loaded
+// packages, against which hooks are type checked
+type targetPackage struct {
+	pkg  *types.Package
+	deps map[string]*types.Package
+}
+
+func newHookResolver() *hookResolver {
+	hr := &hookResolver{
+		replaceMap: map[string]string{},
+		targets:    map[string]*targetPackage{},
+		hookDeps:   map[string]*types.Package{},
+		hookErrs:   map[string]error{},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return hr
+	}
+	gomod, err := findGoMod(wd)
+	if err != nil {
+		return hr
+	}
+	modfile, err := parseGoMod(gomod)
+	if err != nil {
+		return hr
+	}
+	for _, replace := range modfile.Replace {
+		path := replace.New.Path
+		if replace.New.Version == "" && !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(gomod), path)
+		}
+		hr.replaceMap[replace.Old.Path] = path
+	}
+	return hr
+}
+
+func (hr *hookResolver) resolve(path string) (string, error) {
+	if strings.HasPrefix(path, pkgPrefix) {
+		if hr.pkgDir == "" {
+			dir, err := findModCacheDir()
+			if err != nil {
+				return "", err
+			}
+			hr.pkgDir = dir
+		}
+		return filepath.Join(hr.pkgDir, strings.TrimPrefix(path, pkgPrefix)), nil
+	}
+	dir, exist := hr.replaceMap[path]
+	if !exist {
+		return "", errc.New(errc.ErrNotExist,
+			fmt.Sprintf("hook package %s is not replaced in go.mod", path))
+	}
+	if util.PathNotExists(dir) {
+		return "", errc.New(errc.ErrNotExist,
+			fmt.Sprintf("hook package %s is not found in %s", path, dir))
+	}
+	return dir, nil
+}
+
+// hookTarget returns the rule if its hooks follow the signature of the target
+// function, i.e. they can be type checked against the target function
+func hookTarget(rule resource.InstRule) *resource.InstFuncRule {
+	var fr *resource.InstFuncRule
+	switch r := rule.(type) {
+	case *resource.InstCallRule:
+		fr = &r.InstFuncRule
+	case *resource.InstFuncRule:
+		fr = r
+	default:
+		return nil
+	}
+	if fr.UseRaw || fr.Span || fr.IsPattern() {
+		return nil
+	}
+	return fr
+}
+
+// loadTargets loads target packages of rules by the current module at once,
+// which is much faster than loading them one by one. main refers to the main
+// package in the working directory. Packages that can not be loaded, e.g. they
+// are not dependencies, are left out. Hook packages of loaded targets are then
+// loaded at once as well.
+func (hr *hookResolver) loadTargets(contents []string) {
+	importPaths := map[string]bool{}
+	hookPaths := map[string][]string{}
+	for _, content := range contents {
+		raws, err := splitRuleFile(content)
+		if err != nil {
+			continue
+		}
+		for _, raw := range raws {
+			rule, err := decodeRule(raw, true)
+			if err != nil {
+				continue
+			}
+			if fr := hookTarget(rule); fr != nil {
+				importPaths[fr.ImportPath] = true
+				hookPaths[fr.ImportPath] = append(hookPaths[fr.ImportPath],
+					fr.Path)
+			}
+		}
+	}
+	patterns := make([]string, 0, len(importPaths))
+	for importPath := range importPaths {
+		if importPath == "main" {
+			importPath = "."
+		}
+		patterns = append(patterns, importPath)
+	}
+	if len(patterns) == 0 {
+		return
+	}
+	// Validation never downloads modules or touches go.mod, targets that are
+	// not available locally are not checked
+	modFlag := "-mod=readonly"
+	if wd, err := os.Getwd(); err == nil {
+		if gomod, err := findGoMod(wd); err == nil &&
+			util.PathExists(filepath.Join(filepath.Dir(gomod), "vendor")) {
+			modFlag = "-mod=vendor"
+		}
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports |
+			packages.NeedDeps,
+		Env:        append(append(os.Environ(), offlineEnv()...), goProxyOff),
+		BuildFlags: []string{modFlag},
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		util.Log("Skip hook check, failed to load target packages: %v", err)
+		return
+	}
+	deps := map[string]*types.Package{}
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if p.Types != nil {
+			deps[p.PkgPath] = p.Types
+		}
+	})
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 || pkg.Types == nil {
+			util.Log("Skip hook check of %s: %v", pkg.PkgPath, pkg.Errors)
+			continue
+		}
+		tp := &targetPackage{pkg: pkg.Types, deps: deps}
+		if pkg.Name == "main" && importPaths["main"] {
+			hr.targets["main"] = tp
+		}
+		if importPaths[pkg.PkgPath] {
+			hr.targets[pkg.PkgPath] = tp
+		}
+	}
+	loaded := map[string]bool{}
+	for importPath := range hr.targets {
+		for _, path := range hookPaths[importPath] {
+			loaded[path] = true
+		}
+	}
+	hr.loadHooks(slices.Sorted(maps.Keys(loaded)))
+}
+
+// writeHookModfile writes a private copy of go.mod of the current module that
+// requires hook modules of the given paths, where the pkg module and its hook
+// modules are replaced with the embedded ones and otel modules are pinned, just
+// like the build does. The go.mod of the current module is never touched.
+func (hr *hookResolver) writeHookModfile(paths []string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errc.New(errc.ErrGetwd, err.Error())
+	}
+	gomod, err := findGoMod(wd)
+	if err != nil {
+		return "", err
+	}
+	mf, err := parseGoMod(gomod)
+	if err != nil {
+		return "", err
+	}
+	required := map[string]bool{}
+	for _, r := range mf.Require {
+		required[r.Mod.Path] = true
+	}
+	// Hooks always refer to the pkg module, which is not published
+	if hr.pkgDir == "" {
+		hr.pkgDir, err = findModCacheDir()
+		if err != nil {
+			return "", err
+		}
+	}
+	for _, path := range paths {
+		if required[path] {
+			continue
+		}
+		// Replaced modules without a version are required by the zero
+		// pseudo-version, as go mod tidy does
+		err = mf.AddRequire(path, "v0.0.0-00010101000000-000000000000")
+		if err != nil {
+			return "", errc.New(errc.ErrPreprocess, err.Error())
+		}
+	}
+	bs, err := mf.Format()
+	if err != nil {
+		return "", errc.New(errc.ErrPreprocess, err.Error())
+	}
+	dir := util.GetTempBuildDir()
+	err = os.MkdirAll(dir, 0777)
+	if err != nil {
+		return "", errc.New(errc.ErrMkdirAll, err.Error())
+	}
+	private := filepath.Join(dir, "validate.mod")
+	_, err = util.WriteFile(private, string(bs))
+	if err != nil {
+		return "", err
+	}
+	gosum := filepath.Join(filepath.Dir(gomod), util.GoSumFile)
+	if util.PathExists(gosum) {
+		err = util.CopyFile(gosum, filepath.Join(dir, "validate.sum"))
+		if err != nil {
+			return "", err
+		}
+	}
+	replaceMap := map[string][2]string{
+		pkgPrefix: {hr.pkgDir, ""},
+	}
+	for _, path := range paths {
+		if strings.HasPrefix(path, pkgPrefix) {
+			t := strings.TrimPrefix(path, pkgPrefix)
+			replaceMap[path] = [2]string{filepath.Join(hr.pkgDir, t), ""}
+		}
+	}
+	for path, version := range otelDeps {
+		replaceMap[path] = [2]string{path, version}
+	}
+	err = addModReplace(private, replaceMap, "")
+	if err != nil {
+		return "", err
+	}
+	return private, nil
+}
+
+// loadHooks loads hook packages of the given import paths by the current
+// module together with hook modules, so that everything hooks refer to is
+// resolved. Modules of hooks are downloaded unless in offline mode. Hook
+// packages that can not be loaded completely are recorded as errors, as their
+// hooks would be checked partially otherwise.
+func (hr *hookResolver) loadHooks(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	fail := func(err error) {
+		for _, path := range paths {
+			hr.hookErrs[path] = err
+		}
+	}
+	private, err := hr.writeHookModfile(paths)
+	if err != nil {
+		fail(err)
+		return
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports |
+			packages.NeedDeps,
+		// -modfile is not allowed in workspace mode
+		Env:        append(append(os.Environ(), offlineEnv()...), "GOWORK=off"),
+		BuildFlags: []string{"-modfile=" + private, "-mod=mod"},
+	}
+	pkgs, err := packages.Load(cfg, paths...)
+	if err != nil {
+		fail(errc.New(errc.ErrInvalidRule, err.Error()))
+		return
+	}
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if p.Types != nil {
+			hr.hookDeps[p.PkgPath] = p.Types
+		}
+	})
+	for _, pkg := range pkgs {
+		problems := make([]string, 0)
+		packages.Visit([]*packages.Package{pkg}, nil,
+			func(p *packages.Package) {
+				// Hooks may refer to fields that are added by struct rules,
+				// only failures of imports are of interest
+				for _, e := range p.Errors {
+					if e.Kind != packages.TypeError ||
+						strings.Contains(e.Msg, "could not import") {
+						problems = append(problems, e.Msg)
+					}
+				}
+			})
+		if len(problems) > 0 {
+			hr.hookErrs[pkg.PkgPath] = errc.New(errc.ErrInvalidRule,
+				"could not load hook package: "+
+					strings.Join(problems, "; "))
+		}
+	}
+}
+
+// findTargetFunc finds the target function of the rule in the type checked
+// package, generic functions are not checked as hooks do not follow their
+// signatures literally
+func findTargetFunc(pkg *types.Package, rule *resource.InstFuncRule) *types.Func {
+	var target *types.Func
+	if rule.ReceiverType == "" {
+		target, _ = pkg.Scope().Lookup(rule.Function).(*types.Func)
+	} else {
+		re := regexp.MustCompile("^" + rule.ReceiverType + "$")
+		for _, name := range pkg.Scope().Names() {
+			tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				method := named.Method(i)
+				if method.Name() != rule.Function {
+					continue
+				}
+				recv := name
+				recvType := method.Type().(*types.Signature).Recv().Type()
+				if _, ok := recvType.(*types.Pointer); ok {
+					recv = "*" + name
+				}
+				if re.MatchString(recv) {
+					target = method
+				}
+			}
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	sig := target.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || sig.RecvTypeParams().Len() > 0 {
+		return nil
+	}
+	return target
+}
+
+// findHookDecls returns top-level functions of the hook package, together
+// with the file where they are declared
+func findHookDecls(dir string) (map[string]*ast.FuncDecl,
+	map[*ast.FuncDecl]*ast.File, error) {
+	files, err := util.ListFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	decls := make(map[string]*ast.FuncDecl)
+	declFiles := make(map[*ast.FuncDecl]*ast.File)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		// Hooks are never declared in nested packages or test files
+		if filepath.Dir(file) != filepath.Clean(dir) ||
+			!util.IsGoFile(file) || util.IsGoTestFile(file) {
+			continue
+		}
+		root, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, errc.New(errc.ErrParseCode, err.Error())
+		}
+		for _, decl := range root.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				decls[fn.Name.Name] = fn
+				declFiles[fn] = root
+			}
+		}
+	}
+	return decls, declFiles, nil
+}
+
+// isCallContextType checks if the type expression refers to api.CallContext,
+// where the api package may be imported under another name
+func isCallContextType(file *ast.File, expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "CallContext" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if path != apiImportPath {
+			continue
+		}
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == x.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHookDecl checks the shape of the hook function, generic hooks of pattern
+// rules receive nothing but the api.CallContext
+func checkHookDecl(file *ast.File, decl *ast.FuncDecl, generic bool) string {
+	name := decl.Name.Name
+	if decl.Type.Results != nil && len(decl.Type.Results.List) > 0 {
+		return fmt.Sprintf("hook %s should not return anything", name)
+	}
+	params := decl.Type.Params.List
+	if len(params) == 0 || !isCallContextType(file, params[0].Type) {
+		return fmt.Sprintf("hook %s should accept api.CallContext as the "+
+			"first parameter", name)
+	}
+	if generic && (len(params) > 1 || len(params[0].Names) > 1) {
+		return fmt.Sprintf("hook %s of the pattern rule should accept "+
+			"api.CallContext only", name)
+	}
+	return ""
+}
+
+// checkRawCode checks if the raw code is a valid statement list
+func checkRawCode(code string) string {
+	source := "package raw\nfunc _() {\n" + code + "\n}\n"
+	_, err := parser.ParseFile(token.NewFileSet(), "", source,
+		parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Sprintf("bad raw code %q: %v", code, err)
+	}
+	return ""
+}
+
+// checkFuncHooks checks hooks of the func or call rule exist and are in shape
+func checkFuncHooks(hr *hookResolver, rule *resource.InstFuncRule) []string {
+	problems := make([]string, 0)
+	hooks := make([]string, 0)
+	for _, hook := range []string{rule.OnEnter, rule.OnExit} {
+		if hook != "" {
+			hooks = append(hooks, hook)
+		}
+	}
+	if rule.UseRaw {
+		for _, code := range hooks {
+			if problem := checkRawCode(code); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+		return problems
+	}
+	// Hooks of span rules are generated by the tool
+	if rule.Span {
+		return problems
+	}
+	dir, err := hr.resolve(rule.Path)
+	if err != nil {
+		return append(problems, reasonOf(err))
+	}
+	decls, declFiles, err := findHookDecls(dir)
+	if err != nil {
+		return append(problems, reasonOf(err))
+	}
+	for _, hook := range hooks {
+		decl, exist := decls[hook]
+		if !exist {
+			problems = append(problems,
+				fmt.Sprintf("hook %s is not found in %s", hook, rule.Path))
+			continue
+		}
+		problem := checkHookDecl(declFiles[decl], decl, rule.IsPattern())
+		if problem == "" && hookTarget(rule) != nil {
+			problem = hr.checkHookSig(dir, hook, rule)
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// checkHookSig type checks the hook against the target function if the target
+// package can be loaded
+func (hr *hookResolver) checkHookSig(dir, hook string,
+	rule *resource.InstFuncRule) string {
+	tp, ok := hr.targets[rule.ImportPath]
+	if !ok {
+		return ""
+	}
+	target := findTargetFunc(tp.pkg, rule)
+	if target == nil {
+		return ""
+	}
+	if err, ok := hr.hookErrs[rule.Path]; ok {
+		return fmt.Sprintf("hook %s can not be type checked: %s", hook,
+			reasonOf(err))
+	}
+	// Packages shared with the target are taken from the target side, so that
+	// types of both sides are identical
+	deps := make(map[string]*types.Package, len(hr.hookDeps)+len(tp.deps))
+	maps.Copy(deps, hr.hookDeps)
+	maps.Copy(deps, tp.deps)
+	problem, err := instrument.CheckHookSig(dir, hook, target, deps,
+		hook == rule.OnEnter)
+	if err != nil {
+		return fmt.Sprintf("hook %s can not be type checked: %s", hook,
+			reasonOf(err))
+	}
+	if problem == "" {
+		return ""
+	}
+	return fmt.Sprintf("hook %s does not match the target function: %s", hook,
+		problem)
+}
+
+func checkRule(hr *hookResolver, rule resource.InstRule) []string {
+	switch r := rule.(type) {
+	case *resource.InstCallRule:
+		return checkFuncHooks(hr, &r.InstFuncRule)
+	case *resource.InstFuncRule:
+		return checkFuncHooks(hr, r)
+	case *resource.InstStructRule:
+		problems := make([]string, 0)
+		if !token.IsIdentifier(r.FieldName) {
+			problems = append(problems, "bad field name "+r.FieldName)
+		}
+		if _, err := parser.ParseExpr(r.FieldType); err != nil {
+			problems = append(problems, "bad field type "+r.FieldType)
+		}
+		return problems
+	case *resource.InstFileRule:
+		dir, err := hr.resolve(r.Path)
+		if err != nil {
+			return []string{reasonOf(err)}
+		}
+		if util.PathNotExists(filepath.Join(dir, r.FileName)) {
+			return []string{fmt.Sprintf("file %s is not found in %s",
+				r.FileName, r.Path)}
+		}
+	}
+	return nil
+}
+
+// validateRuleFile validates all rules of the rule file, it returns the number
+// of rules and problems found in the rule file
+func validateRuleFile(hr *hookResolver, name, content string) (int,
+	[]*RuleProblem) {
+	problems := make([]*RuleProblem, 0)
+	raws, err := splitRuleFile(content)
+	if err != nil {
+		return 0, append(problems, &RuleProblem{
+			RuleFile: name,
+			Index:    -1,
+			Problem:  reasonOf(err),
+		})
+	}
+	for i, raw := range raws {
+		rule, err := decodeRule(raw, true)
+		if err != nil {
+			problems = append(problems, &RuleProblem{
+				RuleFile: name,
+				Index:    i,
+				Problem:  reasonOf(err),
+			})
+			continue
+		}
+		for _, problem := range checkRule(hr, rule) {
+			problems = append(problems, &RuleProblem{
+				RuleFile: name,
+				Index:    i,
+				Target:   describeRule(rule),
+				Problem:  problem,
+			})
+		}
+	}
+	return len(raws), problems
+}
+
+func validateRules(w io.Writer, asJson bool, args []string) error {
+	withDefault := false
+	files := make([]string, 0)
+	for _, arg := range args {
+		if arg == "-default" || arg == "--default" {
+			withDefault = true
+			continue
+		}
+		files = append(files, arg)
+	}
+	// Validate configured custom rule files if no one is given
+	if len(files) == 0 && !withDefault {
+		if rf := config.GetConf().RuleJsonFiles; rf != "" {
+			files = strings.Split(rf, ",")
+		}
+		if len(files) == 0 {
+			return errc.New(errc.ErrInvalidConfig, "no rule file to validate")
+		}
+	}
+	// Rule files are read first, so that target packages of all rules are
+	// loaded at once
+	names := make([]string, 0)
+	contents := make([]string, 0)
+	if withDefault {
+		defaults, err := data.ListRuleFiles()
+		if err != nil {
+			return errc.New(errc.ErrNotExist, err.Error())
+		}
+		for _, name := range defaults {
+			content, err := data.ReadRuleFile(name)
+			if err != nil {
+				return errc.New(errc.ErrOpenFile, err.Error())
+			}
+			names = append(names, name)
+			contents = append(contents, string(content))
+		}
+	}
+	for _, file := range files {
+		content, err := util.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		names = append(names, file)
+		contents = append(contents, content)
+	}
+	hr := newHookResolver()
+	hr.loadTargets(contents)
+
+	type result struct {
+		name     string
+		rules    int
+		problems []*RuleProblem
+	}
+	results := make([]*result, 0)
+	for i, name := range names {
+		n, problems := validateRuleFile(hr, name, contents[i])
+		results = append(results, &result{name, n, problems})
+	}
+
+	all := make([]*RuleProblem, 0)
+	for _, r := range results {
+		all = append(all, r.problems...)
+	}
+	if asJson {
+		bs, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			return errc.New(errc.ErrInvalidJSON, err.Error())
+		}
+		fmt.Fprintln(w, string(bs))
+	} else {
+		for _, r := range results {
+			if len(r.problems) == 0 {
+				fmt.Fprintf(w, "%s: %d rules, ok\n", r.name, r.rules)
+				continue
+			}
+			fmt.Fprintf(w, "%s: %d rules, %d problems\n", r.name, r.rules,
+				len(r.problems))
+			for _, p := range r.problems {
+				fmt.Fprintf(w, "  %s\n", p)
+			}
+		}
+	}
+	if len(all) > 0 {
+		return errc.New(errc.ErrInvalidRule,
+			fmt.Sprintf("%d problems are found in rule files", len(all)))
+	}
+	return nil
+}
@@ -24,6 +24,9 @@ import (
 
 const (
 	MatchedRulesJsonFile = "matched_rules.json"
+	// The compiler flag that carries the rule set of the package, it's part of
+	// the package inputs for the build cache, and never seen by the compiler
+	RuleSetFlag = "-otel.ruleset="
 )
 
 // RuleBundle is a collection of rules that matched with one compilation action
@@ -33,6 +36,7 @@ type RuleBundle struct {
 	FileRules        []*InstFileRule
 	File2FuncRules   map[string]map[string][]*InstFuncRule
 	File2StructRules map[string]map[string][]*InstStructRule
+	CallRules        []*InstCallRule
 }
 
 func NewRuleBundle(importPath string) *RuleBundle {
@@ -42,6 +46,7 @@ func NewRuleBundle(importPath string) *RuleBundle {
 		FileRules:        make([]*InstFileRule, 0),
 		File2FuncRules:   make(map[string]map[string][]*InstFuncRule),
 		File2StructRules: make(map[string]map[string][]*InstStructRule),
+		CallRules:        make([]*InstCallRule, 0),
 	}
 }
 
@@ -54,7 +59,8 @@ func (rb *RuleBundle) IsValid() bool {
 	return rb != nil &&
 		(len(rb.FileRules) > 0 ||
 			len(rb.File2FuncRules) > 0 ||
-			len(rb.File2StructRules) > 0)
+			len(rb.File2StructRules) > 0 ||
+			len(rb.CallRules) > 0)
 }
 
 func (rb *RuleBundle) AddFile2FuncRule(file string, rule *InstFuncRule) error {
@@ -91,6 +97,32 @@ func (rb *RuleBundle) AddFile2StructRule(file string, rule *InstStructRule) erro
 	return nil
 }
 
+func (rb *RuleBundle) AddCallRule(rule *InstCallRule) {
+	rb.CallRules = append(rb.CallRules, rule)
+}
+
+// Merge merges rules of another bundle of the same package, rules targeting
+// the same file are considered the same
+func (rb *RuleBundle) Merge(other *RuleBundle) {
+	util.Assert(rb.ImportPath == other.ImportPath, "sanity check")
+	for file, rules := range other.File2FuncRules {
+		if _, exist := rb.File2FuncRules[file]; !exist {
+			rb.File2FuncRules[file] = rules
+		}
+	}
+	for file, rules := range other.File2StructRules {
+		if _, exist := rb.File2StructRules[file]; !exist {
+			rb.File2StructRules[file] = rules
+		}
+	}
+	if len(rb.FileRules) == 0 {
+		rb.FileRules = other.FileRules
+	}
+	if len(rb.CallRules) == 0 {
+		rb.CallRules = other.CallRules
+	}
+}
+
 func (rb *RuleBundle) SetPackageName(name string) {
 	rb.PackageName = name
 }
@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+)
+
+const OverlayJsonFile = "overlay.json"
+
+// OverlayJSON is the format of the -overlay file, see "go help build"
+type OverlayJSON struct {
+	Replace map[string]string
+}
+
+// LoadOverlay loads the overlay passed to the instrumented build, it maps
+// absolute paths of files to where their content is actually located, or to
+// the empty string if they are deleted
+func LoadOverlay() (map[string]string, error) {
+	path := util.GetPreprocessLogPath(OverlayJsonFile)
+	if !util.PathExists(path) {
+		return map[string]string{}, nil
+	}
+	data, err := util.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ov := &OverlayJSON{}
+	err = json.Unmarshal([]byte(data), ov)
+	if err != nil {
+		return nil, errc.New(errc.ErrInvalidJSON, "bad "+path)
+	}
+	if ov.Replace == nil {
+		ov.Replace = map[string]string{}
+	}
+	return ov.Replace, nil
+}
+
+// ListHookFiles lists go files of the hook package in dir as the go command
+// sees them, i.e. with the overlay applied, and returns where their content is
+// located. Files generated into hook packages of the pkg module are placed via
+// overlay, as the extracted pkg module is shared and never written to.
+func ListHookFiles(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errc.New(errc.ErrAbsPath, err.Error())
+	}
+	overlay, err := LoadOverlay()
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errc.New(errc.ErrReadDir, err.Error())
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			path := filepath.Join(dir, entry.Name())
+			files[path] = path
+		}
+	}
+	for path, target := range overlay {
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		if target == "" {
+			delete(files, path)
+		} else {
+			files[path] = target
+		}
+	}
+	names := make([]string, 0, len(files))
+	for path := range files {
+		name := filepath.Base(path)
+		if !util.IsGoFile(name) || util.IsGoTestFile(name) ||
+			strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, path)
+	}
+	sort.Strings(names)
+	located := make([]string, 0, len(names))
+	for _, path := range names {
+		located = append(located, files[path])
+	}
+	return located, nil
+}
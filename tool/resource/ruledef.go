@@ -15,10 +15,13 @@ package resource
 
 import (
 	"encoding/json"
+	"go/token"
+	"regexp"
 	"strings"
 
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
 	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/util"
+	"github.com/dave/dst"
 )
 
 // -----------------------------------------------------------------------------
@@ -36,12 +39,20 @@ import (
 // - InstStructRule: Instrumentation rule for a specific struct type
 // - InstFileRule: Instrumentation rule for a specific file
 
+// RuleSchemaVersion is the latest schema version of rule files. A rule file is
+// either a plain array of rules, or an object that declares the schema version
+// it is written against, i.e. {"SchemaVersion": 1, "Rules": [...]}. Plain arrays
+// are considered to be written against the first version.
+const RuleSchemaVersion = 1
+
 type InstRule interface {
 	GetVersion() string    // GetVersion returns the version of the rule
 	GetGoVersion() string  // GetGoVersion returns the go version of the rule
 	GetImportPath() string // GetImportPath returns import path of the rule
 	GetPath() string       // GetPath returns the local path of the rule
 	SetPath(path string)   // SetPath sets the local path of the rule
+	GetRuleFile() string   // GetRuleFile returns where the rule is defined
+	SetRuleFile(f string)  // SetRuleFile sets where the rule is defined
 	String() string        // String returns string representation of rule
 	Verify() error         // Verify checks the rule is valid
 }
@@ -58,6 +69,10 @@ type InstBaseRule struct {
 	// Import path of the rule, e.g. "github.com/gin-gonic/gin", it desginates
 	// the import path of rule, all other import path will not be instrumented
 	ImportPath string `json:"ImportPath,omitempty"`
+	// Rule file where the rule is defined, e.g. "gin.json" for default rules
+	// or absolute path for custom rules, it's filled by the tool rather than
+	// rule authors
+	RuleFile string `json:"RuleFile,omitempty"`
 }
 
 func (rule *InstBaseRule) GetVersion() string {
@@ -80,6 +95,14 @@ func (rule *InstBaseRule) SetPath(path string) {
 	rule.Path = path
 }
 
+func (rule *InstBaseRule) GetRuleFile() string {
+	return rule.RuleFile
+}
+
+func (rule *InstBaseRule) SetRuleFile(file string) {
+	rule.RuleFile = file
+}
+
 // InstFuncRule finds specific function call and instrument by adding new code
 type InstFuncRule struct {
 	InstBaseRule
@@ -95,6 +118,124 @@ type InstFuncRule struct {
 	OnEnter string `json:"OnEnter,omitempty"`
 	// OnExit callback, called after original function
 	OnExit string `json:"OnExit,omitempty"`
+	// Glob indicates the function name is a glob pattern rather than regexp,
+	// e.g. "Handle*", where "*" matches any sequence and "?" matches any single
+	// character
+	Glob bool `json:"Glob,omitempty"`
+	// ExportedOnly restricts the rule to exported functions only
+	ExportedOnly bool `json:"ExportedOnly,omitempty"`
+	// ContextFirst restricts the rule to functions whose first parameter is
+	// context.Context
+	ContextFirst bool `json:"ContextFirst,omitempty"`
+	// ReturnsError restricts the rule to functions whose last result is error
+	ReturnsError bool `json:"ReturnsError,omitempty"`
+	// Span indicates the rule is backed by the built-in hooks, which create
+	// an internal span named after the function and record the returned error,
+	// no hook code is required
+	Span bool `json:"Span,omitempty"`
+	// SpanParams captures parameters as span attributes, it maps attribute key
+	// to the parameter index, where the receiver is the first parameter
+	SpanParams map[string]int `json:"SpanParams,omitempty"`
+}
+
+// globToRegexp converts the glob pattern to equivalent regexp
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// GetFunctionRegexp returns the regexp of the function name
+func (rule *InstFuncRule) GetFunctionRegexp() string {
+	if rule.Glob {
+		return globToRegexp(rule.Function)
+	}
+	return rule.Function
+}
+
+// IsPattern checks if the rule targets a batch of functions rather than a
+// specific one, in which case the hooks are generic and do not follow the
+// signature of the target function.
+func (rule *InstFuncRule) IsPattern() bool {
+	return rule.Glob || rule.ExportedOnly || rule.ContextFirst ||
+		rule.ReturnsError ||
+		regexp.QuoteMeta(rule.Function) != rule.Function
+}
+
+// MatchFuncDecl checks if the function declaration matches the rule
+func (rule *InstFuncRule) MatchFuncDecl(decl dst.Decl) bool {
+	if !util.MatchFuncDecl(decl, rule.GetFunctionRegexp(), rule.ReceiverType) {
+		return false
+	}
+	funcDecl := decl.(*dst.FuncDecl)
+	// Functions without body(e.g. implemented in assembly) can not be
+	// instrumented, they are skipped silently when matching by pattern
+	if rule.IsPattern() && funcDecl.Body == nil {
+		return false
+	}
+	if rule.ExportedOnly && !token.IsExported(funcDecl.Name.Name) {
+		return false
+	}
+	if rule.ContextFirst && !util.IsContextFirstParam(funcDecl) {
+		return false
+	}
+	if rule.ReturnsError && !util.IsErrorLastResult(funcDecl) {
+		return false
+	}
+	return true
+}
+
+// InstCallRule finds calls to specific function and instrument the call sites
+// by wrapping them, rather than rewriting the called function itself. This is
+// useful for functions that can not be recompiled cheaply, e.g. functions from
+// the standard library or implemented in assembly, or when only calls from
+// certain packages are interesting. The ImportPath designates the package of
+// the called function, hooks follow the same convention as InstFuncRule.
+type InstCallRule struct {
+	InstFuncRule
+	// Call indicates the rule is a call rule, it's required to distinguish
+	// from func rules
+	Call bool `json:"Call,omitempty"`
+	// Callers restricts the packages whose call sites are instrumented, it
+	// follows the syntax of package patterns, e.g. "github.com/foo/...", all
+	// packages of the main module by default
+	Callers []string `json:"Callers,omitempty"`
+	// Import path of the hook package, it's filled by the tool rather than
+	// rule authors, as the local path of the rule is rectified before
+	// instrumentation
+	HookImportPath string `json:"HookImportPath,omitempty"`
+}
+
+// MatchCallee checks if the called function matches the rule, where recv is
+// the receiver type name of the called method, e.g. "*Client", or empty for
+// plain functions
+func (rule *InstCallRule) MatchCallee(pkgPath, name, recv string) bool {
+	if pkgPath != rule.ImportPath || !rule.MatchCalleeName(name) {
+		return false
+	}
+	if rule.ReceiverType == "" {
+		return recv == ""
+	}
+	return regexp.MustCompile("^" + rule.ReceiverType + "$").MatchString(recv)
+}
+
+// MatchCalleeName checks if the name of the called function or method matches
+// the rule, regardless of the package and the receiver
+func (rule *InstCallRule) MatchCalleeName(name string) bool {
+	re := regexp.MustCompile("^" + rule.GetFunctionRegexp() + "$")
+	if !re.MatchString(name) {
+		return false
+	}
+	return !rule.ExportedOnly || token.IsExported(name)
 }
 
 // InstStructRule finds specific struct type and instrument by adding new field
@@ -126,12 +267,17 @@ func (rule *InstStructRule) String() string {
 	bs, _ := json.Marshal(rule)
 	return string(bs)
 }
+func (rule *InstCallRule) String() string {
+	bs, _ := json.Marshal(rule)
+	return string(bs)
+}
 func (rule *InstFileRule) String() string {
 	bs, _ := json.Marshal(rule)
 	return string(bs)
 }
 
-// Verify checks the rule is valid
+// verifyRule checks the common part of the rule is valid, where checkPath
+// requires the rule to have hook code
 func verifyRule(rule *InstBaseRule, checkPath bool) error {
 	if checkPath {
 		if rule.Path == "" {
@@ -145,27 +291,17 @@ func verifyRule(rule *InstBaseRule, checkPath bool) error {
 	// If version is specified, it should be in the format of [start,end)
 	for _, v := range []string{rule.Version, rule.GoVersion} {
 		if v != "" {
-			if !strings.Contains(v, "[") ||
-				!strings.Contains(v, ")") ||
-				!strings.Contains(v, ",") ||
-				strings.Contains(v, "v") {
-				return errc.New(errc.ErrInvalidRule, "bad version "+v)
+			_, err := util.ParseVersionRange(v)
+			if err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
-func verifyRuleBase(rule *InstBaseRule) error {
-	return verifyRule(rule, false)
-}
-
-func verifyRuleBaseWithoutPath(rule *InstBaseRule) error {
-	return verifyRule(rule, true)
-}
-
 func (rule *InstFileRule) Verify() error {
-	err := verifyRuleBase(&rule.InstBaseRule)
+	err := verifyRule(&rule.InstBaseRule, true)
 	if err != nil {
 		return err
 	}
@@ -179,26 +315,62 @@ func (rule *InstFileRule) Verify() error {
 }
 
 func (rule *InstFuncRule) Verify() error {
-	var err error
-	if rule.UseRaw {
-		err = verifyRuleBaseWithoutPath(&rule.InstBaseRule)
-	} else {
-		err = verifyRuleBase(&rule.InstBaseRule)
-	}
+	// Raw code is inserted as it is, there is no hook code
+	err := verifyRule(&rule.InstBaseRule, !rule.UseRaw)
 	if err != nil {
 		return err
 	}
 	if rule.Function == "" {
 		return errc.New(errc.ErrInvalidRule, "empty function name")
 	}
+	if _, err := regexp.Compile(rule.GetFunctionRegexp()); err != nil {
+		return errc.New(errc.ErrInvalidRule, "bad function pattern "+
+			rule.Function)
+	}
+	if _, err := regexp.Compile(rule.ReceiverType); err != nil {
+		return errc.New(errc.ErrInvalidRule, "bad receiver type pattern "+
+			rule.ReceiverType)
+	}
 	if rule.OnEnter == "" && rule.OnExit == "" {
 		return errc.New(errc.ErrInvalidRule, "empty hook")
 	}
+	if rule.Span && rule.UseRaw {
+		return errc.New(errc.ErrInvalidRule, "span rule can not use raw code")
+	}
+	indices := make(map[int]bool, len(rule.SpanParams))
+	for key, index := range rule.SpanParams {
+		if key == "" || index < 0 || indices[index] {
+			return errc.New(errc.ErrInvalidRule, "bad span param "+key)
+		}
+		indices[index] = true
+	}
+	return nil
+}
+
+func (rule *InstCallRule) Verify() error {
+	err := rule.InstFuncRule.Verify()
+	if err != nil {
+		return err
+	}
+	if rule.UseRaw || rule.Span {
+		return errc.New(errc.ErrInvalidRule,
+			"call rule can not use raw code or span")
+	}
+	// Version range of the called package is unknown at call sites
+	if rule.Version != "" {
+		return errc.New(errc.ErrInvalidRule,
+			"call rule does not support version range")
+	}
+	for _, caller := range rule.Callers {
+		if caller == "" || strings.ContainsAny(caller, " \t") {
+			return errc.New(errc.ErrInvalidRule, "bad caller pattern "+caller)
+		}
+	}
 	return nil
 }
 
 func (rule *InstStructRule) Verify() error {
-	err := verifyRuleBaseWithoutPath(&rule.InstBaseRule)
+	err := verifyRule(&rule.InstBaseRule, false)
 	if err != nil {
 		return err
 	}
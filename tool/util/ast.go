@@ -152,13 +152,23 @@ func IsInterfaceType(typ dst.Expr) bool {
 	return ok
 }
 
+// IsAnyType checks if the type is the predeclared any
+func IsAnyType(typ dst.Expr) bool {
+	ident, ok := typ.(*dst.Ident)
+	return ok && ident.Name == "any" && ident.Path == ""
+}
+
 func IsEllipsis(typ dst.Expr) bool {
 	_, ok := typ.(*dst.Ellipsis)
 	return ok
 }
 
+// InterfaceType returns interface{}, braces are marked as present so that it is
+// printed on one line
 func InterfaceType() *dst.InterfaceType {
-	return &dst.InterfaceType{Methods: &dst.FieldList{List: nil}}
+	return &dst.InterfaceType{
+		Methods: &dst.FieldList{Opening: true, List: nil, Closing: true},
+	}
 }
 
 func ArrayType(elem dst.Expr) *dst.ArrayType {
@@ -360,24 +370,7 @@ func MatchFuncDecl(decl dst.Decl, function string, receiverType string) bool {
 		if !HasReceiver(funcDecl) {
 			return re.MatchString("")
 		}
-		switch recvTypeExpr := funcDecl.Recv.List[0].Type.(type) {
-		case *dst.StarExpr:
-			if _, ok := recvTypeExpr.X.(*dst.Ident); !ok {
-				// This is a generic type, we don't support it yet
-				return false
-			}
-			t := "*" + recvTypeExpr.X.(*dst.Ident).Name
-			return re.MatchString(t)
-		case *dst.Ident:
-			t := recvTypeExpr.Name
-			return re.MatchString(t)
-		case *dst.IndexExpr:
-			// This is a generic type, we don't support it yet
-			return false
-		default:
-			msg := fmt.Sprintf("unexpected receiver type: %T", recvTypeExpr)
-			UnimplementedT(msg)
-		}
+		return re.MatchString(RecvTypeName(funcDecl))
 	} else {
 		if HasReceiver(funcDecl) {
 			return false
@@ -386,6 +379,68 @@ func MatchFuncDecl(decl dst.Decl, function string, receiverType string) bool {
 	return true
 }
 
+// FuncDeclName returns the name of the function declaration, the receiver type
+// is included for methods, e.g. "(*Server).Handle"
+func FuncDeclName(decl *dst.FuncDecl) string {
+	if !HasReceiver(decl) {
+		return decl.Name.Name
+	}
+	recv := RecvTypeName(decl)
+	if recv == "" {
+		recv = "?"
+	}
+	return "(" + recv + ")." + decl.Name.Name
+}
+
+// RecvTypeName returns the receiver type name of the method, type arguments
+// of generic receivers are stripped, e.g. "*Cache" for "*Cache[K, V]"
+func RecvTypeName(decl *dst.FuncDecl) string {
+	if !HasReceiver(decl) {
+		return ""
+	}
+	typ := decl.Recv.List[0].Type
+	star := ""
+	if t, ok := typ.(*dst.StarExpr); ok {
+		star = "*"
+		typ = t.X
+	}
+	switch t := typ.(type) {
+	case *dst.IndexExpr:
+		typ = t.X
+	case *dst.IndexListExpr:
+		typ = t.X
+	}
+	if ident, ok := typ.(*dst.Ident); ok {
+		return star + ident.Name
+	}
+	return ""
+}
+
+// IsContextFirstParam checks if the first parameter of the function is of type
+// context.Context, the receiver is not counted as parameter
+func IsContextFirstParam(decl *dst.FuncDecl) bool {
+	params := decl.Type.Params
+	if params == nil || len(params.List) == 0 {
+		return false
+	}
+	sel, ok := params.List[0].Type.(*dst.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*dst.Ident)
+	return ok && pkg.Name == "context" && sel.Sel.Name == "Context"
+}
+
+// IsErrorLastResult checks if the last result of the function is of type error
+func IsErrorLastResult(decl *dst.FuncDecl) bool {
+	results := decl.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return false
+	}
+	ident, ok := results.List[len(results.List)-1].Type.(*dst.Ident)
+	return ok && ident.Name == "error"
+}
+
 func MatchStructDecl(decl dst.Decl, structType string) bool {
 	if genDecl, ok := decl.(*dst.GenDecl); ok {
 		if genDecl.Tok == token.TYPE {
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/dave/dst"
+)
+
+const genericSource = `package main
+
+type Cache[K comparable, V any] struct{}
+
+func (c *Cache[K, V]) Get(k K) (V, bool) { var v V; return v, false }
+
+func (c Cache[_, V]) Len() int { return 0 }
+
+type Set[T comparable] struct{}
+
+func (s *Set[T]) Add(t T) {}
+
+func Map[S ~[]E, E any, R any](s S, f func(E) R) []R { return nil }
+
+type Server struct{}
+
+func (s *Server) Serve() {}
+`
+
+func TestMatchFuncDecl(t *testing.T) {
+	root, err := NewAstParser().ParseSource(genericSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := func(name string) *dst.FuncDecl {
+		for _, decl := range root.Decls {
+			if f, ok := decl.(*dst.FuncDecl); ok && f.Name.Name == name {
+				return f
+			}
+		}
+		t.Fatalf("no function %s", name)
+		return nil
+	}
+	tests := []struct {
+		name         string
+		function     string
+		receiverType string
+		want         bool
+		wantName     string
+	}{
+		{"Get", "Get", "\\*Cache", true, "(*Cache).Get"},
+		{"Get", "Get", "Cache", false, "(*Cache).Get"},
+		{"Len", "Len", "Cache", true, "(Cache).Len"},
+		{"Len", "Len", ".*Cache", true, "(Cache).Len"},
+		{"Add", "Add", "\\*Set", true, "(*Set).Add"},
+		{"Map", "Map", "", true, "Map"},
+		{"Map", "Map", "\\*Cache", false, "Map"},
+		{"Serve", "Serve", "\\*Server", true, "(*Server).Serve"},
+		{"Serve", "Serve", "", false, "(*Server).Serve"},
+	}
+	for _, tt := range tests {
+		decl := find(tt.name)
+		got := MatchFuncDecl(decl, tt.function, tt.receiverType)
+		if got != tt.want {
+			t.Errorf("MatchFuncDecl(%s, %q) = %v, want %v",
+				tt.name, tt.receiverType, got, tt.want)
+		}
+		if name := FuncDeclName(decl); name != tt.wantName {
+			t.Errorf("FuncDeclName(%s) = %q, want %q", tt.name, name, tt.wantName)
+		}
+	}
+}
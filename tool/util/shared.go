@@ -16,20 +16,26 @@ package util
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/alibaba/opentelemetry-go-auto-instrumentation/tool/errc"
 	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 const (
 	GoBuildIgnoreComment = "//go:build ignore"
 	GoModFile            = "go.mod"
 	GoSumFile            = "go.sum"
+	GoWorkFile           = "go.work"
 	GoWorkSumFile        = "go.work.sum"
 	DebugLogFile         = "debug.log"
 	TempBuildDir         = ".otel-build"
+	BuildDirEnv          = "OTELTOOL_BUILD_DIR"
 )
 
 const (
@@ -48,7 +54,7 @@ func AssertGoBuild(args []string) {
 	if !strings.Contains(args[0], "go") {
 		Assert(false, "invalid go build command %v", args)
 	}
-	if args[1] != "build" && args[1] != "install" {
+	if args[1] != "build" && args[1] != "install" && args[1] != "test" {
 		Assert(false, "invalid go build command %v", args)
 	}
 }
@@ -80,24 +86,39 @@ func IsCompileCommand(line string) bool {
 	return true
 }
 
+// GetBuildDir returns the private directory of the current build, where files
+// of both phases are kept, so that concurrent builds never step on each other.
+// It's created by the preprocess phase and passed down to the instrument phase
+// via BuildDirEnv.
+func GetBuildDir() string {
+	if dir := os.Getenv(BuildDirEnv); dir != "" {
+		return dir
+	}
+	return TempBuildDir
+}
+
 func GetTempBuildDir() string {
-	return filepath.Join(TempBuildDir, GetRunPhase().String())
+	return filepath.Join(GetBuildDir(), GetRunPhase().String())
 }
 
 func GetTempBuildDirWith(name string) string {
 	return filepath.Join(TempBuildDir, name)
 }
 
+func GetBuildDirWith(name string) string {
+	return filepath.Join(GetBuildDir(), name)
+}
+
 func GetLogPath(name string) string {
 	return filepath.Join(GetTempBuildDir(), name)
 }
 
 func GetInstrumentLogPath(name string) string {
-	return filepath.Join(TempBuildDir, PInstrument, name)
+	return filepath.Join(GetBuildDir(), PInstrument, name)
 }
 
 func GetPreprocessLogPath(name string) string {
-	return filepath.Join(TempBuildDir, PPreprocess, name)
+	return filepath.Join(GetBuildDir(), PPreprocess, name)
 }
 
 func GetVarNameOfFunc(fn string) string {
@@ -132,6 +153,110 @@ func IsModPath(path string) bool {
 	return module.CheckPath(path) == nil
 }
 
+// packagePatterns caches compiled package patterns, as patterns are matched
+// against every package of the build
+var packagePatterns sync.Map
+
+// MatchPackagePattern reports whether the import path matches the package
+// pattern, which follows the same syntax as "go help packages", i.e. "..."
+// wildcard matches any string and "foo/..." also matches "foo" itself.
+func MatchPackagePattern(pattern, importPath string) bool {
+	if !strings.Contains(pattern, "...") {
+		return pattern == importPath
+	}
+	if re, ok := packagePatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp).MatchString(importPath)
+	}
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	compiled := regexp.MustCompile("^" + re + "$")
+	packagePatterns.Store(pattern, compiled)
+	return compiled.MatchString(importPath)
+}
+
+// VersionRange is a range of semantic versions in the format of [start,end),
+// where start is inclusive and end is exclusive, either of them can be omitted,
+// which means the range is open-ended. Versions are stored with "v" prefix.
+type VersionRange struct {
+	Start string
+	End   string
+}
+
+// ParseVersionRange parses the version range of rules, e.g. "[1.9.1,1.9.2)",
+// versions in the range are written without "v" prefix, and whitespaces are
+// ignored.
+func ParseVersionRange(vr string) (*VersionRange, error) {
+	bad := func(reason string) error {
+		return errc.New(errc.ErrInvalidRule,
+			fmt.Sprintf("bad version range %s: %s", vr, reason)).
+			With("expect", "[start,end)")
+	}
+	s := strings.ReplaceAll(vr, " ", "")
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, ")") {
+		return nil, bad("not in the format of [start,end)")
+	}
+	start, end, ok := strings.Cut(s[1:len(s)-1], ",")
+	if !ok || strings.Contains(end, ",") {
+		return nil, bad("expect exactly one comma")
+	}
+	r := &VersionRange{}
+	for _, bound := range []struct {
+		version string
+		dst     *string
+	}{{start, &r.Start}, {end, &r.End}} {
+		if bound.version == "" {
+			continue
+		}
+		if strings.HasPrefix(bound.version, "v") {
+			return nil, bad(bound.version + " should not start with v")
+		}
+		if !semver.IsValid("v" + bound.version) {
+			return nil, bad(bound.version + " is not a semantic version")
+		}
+		*bound.dst = "v" + bound.version
+	}
+	if r.Start == "" && r.End == "" {
+		return nil, bad("both start and end are omitted")
+	}
+	if r.Start != "" && r.End != "" && semver.Compare(r.Start, r.End) >= 0 {
+		return nil, bad("start is not less than end")
+	}
+	return r, nil
+}
+
+// Contains checks if the version, with "v" prefix, is within the range
+func (r *VersionRange) Contains(version string) bool {
+	if r.Start != "" && semver.Compare(version, r.Start) < 0 {
+		return false
+	}
+	if r.End != "" && semver.Compare(version, r.End) >= 0 {
+		return false
+	}
+	return true
+}
+
+// MatchVersion checks if the version string matches the version range in the
+// rule, see ParseVersionRange for the format of the version range. If the rule
+// version string is empty, it always matches.
+func MatchVersion(version string, ruleVersion string) (bool, error) {
+	// Fast path, always match if the rule version is not specified
+	if ruleVersion == "" {
+		return true, nil
+	}
+	if !strings.HasPrefix(version, "v") {
+		return false, errc.New(errc.ErrMatchRule,
+			fmt.Sprintf("invalid version %v", version))
+	}
+	r, err := ParseVersionRange(ruleVersion)
+	if err != nil {
+		return false, err
+	}
+	return r.Contains(version), nil
+}
+
 func IsGoFile(path string) bool {
 	return strings.HasSuffix(path, ".go")
 }
@@ -167,3 +167,67 @@ func TestMatchVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		vr      string
+		start   string
+		end     string
+		wantErr bool
+	}{
+		{name: "full range", vr: "[1.9.1,1.9.2)", start: "v1.9.1", end: "v1.9.2"},
+		{name: "only start", vr: "[1.22,)", start: "v1.22"},
+		{name: "only end", vr: "[,2.0.0)", end: "v2.0.0"},
+		{name: "prerelease", vr: "[1.0.0-dev,1.0.0)", start: "v1.0.0-dev",
+			end: "v1.0.0"},
+		{name: "open range", vr: "[,)", wantErr: true},
+		{name: "not semver", vr: "[1.x,)", wantErr: true},
+		{name: "v prefix", vr: "[v1.0.0,)", wantErr: true},
+		{name: "two commas", vr: "[1.0.0,1.1.0,1.2.0)", wantErr: true},
+		{name: "empty range", vr: "[1.2.0,1.1.0)", wantErr: true},
+		{name: "inclusive end", vr: "[1.0.0,1.1.0]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseVersionRange(tt.vr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersionRange() error = %v, wantErr %v", err,
+					tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.Start != tt.start || r.End != tt.end {
+				t.Fatalf("ParseVersionRange() = [%s,%s), want [%s,%s)",
+					r.Start, r.End, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		importPath string
+		want       bool
+	}{
+		{"github.com/foo/bar", "github.com/foo/bar", true},
+		{"github.com/foo/bar", "github.com/foo/bar/baz", false},
+		{"github.com/foo/...", "github.com/foo", true},
+		{"github.com/foo/...", "github.com/foo/bar/baz", true},
+		{"github.com/foo/...", "github.com/foobar", false},
+		{"github.com/.../internal", "github.com/foo/internal", true},
+		{"github.com/.../internal", "github.com/foo/internal/x", false},
+	}
+	for _, tt := range tests {
+		// Match twice to cover the cached pattern as well
+		for i := 0; i < 2; i++ {
+			got := MatchPackagePattern(tt.pattern, tt.importPath)
+			if got != tt.want {
+				t.Errorf("MatchPackagePattern(%q, %q) = %v, want %v",
+					tt.pattern, tt.importPath, got, tt.want)
+			}
+		}
+	}
+}
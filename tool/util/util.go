@@ -292,3 +292,27 @@ func Jsonify(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
 }
+
+// LockFile acquires the lock at path by creating the file exclusively, it
+// waits for the current holder to release it, or takes it over if the holder
+// has not released it for longer than stale, e.g. it was killed. The returned
+// function releases the lock.
+func LockFile(path string, stale time.Duration) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errc.New(errc.ErrCreateFile, err.Error())
+		}
+		info, err := os.Stat(path)
+		if err == nil && time.Since(info.ModTime()) > stale {
+			_ = os.Remove(path)
+			continue
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}